@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"google.golang.org/genai"
+)
+
+// Rate limits (429) and overloads (5xx) usually clear within seconds, so a
+// model call tries each model ai.attempts times before falling back, waiting
+// as long as the provider asks when it says. Retries share the call's
+// timeouts.ai with the fallbacks; an item whose summary still fails this way
+// is left for the next run instead of going out without one. Each attempt is
+// also cut off after timeouts.ai_attempt, so a request that hangs is retried
+// like a 503 instead of using up the whole call.
+
+// retryModel calls a model until it answers, fails for good or runs out of
+// attempts
+func retryModel(ctx context.Context, cfg AIConfig, model string, call func(context.Context) (*ai.ModelResponse, error)) (*ai.ModelResponse, error) {
+	for n := 1; ; n++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeouts.AIAttempt)
+		resp, err := call(attemptCtx)
+		cancel()
+		if err == nil || n >= cfg.attempts() || ctx.Err() != nil {
+			return resp, err
+		}
+		hint, ok := transientAIError(err)
+		if !ok {
+			return resp, err
+		}
+		delay := cfg.retryDelay(n, hint)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		fmt.Printf("🔁 %s failed (%v), retrying in %s\n", model, err, delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// transientAIError reports whether a model error is worth retrying: throttling,
+// overload and network trouble. The duration is how long the provider asked
+// to wait, 0 if it didn't say.
+func transientAIError(err error) (time.Duration, bool) {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return geminiRetryDelay(apiErr), retryableStatus(apiErr.Code)
+	}
+	var providerErr *providerError
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter, retryableStatus(providerErr.Status)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return 0, true // the attempt hung past timeouts.ai_attempt, or the whole call ran out
+	}
+	return 0, retryableError(err)
+}
+
+// geminiRetryDelay reads the RetryInfo detail Gemini sends with a 429, e.g.
+// {"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "37s"}
+func geminiRetryDelay(err genai.APIError) time.Duration {
+	for _, detail := range err.Details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		if s, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(s); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
+// attempts is how many times each model is tried, default 3
+func (c AIConfig) attempts() int {
+	if c.Attempts <= 0 {
+		return 3
+	}
+	return c.Attempts
+}
+
+// retryDelay is the wait before retry n (1-based): what the provider asked
+// for, or retry_delay doubled each time with ±50% jitter
+func (c AIConfig) retryDelay(n int, hint time.Duration) time.Duration {
+	if hint > 0 {
+		return hint
+	}
+	base := c.RetryDelay
+	if base <= 0 {
+		base = 2 * time.Second
+	}
+	d := base << (n - 1)
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
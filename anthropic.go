@@ -0,0 +1,143 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+)
+
+// AnthropicConfig points anthropic/* models (e.g. anthropic/claude-sonnet-4-5)
+// at Anthropic's Messages API
+type AnthropicConfig struct {
+	APIKey    string `yaml:"api_key"`
+	BaseURL   string `yaml:"base_url"`   // default https://api.anthropic.com/v1
+	MaxTokens int    `yaml:"max_tokens"` // cap on each reply, which the API requires; default 4096
+}
+
+const (
+	ANTHROPIC_BASE_URL   = "https://api.anthropic.com/v1"
+	ANTHROPIC_VERSION    = "2023-06-01"
+	ANTHROPIC_MAX_TOKENS = 4096
+)
+
+// anthropicPlugin resolves anthropic/<model> names for genkit
+type anthropicPlugin struct {
+	cfg AnthropicConfig
+}
+
+func (p *anthropicPlugin) Name() string { return PROVIDER_ANTHROPIC }
+
+func (p *anthropicPlugin) Init(ctx context.Context) []api.Action { return nil }
+
+func (p *anthropicPlugin) ListActions(ctx context.Context) []api.ActionDesc { return nil }
+
+func (p *anthropicPlugin) ResolveAction(atype api.ActionType, name string) api.Action {
+	if atype != api.ActionTypeModel {
+		return nil
+	}
+	return providerModel(PROVIDER_ANTHROPIC, name, func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		return p.generate(ctx, name, req)
+	})
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   float64            `json:"temperature,omitempty"`
+	TopP          float64            `json:"top_p,omitempty"`
+	TopK          int                `json:"top_k,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// generate sends one Messages API request
+func (p *anthropicPlugin) generate(ctx context.Context, model string, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+	if p.cfg.APIKey == "" {
+		return nil, fmt.Errorf("anthropic: no API key (set ai.anthropic.api_key or ANTHROPIC_API_KEY)")
+	}
+
+	body := anthropicRequest{Model: model}
+	var system []string
+	for _, m := range req.Messages {
+		switch m.Role {
+		case ai.RoleSystem:
+			// The API takes system instructions apart from the conversation
+			system = append(system, messageText(m))
+		case ai.RoleUser:
+			body.Messages = append(body.Messages, anthropicMessage{Role: "user", Content: messageText(m)})
+		case ai.RoleModel:
+			body.Messages = append(body.Messages, anthropicMessage{Role: "assistant", Content: messageText(m)})
+		}
+	}
+	body.System = strings.Join(system, "\n\n")
+	cfg := commonConfig(req)
+	body.MaxTokens = cmp.Or(cfg.MaxOutputTokens, p.cfg.MaxTokens, ANTHROPIC_MAX_TOKENS)
+	body.Temperature, body.TopP, body.TopK, body.StopSequences = cfg.Temperature, cfg.TopP, cfg.TopK, cfg.StopSequences
+
+	endpoint := strings.TrimSuffix(cmp.Or(p.cfg.BaseURL, ANTHROPIC_BASE_URL), "/")
+	header := http.Header{}
+	header.Set("x-api-key", p.cfg.APIKey)
+	header.Set("anthropic-version", ANTHROPIC_VERSION)
+
+	start := time.Now()
+	var out anthropicResponse
+	if err := postProvider(ctx, PROVIDER_ANTHROPIC, endpoint+"/messages", header, body, &out); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return &ai.ModelResponse{
+		Message:      ai.NewModelTextMessage(text.String()),
+		FinishReason: anthropicFinishReason(out.StopReason),
+		LatencyMs:    float64(time.Since(start).Milliseconds()),
+		Usage: &ai.GenerationUsage{
+			InputTokens:  out.Usage.InputTokens,
+			OutputTokens: out.Usage.OutputTokens,
+			TotalTokens:  out.Usage.InputTokens + out.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+func anthropicFinishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return ai.FinishReasonStop
+	case "max_tokens":
+		return ai.FinishReasonLength
+	case "refusal":
+		return ai.FinishReasonBlocked
+	case "":
+		return ai.FinishReasonUnknown
+	default:
+		return ai.FinishReasonOther
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const DEFAULT_API_MAX_ITEMS = 50
+const DEFAULT_API_RATE_LIMIT = 60
+
+type APIConfig struct {
+	Listen     string `yaml:"listen"`      // e.g. ":8080"; empty disables the public API
+	MaxItems   int    `yaml:"max_items"`   // cap on ?limit, default 50
+	RateLimit  int    `yaml:"rate_limit"`  // requests per minute per client IP, default 60
+	CORSOrigin string `yaml:"cors_origin"` // Access-Control-Allow-Origin, default "*"
+}
+
+// publicItem is the subset of an archived item safe to publish
+type publicItem struct {
+	Title   string    `json:"title"`
+	Link    string    `json:"link"`
+	Summary string    `json:"summary"`
+	Tags    []string  `json:"tags"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// recentHandler serves GET /api/recent?limit=N with the newest summarized items
+func recentHandler(archive *Archive, cfg APIConfig) http.HandlerFunc {
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = DEFAULT_API_MAX_ITEMS
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := maxItems
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxItems)
+		}
+
+		// Skipped and unsummarized items aren't published, so they don't count towards limit
+		items, err := archive.RecentWhere(limit, func(item *ArchivedItem) bool {
+			return item.Summary != ""
+		})
+		if err != nil {
+			http.Error(w, "archive unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		out := make([]publicItem, 0, len(items))
+		for _, item := range items {
+			tags := item.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			out = append(out, publicItem{
+				Title:   item.Title,
+				Link:    item.Link,
+				Summary: item.Summary,
+				Tags:    tags,
+				SentAt:  item.SentAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+// withRateLimit answers 429 once a client IP exceeds perMinute requests
+func withRateLimit(perMinute int, next http.Handler) http.Handler {
+	limiter := newKeyedLimiter(float64(perMinute), float64(perMinute)/60)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if !limiter.Allow(ip) {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withCORS(origin string, next http.Handler) http.Handler {
+	if origin == "" {
+		origin = "*"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveAPI runs the public read-only JSON API until ctx is cancelled
+func serveAPI(ctx context.Context, archive *Archive, cfg APIConfig) error {
+	rateLimit := cfg.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = DEFAULT_API_RATE_LIMIT
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/recent", withCORS(cfg.CORSOrigin, withRateLimit(rateLimit, recentHandler(archive, cfg))))
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("🌐 Public API listening on %s\n", cfg.Listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("api server: %w", err)
+	}
+	return nil
+}
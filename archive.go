@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ARCHIVE_FILE = "archive.jsonl"
+
+// ArchivedItem is a posted item together with the summary that went out
+type ArchivedItem struct {
+	ID      string    `json:"id"`
+	Feed    string    `json:"feed"`
+	Title   string    `json:"title"`
+	Link    string    `json:"link"`
+	Summary string    `json:"summary,omitempty"`
+	Content string    `json:"content,omitempty"` // extracted article text, dropped after retention.content_days
+	Tags    []string  `json:"tags,omitempty"`
+	Variant string    `json:"variant,omitempty"` // prompt A/B variant, see prompts.go
+	SentAt  time.Time `json:"sent_at"`
+
+	ChatID    string `json:"chat_id,omitempty"` // where it was posted, as configured (id or @username)
+	MessageID int64  `json:"message_id,omitempty"`
+}
+
+// Archive is an append-only JSONL log of everything the bot has posted
+type Archive struct {
+	path string
+
+	mu          sync.Mutex
+	subscribers map[chan ArchivedItem]struct{}
+}
+
+func openArchive(path string) *Archive {
+	if path == "" {
+		path = ARCHIVE_FILE
+	}
+	return &Archive{path: path, subscribers: map[chan ArchivedItem]struct{}{}}
+}
+
+// Append stores item and notifies live subscribers
+func (a *Archive) Append(item ArchivedItem) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Printf("⚠️  Archive write failed: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(item); err != nil {
+		fmt.Printf("⚠️  Archive write failed: %v\n", err)
+		return
+	}
+
+	for ch := range a.subscribers {
+		select {
+		case ch <- item:
+		default: // slow subscriber, drop rather than stall the run
+		}
+	}
+}
+
+// All returns every archived item, oldest first
+func (a *Archive) All() ([]ArchivedItem, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	defer f.Close()
+
+	var items []ArchivedItem
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var item ArchivedItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue // skip a torn line rather than lose the whole archive
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	return items, nil
+}
+
+// Recent returns up to n items, newest first, optionally limited to one feed
+func (a *Archive) Recent(n int, feed string) ([]ArchivedItem, error) {
+	return a.RecentWhere(n, func(item *ArchivedItem) bool {
+		return feed == "" || item.Feed == feed
+	})
+}
+
+// RecentWhere returns up to n items that keep accepts, newest first
+func (a *Archive) RecentWhere(n int, keep func(*ArchivedItem) bool) ([]ArchivedItem, error) {
+	items, err := a.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []ArchivedItem
+	for i := len(items) - 1; i >= 0 && (n <= 0 || len(recent) < n); i-- {
+		if !keep(&items[i]) {
+			continue
+		}
+		recent = append(recent, items[i])
+	}
+	return recent, nil
+}
+
+// Get looks an item up by its id or link
+func (a *Archive) Get(key string) (*ArchivedItem, error) {
+	items, err := a.All()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].ID == key || items[i].Link == key {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ByMessage finds the item posted as messageID in a chat known by any of chatIDs
+func (a *Archive) ByMessage(chatIDs []string, messageID int64) (*ArchivedItem, error) {
+	items, err := a.All()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].MessageID != messageID {
+			continue
+		}
+		for _, id := range chatIDs {
+			if strings.EqualFold(items[i].ChatID, id) {
+				return &items[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Subscribe delivers items as they are appended until cancel is called
+func (a *Archive) Subscribe() (<-chan ArchivedItem, func()) {
+	ch := make(chan ArchivedItem, 16)
+
+	a.mu.Lock()
+	a.subscribers[ch] = struct{}{}
+	a.mu.Unlock()
+
+	cancel := func() {
+		a.mu.Lock()
+		delete(a.subscribers, ch)
+		a.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// Rewrite replaces the archive with the items keep returns true for. keep may
+// also modify the item in place. The new file is written aside and renamed over
+// the old one so a crash never leaves a half-written archive.
+func (a *Archive) Rewrite(keep func(*ArchivedItem) bool) (removed int, err error) {
+	items, err := a.All()
+	if err != nil {
+		return 0, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tmp := a.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("rewrite archive: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for i := range items {
+		if !keep(&items[i]) {
+			removed++
+			continue
+		}
+		if err := enc.Encode(items[i]); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return 0, fmt.Errorf("rewrite archive: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("rewrite archive: %w", err)
+	}
+	if len(items) == 0 {
+		os.Remove(tmp)
+		return 0, nil
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return 0, fmt.Errorf("rewrite archive: %w", err)
+	}
+	return removed, nil
+}
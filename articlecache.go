@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const ARTICLE_CACHE_DIR = "articles.cache"
+
+// ArticleCacheConfig keeps downloaded article pages on disk, so a run that
+// dies before saving state, or a retry of a failed item, doesn't download
+// them again. Files are named by the hash of the URL; a ttl of 0 disables it.
+type ArticleCacheConfig struct {
+	Dir string        `yaml:"dir"` // default articles.cache
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// articles is the cache fetchArticleContent uses, see configureArticleCache
+var articles *articleCache
+
+// configureArticleCache applies article_cache
+func configureArticleCache(cfg ArticleCacheConfig) {
+	articles = newArticleCache(cfg)
+}
+
+// articleCache stores pages by URL; a nil cache stores nothing
+type articleCache struct {
+	dir string
+	ttl time.Duration
+
+	sweep sync.Once
+}
+
+func newArticleCache(cfg ArticleCacheConfig) *articleCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = ARTICLE_CACHE_DIR
+	}
+	return &articleCache{dir: cfg.Dir, ttl: cfg.TTL}
+}
+
+func (c *articleCache) path(rawURL string) string {
+	return filepath.Join(c.dir, hash(rawURL)+".html")
+}
+
+// Get returns a fresh cached page and the URL it was finally served from.
+// The first line of a cache file is that URL, the rest is the page.
+func (c *articleCache) Get(rawURL string) ([]byte, *url.URL, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+	c.sweep.Do(c.prune)
+
+	path := c.path(rawURL)
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) > c.ttl {
+		return nil, nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	first, page, ok := bytes.Cut(data, []byte("\n"))
+	final, err := url.Parse(string(first))
+	if !ok || err != nil {
+		return nil, nil, false
+	}
+	return page, final, true
+}
+
+// Put stores a page; failures only cost a download next time
+func (c *articleCache) Put(rawURL string, final *url.URL, page []byte) {
+	if c == nil {
+		return
+	}
+	data := append([]byte(final.String()+"\n"), page...)
+	err := os.MkdirAll(c.dir, 0755)
+	if err == nil {
+		err = writeFileAtomic(c.path(rawURL), data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Could not cache article: %v\n", err)
+	}
+}
+
+// prune deletes expired pages, once per process
+func (c *articleCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && !e.IsDir() && time.Since(fi.ModTime()) > c.ttl {
+			os.Remove(filepath.Join(c.dir, e.Name()))
+		}
+	}
+}
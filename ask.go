@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// AskConfig enables `/ask <question>` replies to posts under `rss serve`.
+// For channels, readers reply in the linked discussion group, so the bot must
+// be a member there.
+type AskConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	PerUserPerHour int  `yaml:"per_user_per_hour"` // default 5
+	DailyTokens    int  `yaml:"daily_tokens"`      // model tokens per UTC day across all readers, default 200000
+	MaxQuestion    int  `yaml:"max_question"`      // characters, default 500
+}
+
+const ASK_PROMPT = `You answer a reader's question about an article that was shared in a Telegram channel.
+Answer in 2-5 sentences of plain text, in the language of the question. Use only the article below;
+if it doesn't contain the answer, say so briefly. NO HTML tags.
+
+Article title: %s
+
+Article:
+%s
+
+Question: %s`
+
+// askBudget caps the model tokens spent on questions per UTC day
+type askBudget struct {
+	limit int
+
+	mu   sync.Mutex
+	day  string
+	used int
+}
+
+func (b *askBudget) exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if today := time.Now().UTC().Format("2006-01-02"); b.day != today {
+		b.day, b.used = today, 0
+	}
+	return b.used >= b.limit
+}
+
+func (b *askBudget) spend(tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used += tokens
+}
+
+// runAsk long-polls Telegram for /ask commands until ctx is done
+func runAsk(ctx context.Context, bot *Bot) error {
+	cfg := bot.cfg.Ask
+	if cfg.PerUserPerHour <= 0 {
+		cfg.PerUserPerHour = 5
+	}
+	if cfg.DailyTokens <= 0 {
+		cfg.DailyTokens = 200000
+	}
+	if cfg.MaxQuestion <= 0 {
+		cfg.MaxQuestion = 500
+	}
+
+	limiter := newKeyedLimiter(float64(cfg.PerUserPerHour), float64(cfg.PerUserPerHour)/3600)
+	budget := &askBudget{limit: cfg.DailyTokens}
+	token := bot.cfg.Telegram.Token
+
+	fmt.Println("💬 Answering /ask replies")
+	var offset int64
+	for {
+		updates, err := getUpdates(ctx, token, offset, []string{"message"})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Printf("⚠️  getUpdates failed: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			msg := u.Message
+			question, ok := askQuestion(msg)
+			if !ok {
+				continue
+			}
+
+			reply := bot.answer(ctx, msg, question, cfg, limiter, budget)
+			if _, err := replyOnTelegram(ctx, token, msg, reply); err != nil {
+				fmt.Printf("⚠️  /ask reply failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// askQuestion extracts the question from "/ask ..." or "/ask@botname ..."
+func askQuestion(msg *TelegramMessage) (string, bool) {
+	if msg == nil || msg.From == nil {
+		return "", false
+	}
+	cmd, rest, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+	cmd, _, _ = strings.Cut(cmd, "@")
+	if cmd != "/ask" {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// askedItem finds the archived post a question replies to: either the post
+// itself, or its automatic copy in the channel's discussion group
+func (b *Bot) askedItem(msg *TelegramMessage) (*ArchivedItem, error) {
+	target := msg.ReplyTo
+	if target == nil {
+		return nil, nil
+	}
+
+	chat, messageID := target.Chat, target.MessageID
+	if o := target.ForwardOrigin; o != nil && o.Type == "channel" && o.Chat != nil {
+		chat, messageID = *o.Chat, o.MessageID
+	}
+
+	ids := []string{strconv.FormatInt(chat.ID, 10)}
+	if chat.Username != "" {
+		ids = append(ids, "@"+chat.Username)
+	}
+	return b.archive.ByMessage(ids, messageID)
+}
+
+// answer produces the reply text for one question, enforcing the limits
+func (b *Bot) answer(ctx context.Context, msg *TelegramMessage, question string, cfg AskConfig, limiter *keyedLimiter, budget *askBudget) string {
+	if question == "" {
+		return "Reply to a post with <code>/ask your question</code>."
+	}
+	if len([]rune(question)) > cfg.MaxQuestion {
+		return fmt.Sprintf("Please keep questions under %d characters.", cfg.MaxQuestion)
+	}
+
+	item, err := b.askedItem(msg)
+	if err != nil {
+		fmt.Printf("⚠️  Archive lookup failed: %v\n", err)
+		return "Sorry, something went wrong."
+	}
+	if item == nil {
+		return "Reply to one of the bot's posts with <code>/ask your question</code>."
+	}
+
+	if !limiter.Allow(strconv.FormatInt(msg.From.ID, 10)) {
+		return "You've asked a lot of questions recently, please try again later."
+	}
+	if budget.exhausted() {
+		return "Questions are paused for today, please try again tomorrow."
+	}
+
+	article := item.Content
+	if article == "" {
+		// Full text may have been dropped by retention; the summary still helps
+		article = item.Summary
+	}
+
+	fmt.Printf("💬 /ask on %s: %s\n", item.Link, truncate(question, 80, "…"))
+	aiCtx, cancel := aiContext(ctx)
+	defer cancel()
+	resp, err := genkit.Generate(aiCtx, b.g,
+		ai.WithPrompt(fmt.Sprintf(ASK_PROMPT, item.Title, article, question)),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	if err != nil {
+		fmt.Printf("⚠️  /ask answer failed: %v\n", err)
+		return "Sorry, I couldn't answer that right now."
+	}
+	if resp.Usage != nil {
+		budget.spend(resp.Usage.InputTokens + resp.Usage.OutputTokens)
+	}
+
+	return html.EscapeString(truncate(strings.TrimSpace(resp.Text()), TELEGRAM_MESSAGE_LIMIT-100, "…"))
+}
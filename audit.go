@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AuditConfig struct {
+	File       string `yaml:"file"`        // JSONL audit log; empty disables auditing
+	MaxSizeMB  int    `yaml:"max_size_mb"` // rotate once the file reaches this size, default 10
+	MaxBackups int    `yaml:"max_backups"` // rotated files to keep (file.1 … file.N), default 5
+}
+
+// AuditRecord is one outbound request or one posted message
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"` // "http" or "message"
+	Purpose    string    `json:"purpose,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	Status     int       `json:"status,omitempty"`
+	BytesOut   int64     `json:"bytes_out,omitempty"`
+	BytesIn    int64     `json:"bytes_in,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Chat       string    `json:"chat,omitempty"`
+	MessageID  int64     `json:"message_id,omitempty"`
+	ItemID     string    `json:"item_id,omitempty"`
+	Link       string    `json:"link,omitempty"`
+}
+
+// AuditLog is an append-only, size-rotated JSONL file. A nil log records nothing.
+type AuditLog struct {
+	cfg AuditConfig
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// audit is the process-wide audit log; every outbound request goes through
+// http.DefaultTransport, which is wrapped to record into it
+var audit *AuditLog
+
+func openAuditLog(cfg AuditConfig) (*AuditLog, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = 10
+	}
+	if cfg.MaxBackups <= 0 {
+		cfg.MaxBackups = 5
+	}
+
+	l := &AuditLog{cfg: cfg}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *AuditLog) open() error {
+	f, err := os.OpenFile(l.cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts file.N-1 → file.N … file → file.1 and starts a fresh file
+func (l *AuditLog) rotate() error {
+	l.f.Close()
+	for i := l.cfg.MaxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", l.cfg.File, i), fmt.Sprintf("%s.%d", l.cfg.File, i+1))
+	}
+	if err := os.Rename(l.cfg.File, l.cfg.File+".1"); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+func (l *AuditLog) Record(r AuditRecord) {
+	if l == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	line, _ := json.Marshal(r)
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size+int64(len(line)) > int64(l.cfg.MaxSizeMB)<<20 && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		fmt.Printf("⚠️  Audit log write failed: %v\n", err)
+	}
+}
+
+// Message records a Telegram call that posted or changed a message, once
+// per item ctx attributes it to
+func (l *AuditLog) Message(ctx context.Context, method, chat string, messageID int64) {
+	if l == nil {
+		return
+	}
+	rec := AuditRecord{Kind: "message", Purpose: method, Chat: chat, MessageID: messageID}
+	items, _ := ctx.Value(auditItemsKey{}).([]auditItem)
+	if len(items) == 0 {
+		l.Record(rec)
+		return
+	}
+	for _, item := range items {
+		rec.ItemID, rec.Link = item.id, item.link
+		l.Record(rec)
+	}
+}
+
+func (l *AuditLog) Close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.f.Close()
+}
+
+type auditPurposeKey struct{}
+
+type auditItemsKey struct{}
+
+type auditItem struct {
+	id, link string
+}
+
+// withAuditItem attributes the messages sent with ctx to an item, in
+// addition to any it is already attributed to (a digest covers several)
+func withAuditItem(ctx context.Context, id, link string) context.Context {
+	items, _ := ctx.Value(auditItemsKey{}).([]auditItem)
+	return context.WithValue(ctx, auditItemsKey{}, append(slices.Clone(items), auditItem{id, link}))
+}
+
+// withPurpose tags outbound requests made with ctx for the audit log
+func withPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, auditPurposeKey{}, purpose)
+}
+
+// requestPurpose falls back to guessing from the host for requests made by
+// libraries (the AI SDK) that we can't tag
+func requestPurpose(req *http.Request) string {
+	if p, ok := req.Context().Value(auditPurposeKey{}).(string); ok {
+		return p
+	}
+	switch host := req.URL.Hostname(); {
+	case host == "api.telegram.org":
+		return "telegram"
+	case strings.HasSuffix(host, "googleapis.com"):
+		return "ai"
+	default:
+		return "other"
+	}
+}
+
+var telegramTokenPath = regexp.MustCompile(`/bot[^/]+/`)
+
+// redactURL strips credentials (Telegram bot token, key-like query params) before logging
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	c.Path = telegramTokenPath.ReplaceAllString(c.Path, "/bot<redacted>/")
+	c.RawPath = ""
+	q := c.Query()
+	for k := range q {
+		lower := strings.ToLower(k)
+		if strings.Contains(lower, "key") || strings.Contains(lower, "token") || strings.Contains(lower, "sig") {
+			q.Set(k, "redacted")
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// auditTransport records every request passing through it
+type auditTransport struct {
+	base http.RoundTripper
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	rec := AuditRecord{
+		Kind:     "http",
+		Purpose:  requestPurpose(req),
+		Method:   req.Method,
+		URL:      redactURL(req.URL),
+		BytesOut: max(req.ContentLength, 0),
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		rec.Error = err.Error()
+		rec.DurationMS = time.Since(start).Milliseconds()
+		audit.Record(rec)
+		return nil, err
+	}
+
+	rec.Status = resp.StatusCode
+	resp.Body = &auditBody{ReadCloser: resp.Body, rec: rec, start: start}
+	return resp, nil
+}
+
+// auditBody counts response bytes and writes the record once the body is closed
+type auditBody struct {
+	io.ReadCloser
+	rec   AuditRecord
+	start time.Time
+	once  sync.Once
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.rec.BytesIn += int64(n)
+	return n, err
+}
+
+func (b *auditBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.rec.DurationMS = time.Since(b.start).Milliseconds()
+		audit.Record(b.rec)
+	})
+	return err
+}
+
+// installAudit routes all outbound HTTP through the audit log
+func installAudit(cfg AuditConfig) error {
+	l, err := openAuditLog(cfg)
+	if err != nil || l == nil {
+		return err
+	}
+	audit = l
+	http.DefaultTransport = &auditTransport{base: http.DefaultTransport}
+	return nil
+}
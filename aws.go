@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsCredentials come from the standard AWS_* environment variables
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+}
+
+func awsCredentialsFromEnv() awsCredentials {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          region,
+	}
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signAWSRequest adds a Signature Version 4 Authorization header to req.
+// Requests without credentials are left unsigned, which works for public objects.
+func signAWSRequest(req *http.Request, payload []byte, service string, creds awsCredentials) {
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := hash(string(payload))
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") || lower == "if-match" || lower == "if-none-match" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var canonicalQuery []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			canonicalQuery = append(canonicalQuery, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + creds.Region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hash(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsEscape is the RFC 3986 escaping SigV4 expects for query components
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// s3ObjectURL maps s3://bucket/key to an HTTPS endpoint, honoring
+// AWS_ENDPOINT_URL_S3 for S3-compatible stores such as MinIO
+func s3ObjectURL(s3url string, creds awsCredentials) (string, error) {
+	u, err := url.Parse(s3url)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", fmt.Errorf("bad s3 url: %s", s3url)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/" + u.Host + "/" + key, nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.Host, creds.Region, key), nil
+}
+
+// errObjectNotFound is returned when an S3 or GCS object doesn't exist yet
+var errObjectNotFound = errors.New("object not found")
+
+// errPreconditionFailed means a conditional write lost a race with another writer
+var errPreconditionFailed = errors.New("object changed since it was read")
+
+// s3Get downloads an object, returning its body and ETag
+func s3Get(s3url string) ([]byte, string, error) {
+	creds := awsCredentialsFromEnv()
+	target, err := s3ObjectURL(s3url, creds)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(withPurpose(context.Background(), "s3"), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	signAWSRequest(req, nil, "s3", creds)
+
+	resp, err := httpDo(req, 30*time.Second)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errObjectNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("bad status: %d %s", resp.StatusCode, string(body))
+	}
+	return body, resp.Header.Get("ETag"), nil
+}
+
+// s3Put uploads an object only if it still has etag, or doesn't exist yet when
+// etag is empty, and returns the new ETag
+func s3Put(s3url string, data []byte, etag string) (string, error) {
+	creds := awsCredentialsFromEnv()
+	target, err := s3ObjectURL(s3url, creds)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(withPurpose(context.Background(), "s3"), http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+	signAWSRequest(req, data, "s3", creds)
+
+	resp, err := httpDo(req, 30*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return "", errPreconditionFailed
+	case resp.StatusCode >= 300:
+		rb, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload failed: %d %s", resp.StatusCode, string(rb))
+	}
+	return resp.Header.Get("ETag"), nil
+}
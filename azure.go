@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+)
+
+// Azure OpenAI serves OpenAI models from deployments in an Azure resource.
+// Models are named azure/<deployment>, e.g. azure/gpt-4o-news, and requests
+// go to the deployment on ai.azure.endpoint, speaking OpenAI's chat API.
+const PROVIDER_AZURE = "azure"
+
+type AzureConfig struct {
+	Endpoint   string `yaml:"endpoint"`    // resource URL, e.g. https://my-resource.openai.azure.com
+	APIKey     string `yaml:"api_key"`     // default AZURE_OPENAI_API_KEY
+	APIVersion string `yaml:"api_version"` // default 2024-10-21
+	MaxTokens  int    `yaml:"max_tokens"`  // cap on each reply; 0 leaves it to the service
+}
+
+const AZURE_API_VERSION = "2024-10-21"
+
+// azurePlugin resolves azure/<deployment> names for genkit
+type azurePlugin struct {
+	cfg AzureConfig
+}
+
+func (p *azurePlugin) Name() string { return PROVIDER_AZURE }
+
+func (p *azurePlugin) Init(ctx context.Context) []api.Action { return nil }
+
+func (p *azurePlugin) ListActions(ctx context.Context) []api.ActionDesc { return nil }
+
+func (p *azurePlugin) ResolveAction(atype api.ActionType, name string) api.Action {
+	if atype != api.ActionTypeModel {
+		return nil
+	}
+	return providerModel(PROVIDER_AZURE, name, func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		return p.generate(ctx, name, req)
+	})
+}
+
+// generate sends one chat completion request to a deployment
+func (p *azurePlugin) generate(ctx context.Context, deployment string, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+	if p.cfg.Endpoint == "" || p.cfg.APIKey == "" {
+		return nil, fmt.Errorf("azure: no endpoint or API key (set ai.azure.endpoint and ai.azure.api_key or AZURE_OPENAI_API_KEY)")
+	}
+
+	start := time.Now()
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(p.cfg.Endpoint, "/"), url.PathEscape(deployment), url.QueryEscape(cmp.Or(p.cfg.APIVersion, AZURE_API_VERSION)))
+	header := http.Header{}
+	header.Set("api-key", p.cfg.APIKey)
+	var out openAIResponse
+	if err := postProvider(ctx, PROVIDER_AZURE, endpoint, header, openAIChatRequest(deployment, p.cfg.MaxTokens, req), &out); err != nil {
+		return nil, err
+	}
+	return openAIChatResponse(PROVIDER_AZURE, out, start)
+}
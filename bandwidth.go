@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Feeds whose own content is at least this long are treated as full-text
+const FULL_CONTENT_MIN = 1000
+
+// BandwidthConfig is for hosts on a metered connection. Responses are always
+// requested gzip-compressed; bytes on the wire are reported after every run.
+type BandwidthConfig struct {
+	Low           bool `yaml:"low"`             // summarize full-text feeds from the feed itself instead of downloading the page
+	MaxDownloadKB int  `yaml:"max_download_kb"` // stop reading an article page (or image) after this much, 0 for no limit
+}
+
+// Bytes read from and written to the network by every connection the process dials
+var wireIn, wireOut atomic.Int64
+
+// countingConn adds what passes through a connection to the wire counters
+type countingConn struct {
+	net.Conn
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	wireIn.Add(int64(n))
+	return n, err
+}
+
+func (c countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	wireOut.Add(int64(n))
+	return n, err
+}
+
+func init() {
+	// Proxy transports are cloned from baseTransport, so they count too
+	baseTransport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return countingConn{conn}, nil
+	}
+}
+
+// wireBytes returns the bytes received and sent so far
+func wireBytes() (int64, int64) {
+	return wireIn.Load(), wireOut.Load()
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// htmlText is the whitespace-normalized text of an HTML fragment
+func htmlText(s string) string {
+	if s == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// fullContent is the article text a feed carries itself (content:encoded,
+// Atom content or a long description), empty when the feed only has a teaser
+func fullContent(item Item) string {
+	for _, s := range []string{item.Content, item.Description} {
+		if text := htmlText(s); utf8.RuneCountInString(text) >= FULL_CONTENT_MIN {
+			return truncate(text, 3000, "...")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// Feeds of many tiny items (Hacker News, release notes) can set batch: N to
+// summarize up to N short items in one request. The usual prompt for the
+// feed becomes the instructions, given once for all the articles, and the
+// answer is split back into one summary per item by its markers. Items too
+// long to batch, or missing from the answer, are summarized alone as usual.
+
+const MAX_BATCH = 20
+
+// Items with more article text than this are summarized alone
+const BATCH_MAX_CONTENT = 1500
+
+// Stands in for the title and content in the instructions
+const BATCH_PLACEHOLDER = "(given with each article below)"
+
+const BATCH_PROMPT = `Below are %d short articles. Answer for each of them separately, following these instructions for every article:
+
+<<<
+%s
+>>>
+
+Start the answer for each article with its marker line exactly as given (for example === 1 ===), then the answer. Answer every article, in order, and write nothing before the first marker. "AI FAILED" covers only the article it is under.
+
+Articles:
+%s`
+
+var batchMarker = regexp.MustCompile(`(?m)^[ \t]*={2,}[ \t]*(\d+)[ \t]*={2,}[ \t]*$`)
+
+// draftBatch screens the items of group and summarizes the short ones that
+// share a prompt in one request each, leaving each summary in the item's
+// draft for summarize to use
+func (b *Bot) draftBatch(ctx context.Context, group []*pendingItem) {
+	if len(group) < 2 {
+		return
+	}
+	batches := map[string][]*pendingItem{} // instructions -> items
+	var order []string
+	for _, p := range group {
+		structured := b.cfg.AI.Structured && p.feed.Kind != FEED_RELEASE
+		if p.job || p.unclaimed || p.fetchErr != nil || structured || len([]rune(p.content)) > BATCH_MAX_CONTENT {
+			continue
+		}
+		if !b.screen(ctx, p) {
+			continue
+		}
+		variant := b.cfg.Prompts.variantFor(p.feed, p.id)
+		if prompt, err := b.summaryPrompt(p.feed, variant, p.item.Title, p.content, false); err != nil || p.lookupSummary(summaryKey(b.cfg.AI.Model, prompt)) != nil {
+			continue // summarized alone, or from the cache
+		}
+		instructions, err := b.summaryPrompt(p.feed, variant, BATCH_PLACEHOLDER, BATCH_PLACEHOLDER, false)
+		if err != nil {
+			continue
+		}
+		if _, ok := batches[instructions]; !ok {
+			order = append(order, instructions)
+		}
+		batches[instructions] = append(batches[instructions], p)
+	}
+	for _, instructions := range order {
+		if items := batches[instructions]; len(items) > 1 {
+			b.generateBatch(ctx, instructions, items)
+		}
+	}
+}
+
+// generateBatch asks for the summaries of items in one request. Tokens are
+// shared out evenly, the first item taking the remainder; on failure the
+// items keep no draft.
+func (b *Bot) generateBatch(ctx context.Context, instructions string, items []*pendingItem) {
+	if chaosError(CHAOS_AI) != nil {
+		return
+	}
+	var articles strings.Builder
+	for i, p := range items {
+		fmt.Fprintf(&articles, "\n=== %d ===\nTitle: %s\n\nContent:\n%s\n", i+1, p.item.Title, p.content)
+	}
+
+	fmt.Printf("🤖 Summarizing %d short items in one request\n", len(items))
+	aiCtx, cancel := aiContext(ctx)
+	defer cancel()
+	resp, err := genkit.Generate(aiCtx, b.g,
+		ai.WithPrompt(fmt.Sprintf(BATCH_PROMPT, len(items), instructions, articles.String())),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	if err != nil {
+		fmt.Printf("⚠️  Batch summary failed, summarizing one by one: %v\n", err)
+		return
+	}
+
+	answers := parseBatchAnswers(resp.Text(), len(items))
+	missing := 0
+	for i, p := range items {
+		if u := resp.Usage; u != nil {
+			input, output := u.InputTokens/len(items), u.OutputTokens/len(items)
+			if i == 0 { // the remainder, so the items add up to the response
+				input += u.InputTokens % len(items)
+				output += u.OutputTokens % len(items)
+			}
+			p.addTokens(input, output)
+		}
+		p.decision.Batch = len(items)
+		if p.draft = answers[i]; p.draft == "" {
+			missing++
+		}
+	}
+	if missing > 0 {
+		fmt.Printf("   %d item(s) missing from the answer, summarizing them one by one\n", missing)
+	}
+}
+
+// parseBatchAnswers splits a batch answer at its markers into n summaries,
+// empty for any article the answer skipped
+func parseBatchAnswers(text string, n int) []string {
+	answers := make([]string, n)
+	marks := batchMarker.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range marks {
+		num, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || num < 1 || num > n {
+			continue
+		}
+		end := len(text)
+		if i+1 < len(marks) {
+			end = marks[i+1][0]
+		}
+		if answers[num-1] == "" {
+			answers[num-1] = strings.TrimSpace(text[m[1]:end])
+		}
+	}
+	return answers
+}
@@ -0,0 +1,897 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+var errRunInProgress = errors.New("a run is already in progress")
+
+// Bot owns everything a run needs, so a run can be started once from the
+// command line or repeatedly from the API server
+type Bot struct {
+	cfg       *Config
+	g         *genkit.Genkit
+	decisions *DecisionLog
+	archive   *Archive
+
+	translators     map[string]Translator // task -> provider, see translate.go
+	filters         *Filters
+	templates       map[string]*template.Template // message template source -> parsed
+	prompts         map[string]*template.Template // prompt file path -> parsed
+	embeddings      *embeddingStore               // recent posts, to spot stories again; nil when off
+	moderationWords *regexp.Regexp                // moderation.keywords, nil without any
+	flows           botFlows                      // the model steps as genkit flows, see flows.go
+	progress        *Progress                     // live view for interactive runs, nil otherwise
+
+	running sync.Mutex
+}
+
+func newBot(ctx context.Context, cfg *Config) (*Bot, error) {
+	g := genkit.Init(ctx, genkit.WithPlugins(cfg.AI.plugins()...))
+
+	translators, err := newTranslators(cfg.Translation, g, cfg.AI)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := map[string]*template.Template{}
+	sources := []string{cfg.MessageTemplate}
+	for _, feed := range cfg.Feeds {
+		sources = append(sources, feed.Template)
+	}
+	for _, text := range sources {
+		if _, ok := templates[text]; ok || text == "" {
+			continue
+		}
+		tmpl, err := parseMessageTemplate(text)
+		if err != nil {
+			return nil, fmt.Errorf("message template: %w", err)
+		}
+		templates[text] = tmpl
+	}
+
+	prompts := map[string]*template.Template{}
+	for _, feed := range cfg.Feeds {
+		path := cfg.Prompts.promptFile(feed)
+		if _, ok := prompts[path]; ok || path == "" {
+			continue
+		}
+		tmpl, err := loadPromptFile(path)
+		if err != nil {
+			return nil, err
+		}
+		prompts[path] = tmpl
+	}
+	for _, v := range cfg.Prompts.Variants {
+		if _, ok := prompts[v.File]; ok || v.File == "" {
+			continue
+		}
+		tmpl, err := loadPromptFile(v.File)
+		if err != nil {
+			return nil, fmt.Errorf("prompt variant %s: %w", v.Name, err)
+		}
+		prompts[v.File] = tmpl
+	}
+
+	decisions, err := openDecisionLog(cfg.DecisionLog)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+
+	var embeddings *embeddingStore
+	if cfg.Stories.Embedder != "" {
+		if embeddings, err = openEmbeddingStore(cfg.Stories.File); err != nil {
+			fmt.Printf("⚠️  %v, starting afresh\n", err)
+		}
+	}
+
+	b := &Bot{
+		cfg:         cfg,
+		g:           g,
+		decisions:   decisions,
+		archive:     openArchive(cfg.Archive),
+		translators: translators,
+		filters:     newFilters(cfg.Filters),
+		templates:   templates,
+		prompts:     prompts,
+		embeddings:  embeddings,
+
+		moderationWords: moderationWords(cfg.Moderation.Keywords),
+	}
+	b.defineFlows()
+	return b, nil
+}
+
+func (b *Bot) Close() {
+	b.decisions.Close()
+}
+
+// Run fetches every feed once and posts unseen items. Only one run may be active at a time.
+func (b *Bot) Run(ctx context.Context) (*RunMetrics, error) {
+	if !b.running.TryLock() {
+		return nil, errRunInProgress
+	}
+	defer b.running.Unlock()
+
+	return b.run(ctx), nil
+}
+
+// Start launches a run in the background, failing fast if one is already active
+func (b *Bot) Start(ctx context.Context) error {
+	if !b.running.TryLock() {
+		return errRunInProgress
+	}
+
+	go func() {
+		defer b.running.Unlock()
+		b.run(ctx)
+	}()
+	return nil
+}
+
+func (b *Bot) run(ctx context.Context) *RunMetrics {
+	unlock, err := acquireRunLock(b.cfg.State)
+	if errors.Is(err, errRunLocked) {
+		fmt.Println("⏭️  Another run is still in progress, skipping this one")
+		return newRunMetrics()
+	}
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return newRunMetrics()
+	}
+	defer unlock()
+
+	state, err := openStateStore(b.cfg.State)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return newRunMetrics()
+	}
+	defer func() {
+		// 🔒 ALWAYS save state
+		if err := state.Close(); err != nil {
+			fmt.Printf("⚠️  Saving state failed: %v\n", err)
+		}
+	}()
+	pruneState(state, b.cfg.Retention.StateDays)
+
+	postsSent := 0
+	metrics := newRunMetrics()
+	bytesIn, bytesOut := wireBytes()
+
+	// Shuffle feeds
+	feeds := append([]FeedConfig(nil), b.cfg.Feeds...)
+	rand.Shuffle(len(feeds), func(i, j int) {
+		feeds[i], feeds[j] = feeds[j], feeds[i]
+	})
+
+	// Feeds whose host sent a long Retry-After go to the back of the queue,
+	// once, and are fetched again when the host allows
+	deferred := map[string]time.Time{}
+
+	b.progress.Start(len(feeds))
+	for i := 0; i < len(feeds); i++ {
+		feed := feeds[i]
+		feedURL := feed.URL
+		chatID := b.cfg.chatFor(feed)
+		fetchOpts := b.cfg.fetchFor(feed)
+		feedSent := 0
+
+		if postsSent >= b.cfg.MaxPostsPerRun {
+			fmt.Printf("✅ Reached limit of %d posts, stopping\n", b.cfg.MaxPostsPerRun)
+			break
+		}
+		b.progress.Feed(feedURL, metrics)
+
+		status, err := state.FeedStatus(feedURL)
+		if err != nil {
+			fmt.Printf("⚠️  Feed status lookup failed: %v\n", err)
+		}
+		if retry := b.cfg.FeedBackoff.retryAt(status); time.Now().Before(retry) {
+			fmt.Printf("⏸️  Skipping %s: failed %d runs in a row (%s), retrying after %s\n",
+				feedURL, status.Failures, status.LastError, retry.Local().Format(time.DateTime))
+			metrics.FeedsSkipped++
+			continue
+		}
+
+		if wait := time.Until(deferred[feedURL]); wait > 0 {
+			fmt.Printf("⏳ Waiting %s for %s to accept requests again\n", wait.Round(time.Second), shortURL(feedURL))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+			}
+		}
+
+		fmt.Printf("📡 Fetching: %s\n", feedURL)
+
+		body, err := fetchFeed(feedURL, fetchOpts)
+		var throttled *throttledError
+		if _, again := deferred[feedURL]; errors.As(err, &throttled) && !again && time.Until(throttled.Until) <= MAX_THROTTLE_WAIT {
+			fmt.Printf("⏳ %v, trying %s again later in this run\n", throttled, feedURL)
+			deferred[feedURL] = throttled.Until
+			feeds = append(feeds, feed)
+			metrics.FeedsDeferred++
+			continue
+		}
+		bodyHash := hash(string(body))
+		if err == nil && bodyHash == status.BodyHash {
+			recordFetch(state, feedURL, status, nil)
+			fmt.Printf("   Unchanged since the last run, skipping\n")
+			metrics.FeedsFetched++
+			metrics.FeedsUnchanged++
+			continue
+		}
+		var rss *RSS
+		if err == nil {
+			rss, err = parseFeed(body)
+		}
+		status = recordFetch(state, feedURL, status, err)
+		if err != nil {
+			fmt.Printf("⚠️RSS feed failed (%s): %v\n", feedURL, err)
+			metrics.FeedErrors++
+			metrics.PerFeedFailure[feedURL]++
+			continue // Skip this feed and move to next
+		}
+
+		fmt.Printf("   Found %d items\n", len(rss.Channel.Items))
+		metrics.FeedsFetched++
+
+		if err == nil && status.LastSuccess.IsZero() && noneSeen(state, rss.Channel.Items) {
+			// Nothing of it in state yet: a feed new to the config, or an Atom
+			// feed whose entries older versions didn't parse at all. Its whole
+			// backlog would look new, so it only starts the record.
+			n := markSeen(state, feedURL, rss.Channel.Items, "first fetch")
+			fmt.Printf("   First fetch of this feed: %d item(s) marked seen, posting from the next new one\n", n)
+			recordHandled(state, feedURL, status, bodyHash, rss.Channel.Items, !feed.IgnoreDates)
+			continue
+		}
+
+		items := rss.Channel.Items
+		if !feed.IgnoreDates {
+			items = status.newItems(items)
+			if skipped := len(rss.Channel.Items) - len(items); skipped > 0 {
+				fmt.Printf("   %d published before the last handled item\n", skipped)
+			}
+		}
+
+		if feed.Kind == FEED_DOCS {
+			if b.postDocs(feed, chatID, items, state, metrics) {
+				postsSent++
+			}
+			recordHandled(state, feedURL, status, bodyHash, items, !feed.IgnoreDates)
+			continue
+		}
+
+		// Process from oldest to newest, in batches that fit the post limits;
+		// items that fail to send leave room for another batch
+		next := len(items) - 1
+		for next >= 0 {
+			budget := b.cfg.MaxPostsPerRun - postsSent
+			if feed.MaxPosts > 0 {
+				if feedSent >= feed.MaxPosts {
+					fmt.Printf("   Reached feed limit of %d posts\n", feed.MaxPosts)
+					break
+				}
+				budget = min(budget, feed.MaxPosts-feedSent)
+			}
+			if budget <= 0 {
+				break
+			}
+
+			var batch []*pendingItem
+			for ; next >= 0 && len(batch) < budget; next-- {
+				item := items[next]
+				// Shared stores are claimed item by item as the pipeline
+				// reaches them (see postItems): claiming the whole batch here
+				// would let early claims expire while later items are worked on
+				id := itemID(item.Link)
+				seen, err := seenLink(state, item.Link)
+				if err != nil {
+					fmt.Printf("⚠️  State lookup failed, skipping item: %v\n", err)
+					continue
+				}
+				if seen {
+					continue
+				}
+
+				metrics.ItemsNew++
+				decision := &Decision{Feed: feedURL, ItemID: id, Title: item.Title, Link: item.Link}
+
+				if reason, rejected := b.filters.Reject(item); rejected {
+					if err := state.Mark(id, StateEntry{Title: item.Title, Link: item.Link, Feed: feedURL, Skipped: reason}); err != nil {
+						fmt.Printf("   ⚠️  Could not record item as seen: %v\n", err)
+					}
+					decision.Filters = append(decision.Filters, "filters")
+					decision.Action = ACTION_SKIPPED
+					decision.Reason = reason
+					b.decisions.Record(decision)
+					fmt.Printf("   🚫 Filtered (%s): %s\n", reason, item.Title)
+					continue
+				}
+				batch = append(batch, &pendingItem{feed: feed, id: id, item: item, decision: decision})
+			}
+			if len(batch) == 0 {
+				break
+			}
+
+			sent := b.postItems(ctx, batch, state, metrics)
+			postsSent += sent
+			feedSent += sent
+		}
+		recordHandled(state, feedURL, status, bodyHash, items, !feed.IgnoreDates)
+	}
+
+	b.progress.Finish(metrics)
+
+	fmt.Printf("\n🎉 Job finished: %d posts sent\n", postsSent)
+	in, out := wireBytes()
+	metrics.BytesIn, metrics.BytesOut = in-bytesIn, out-bytesOut
+	fmt.Printf("📶 Transferred %s in, %s out\n", formatBytes(metrics.BytesIn), formatBytes(metrics.BytesOut))
+	b.reportCosts(metrics)
+	if b.embeddings != nil {
+		if err := b.embeddings.save(b.cfg.Stories.since()); err != nil {
+			fmt.Printf("⚠️  Saving %v\n", err)
+		}
+	}
+
+	if stripped, err := applyRetention(b.archive, b.cfg.Retention); err != nil {
+		fmt.Printf("⚠️  Retention failed: %v\n", err)
+	} else if stripped > 0 {
+		fmt.Printf("🧹 Dropped full text from %d archived item(s) past retention\n", stripped)
+	}
+
+	if err := b.updateEventsPin(ctx); err != nil {
+		fmt.Printf("⚠️  Updating upcoming events failed: %v\n", err)
+	}
+
+	if b.cfg.Site.Dir != "" && postsSent > 0 {
+		if err := renderSite(b.archive, b.cfg.Site); err != nil {
+			fmt.Printf("⚠️  Site build failed: %v\n", err)
+		} else {
+			fmt.Printf("🌍 Site rebuilt in %s\n", b.cfg.Site.Dir)
+		}
+	}
+
+	if err := pushMetrics(b.cfg.Metrics, metrics, true); err != nil {
+		fmt.Printf("⚠️  Metrics %v\n", err)
+	}
+	return metrics
+}
+
+// pendingItem is an item on its way through the pipeline: its article is
+// fetched, then summarized, then delivered. Each stage owns it in turn.
+type pendingItem struct {
+	feed     FeedConfig
+	id       string
+	item     Item
+	decision *Decision
+	cache    summaryCache // the state store's, nil when it keeps no summaries
+
+	content   string // article text
+	extractor string
+	image     string // the page's og:image, for telegram.photos
+	fetchErr  error
+
+	unclaimed                 bool   // another instance claimed it first, see claimItem
+	job                       bool   // looks like a job post: classified when delivered, summarized only if it isn't one
+	screened                  bool   // duplicate and relevance checks done
+	draft                     string // summary from a batched request, see batch.go
+	summary                   string
+	tags                      []string      // from a structured summary
+	skipped, skipFilter       string        // why a model check skipped the item, and which check
+	embedding                 []float32     // of title and opening text, see stories.go
+	story                     *embeddedItem // the post of the story a duplicate tells
+	topic                     *TopicConfig  // see topics.go
+	flagged                   string        // why moderation flagged the item, see moderation.go
+	variant                   string        // the prompt A/B variant, see prompts.go
+	deferred                  bool          // the model was unavailable, try again next run
+	aiErr                     error
+	event                     *EventInfo
+	title                     string // translated when translation is on
+	originalTitle             string // the feed's title when the model rewrote it, see clickbait.go
+	inputTokens, outputTokens int
+}
+
+// postItem fetches, summarizes and sends one item, marking it as sent in state.
+// The decision is recorded; it reports whether a message went out.
+func (b *Bot) postItem(ctx context.Context, feed FeedConfig, id string, item Item, state StateStore, decision *Decision, metrics *RunMetrics) bool {
+	p := &pendingItem{feed: feed, id: id, item: item, decision: decision, cache: cacheOf(state)}
+	b.fetchArticle(p)
+	b.summarize(ctx, p)
+	return b.deliver(ctx, p, state, metrics)
+}
+
+// postItems runs a feed's items through fetch, summarize and deliver stages
+// at once: while one item is being summarized the next is downloading and
+// the one before is being sent. A stage hands over one item at a time (or
+// one batch, see batch.go), so only a few are ever ahead of delivery. It
+// returns how many were sent.
+func (b *Bot) postItems(ctx context.Context, items []*pendingItem, state StateStore, metrics *RunMetrics) int {
+	fetched := make(chan *pendingItem, 1)
+	summarized := make(chan *pendingItem, 1)
+	for _, p := range items {
+		p.cache = cacheOf(state)
+	}
+	go func() {
+		defer close(fetched)
+		for _, p := range items {
+			if taken, err := claimItem(state, p.id); err != nil || !taken {
+				if err != nil {
+					fmt.Printf("⚠️  Claiming item failed, skipping it: %v\n", err)
+				}
+				p.unclaimed = true // being sent by another instance
+			} else {
+				b.fetchArticle(p)
+			}
+			fetched <- p
+		}
+	}()
+	go func() {
+		defer close(summarized)
+		// Feeds with batch set are summarized a group at a time
+		var group []*pendingItem
+		flush := func() {
+			b.draftBatch(ctx, group)
+			for _, p := range group {
+				b.summarize(ctx, p)
+				summarized <- p
+			}
+			group = nil
+		}
+		for p := range fetched {
+			group = append(group, p)
+			if len(group) >= max(p.feed.Batch, 1) {
+				flush()
+			}
+		}
+		flush()
+	}()
+
+	// State and metrics are only touched here, in delivery order
+	sent := 0
+	for p := range summarized {
+		b.progress.Item(p.item.Title, metrics)
+		delivered := b.deliver(ctx, p, state, metrics)
+		b.progress.Item("", metrics)
+		if delivered {
+			sent++
+			pacing.pause(b.cfg.postDelayFor(p.feed)) // safe pacing; skipped items sent nothing
+		}
+	}
+	return sent
+}
+
+// fetchArticle gets the text to summarize: release notes or full text from
+// the feed itself, otherwise the downloaded article page
+func (b *Bot) fetchArticle(p *pendingItem) {
+	feed := p.feed
+	fetchOpts := b.cfg.fetchFor(feed)
+
+	fmt.Printf("📄 Fetching article content: %s\n", p.item.Title)
+	if notes := releaseNotes(p.item); feed.Kind == FEED_RELEASE && notes != "" {
+		p.content, p.extractor = notes, "feed"
+	} else if full := fullContent(p.item); b.cfg.Bandwidth.Low && full != "" {
+		p.content, p.extractor = full, "feed"
+	} else {
+		var page ArticlePage
+		page, p.fetchErr = fetchArticleContent(p.item.Link, fetchOpts)
+		p.content, p.extractor, p.image = page.Text, page.Extractor, page.Image
+		canonical := page.Canonical
+		if p.fetchErr != nil && fetchOpts.Robots {
+			// The site opted out, or its robots.txt was unreachable; the
+			// feed's own text is still ours to summarize
+			if text := truncate(htmlText(cmp.Or(p.item.Content, p.item.Description)), 3000, "..."); text != "" {
+				fmt.Printf("   🤖 %v, summarizing the feed's text\n", p.fetchErr)
+				p.content, p.extractor, p.fetchErr = text, "feed", nil
+			}
+		}
+		if canonical != "" {
+			// The id stays the feed's link so dedup is unaffected
+			fmt.Printf("   ↪️  Posting canonical link %s\n", canonical)
+			p.item.Link = canonical
+		}
+	}
+	p.decision.Extractor = p.extractor
+	p.job = feed.Jobs != JOBS_KEEP && p.fetchErr == nil && looksLikeJob(p.item)
+}
+
+// summarize asks the model for the summary and any event, and translates
+// the title. Possible job posts wait for delivery to classify them first;
+// items that score below the relevance threshold, or whose summary failed
+// for a reason that may pass, go no further.
+func (b *Bot) summarize(ctx context.Context, p *pendingItem) {
+	if p.job || p.unclaimed {
+		return
+	}
+	feed, item := p.feed, p.item
+	if p.fetchErr == nil {
+		if !b.screen(ctx, p) {
+			return
+		}
+
+		aiModel := b.cfg.AI.Model
+		structured := b.cfg.AI.Structured && feed.Kind != FEED_RELEASE
+		variant := b.cfg.Prompts.variantFor(feed, p.id)
+		if variant != nil {
+			p.variant = variant.Name
+			p.decision.Variant = variant.Name
+		}
+		prompt, aiErr := b.summaryPrompt(feed, variant, item.Title, p.content, structured)
+
+		p.decision.Model = aiModel
+		var res SummarizeResult
+		generated := false
+		var cached *CachedSummary
+		cacheKey := summaryKey(aiModel, prompt)
+		if aiErr == nil && p.draft == "" {
+			if cached = p.lookupSummary(cacheKey); cached != nil {
+				fmt.Printf("   ♻️  Reusing the summary generated earlier\n")
+				p.decision.Cached = true
+			}
+		}
+		if aiErr == nil && p.draft == "" && cached == nil {
+			aiErr = chaosError(CHAOS_AI)
+		}
+		if aiErr == nil && p.draft == "" && cached == nil {
+			res, aiErr = b.flows.summarize.Run(ctx, SummarizeInput{Prompt: prompt, Structured: structured, Tone: b.cfg.toneFor(feed)})
+			generated = true
+		}
+		if aiErr == nil {
+			var text string
+			switch {
+			case generated:
+				text, p.tags = res.Text, res.Tags
+				p.addTokens(res.InputTokens, res.OutputTokens)
+			case cached != nil:
+				text, p.tags = cached.Text, cached.Tags
+			default:
+				text = p.draft
+			}
+			if aiErr = checkSummary(text); aiErr == nil {
+				if cached == nil {
+					p.storeSummary(cacheKey, text, p.tags)
+				}
+				if p.skipped = b.checkRating(p, res.Fields, text); p.skipped != "" {
+					p.skipFilter = "rating"
+					return
+				}
+				p.summary = b.translate(ctx, TRANSLATE_SUMMARY, text)
+			}
+		}
+		if aiErr != nil {
+			p.aiErr = aiErr
+			p.decision.AIError = aiErr.Error()
+			fmt.Printf("⚠️  AI summary failed (%s): %v\n", item.Title, aiErr)
+			if _, transient := transientAIError(aiErr); transient {
+				p.deferred = true
+				return
+			}
+			if text := feedSummary(item); text != "" {
+				fmt.Printf("   📝 Posting the feed's description instead\n")
+				p.summary = b.translate(ctx, TRANSLATE_SUMMARY, text)
+			}
+		}
+
+		p.tagTopic()
+
+		var eventResp *ai.ModelResponse
+		p.event, eventResp = b.detectEvent(ctx, item, p.content)
+		p.addUsage(eventResp)
+	}
+	title := item.Title
+	if feed.RewriteTitles && p.fetchErr == nil {
+		if rewritten := b.rewriteTitle(ctx, p); rewritten != "" {
+			title, p.originalTitle = rewritten, item.Title
+			p.decision.RewrittenTitle = rewritten
+		}
+	}
+	p.title = b.translate(ctx, TRANSLATE_TITLE, title)
+}
+
+// screen runs the checks that may skip an item before it is summarized, and
+// picks its topic. It runs once per item; it reports whether the item goes on.
+func (b *Bot) screen(ctx context.Context, p *pendingItem) bool {
+	if p.screened {
+		return p.skipped == ""
+	}
+	p.screened = true
+	if reason := b.checkModeration(ctx, p); reason != "" {
+		p.decision.Flagged = reason
+		if b.cfg.Moderation.Action != MODERATION_FLAG {
+			p.skipped, p.skipFilter = "moderation: "+reason, "moderation"
+			return false
+		}
+		fmt.Printf("   ⚠️  Flagged by moderation (%s): %s\n", reason, p.item.Title)
+		p.flagged = reason
+	}
+	if p.skipped = b.checkDuplicate(ctx, p); p.skipped != "" {
+		p.skipFilter = "duplicate"
+		return false
+	}
+	if p.skipped = b.checkRelevance(ctx, p); p.skipped != "" {
+		p.skipFilter = "relevance"
+		return false
+	}
+	p.topic = b.classifyTopic(ctx, p)
+	return true
+}
+
+// summaryPrompt is the prompt for an item: the feed's prompt file, the A/B
+// variant's, the structured prompt for the tone, or the tone's prompt (the
+// release prompt for release feeds) with the category's section changes
+func (b *Bot) summaryPrompt(feed FeedConfig, variant *PromptVariant, title, content string, structured bool) (string, error) {
+	path := b.cfg.Prompts.promptFile(feed)
+	if path == "" && variant != nil {
+		path = variant.File
+	}
+	if path != "" {
+		tmpl, ok := b.prompts[path]
+		if !ok {
+			// a feed newBot didn't see, e.g. one given to resend
+			var err error
+			if tmpl, err = loadPromptFile(path); err != nil {
+				return "", err
+			}
+		}
+		prompt, err := renderPrompt(tmpl, feed, title, content)
+		if err != nil {
+			return "", err
+		}
+		return b.adjustPrompt(prompt, feed, structured), nil
+	}
+	if structured {
+		return b.adjustPrompt(structuredPrompt(b.cfg.toneFor(feed), title, content), feed, true), nil
+	}
+	prompt := promptFor(b.cfg.toneFor(feed))
+	if feed.Kind == FEED_RELEASE {
+		prompt = RELEASE_PROMPT
+	}
+	prompt = fmt.Sprintf(b.cfg.Prompts.withSections(prompt, feed.Category), title, content)
+	return b.adjustPrompt(prompt, feed, false), nil
+}
+
+// adjustPrompt applies the feed's length preset, reader persona and summary
+// language to a summary prompt, in that order
+func (b *Bot) adjustPrompt(prompt string, feed FeedConfig, structured bool) string {
+	prompt = withLength(prompt, b.cfg.lengthFor(feed), structured)
+	prompt = withPersona(prompt, b.cfg.personaFor(feed), structured)
+	return withLanguage(prompt, b.cfg.languageFor(feed))
+}
+
+// sendPost sends an item's message, as the caption of the article's image
+// with telegram.photos when there is one and the message fits; a photo that
+// fails (Telegram couldn't fetch the image) falls back to a plain message
+func (b *Bot) sendPost(ctx context.Context, token, chatID string, threadID int64, image, msg string) (int64, bool, error) {
+	if b.cfg.Telegram.Photos && image != "" && telegramTextLen(msg) <= TELEGRAM_CAPTION_LIMIT {
+		messageID, err := sendPhotoToTopic(ctx, token, chatID, threadID, image, msg)
+		if err == nil {
+			return messageID, true, nil
+		}
+		fmt.Printf("   ⚠️  Photo post failed (%v), sending a message instead\n", err)
+	}
+	messageID, err := sendToTopic(ctx, token, chatID, threadID, msg)
+	return messageID, false, err
+}
+
+// addUsage counts a model response's tokens against the item
+func (p *pendingItem) addUsage(resp *ai.ModelResponse) {
+	if resp == nil || resp.Usage == nil {
+		return
+	}
+	p.addTokens(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+}
+
+func (p *pendingItem) addTokens(input, output int) {
+	p.inputTokens += input
+	p.outputTokens += output
+	p.decision.InputTokens += input
+	p.decision.OutputTokens += output
+}
+
+// deliver formats and sends a summarized item and records the outcome in
+// state, the archive and the decision log; it reports whether a message went out
+func (b *Bot) deliver(ctx context.Context, p *pendingItem, state StateStore, metrics *RunMetrics) bool {
+	if p.unclaimed {
+		metrics.ItemsNew-- // not ours after all
+		return false
+	}
+	if p.job {
+		p.decision.Model = b.cfg.AI.Model
+		if b.handleJob(ctx, p.feed, p.id, p.item, p.content, state, p.decision, metrics) {
+			b.decisions.Record(p.decision)
+			return false
+		}
+		p.job = false
+		b.summarize(ctx, p)
+	}
+
+	token := b.cfg.Telegram.Token
+	feed, id, item, decision := p.feed, p.id, p.item, p.decision
+	if p.skipped == "" && p.embedding != nil {
+		// A story may have been posted since this item was checked
+		if p.skipped = b.checkDuplicate(ctx, p); p.skipped != "" {
+			p.skipFilter = "duplicate"
+		}
+	}
+	if p.skipped != "" {
+		metrics.addTokens(feed.URL, p.inputTokens, p.outputTokens)
+		switch p.skipFilter {
+		case "duplicate":
+			metrics.ItemsDuplicate++
+		case "rating":
+			metrics.ItemsLowRated++
+		case "moderation":
+			metrics.ItemsModerated++
+		default:
+			metrics.ItemsIrrelevant++
+		}
+		if err := state.Mark(id, StateEntry{Title: item.Title, Link: item.Link, Feed: feed.URL, Scores: decision.Scores, Skipped: p.skipped}); err != nil {
+			fmt.Printf("   ⚠️  Could not record item as seen: %v\n", err)
+		}
+		decision.Filters = append(decision.Filters, p.skipFilter)
+		decision.Action = ACTION_SKIPPED
+		decision.Reason = p.skipped
+		b.decisions.Record(decision)
+		fmt.Printf("   🙈 Skipped (%s): %s\n", p.skipped, item.Title)
+		if err := b.combineStory(ctx, p); err != nil {
+			fmt.Printf("   ⚠️  %v\n", err)
+		}
+		return false
+	}
+	if p.deferred {
+		metrics.addTokens(feed.URL, p.inputTokens, p.outputTokens)
+		metrics.ItemsDeferred++
+		releaseItem(state, id)
+		decision.Action = ACTION_DEFERRED
+		decision.Reason = p.aiErr.Error()
+		b.decisions.Record(decision)
+		fmt.Printf("   ⏭️  AI unavailable, trying again next run: %s\n", item.Title)
+		return false
+	}
+	feedURL := feed.URL
+	chatID, threadID := b.route(p)
+	summary, event, title := p.summary, p.event, p.title
+	sent := false
+
+	metrics.addTokens(feedURL, p.inputTokens, p.outputTokens)
+	if p.fetchErr != nil {
+		metrics.FetchFailures++
+		decision.FetchError = p.fetchErr.Error()
+	} else if p.aiErr != nil {
+		metrics.AIFailures++
+	}
+
+	tmpl := b.templates[b.cfg.templateFor(feed)]
+	format := func(title, aiDescript string) string {
+		if tmpl != nil {
+			data := MessageData{
+				Title:     title,
+				Link:      item.Link,
+				Summary:   aiDescript,
+				Feed:      feedURL,
+				Category:  feed.Category,
+				Published: item.published(),
+
+				OriginalTitle: p.originalTitle,
+			}
+			if event != nil {
+				data.Event = strings.TrimPrefix(eventLine(event, item.Link), "\n")
+			}
+			msg, err := renderMessage(tmpl, data)
+			if err == nil {
+				return msg
+			}
+			fmt.Printf("   ⚠️  Message template failed (%v), using the default layout\n", err)
+			tmpl = nil
+		}
+		heading := b.postHeading(feed, item.Link, title)
+		if p.originalTitle != "" {
+			heading += originalTitleLine(p.originalTitle)
+		}
+		msg := fmt.Sprintf("%s\n<blockquote expandable>%s</blockquote>", heading, aiDescript)
+		if event != nil {
+			msg += eventLine(event, item.Link)
+		}
+		return msg
+	}
+	msg := fitMessage(summary, func(text string) string {
+		aiDescript := "NO AI DESCRIPTION"
+		if text != "" {
+			aiDescript = convertToTelegramHTML(text)
+		}
+		if text != summary && item.Link != "" {
+			aiDescript += readMoreLink(item.Link)
+		}
+		if p.flagged != "" {
+			aiDescript = flaggedSummary(p.flagged, aiDescript)
+		}
+		return format(title, aiDescript)
+	})
+	if _, _, err := telegramEntities(msg); err != nil {
+		// Telegram would reject the whole message, so drop the formatting instead
+		fmt.Printf("   ⚠️  Bad markup (%v), sending summary as plain text\n", err)
+		tmpl = nil
+		msg = fitMessage(summary, func(text string) string {
+			aiDescript := html.EscapeString(text)
+			if text != summary && item.Link != "" {
+				aiDescript += readMoreLink(item.Link)
+			}
+			if p.flagged != "" {
+				aiDescript = flaggedSummary(p.flagged, aiDescript)
+			}
+			return format(html.EscapeString(title), aiDescript)
+		})
+	}
+
+	messageID, photo, err := b.sendPost(withAuditItem(context.Background(), id, item.Link), token, chatID, threadID, p.image, msg)
+	if err == nil {
+		if err := state.Mark(id, StateEntry{
+			Title:     title,
+			Link:      item.Link,
+			Feed:      feedURL,
+			Summary:   summary,
+			Scores:    decision.Scores,
+			ChatID:    chatID,
+			MessageID: messageID,
+		}); err != nil {
+			fmt.Printf("   ⚠️  Could not record item as sent: %v\n", err)
+		}
+		storyText := msg
+		if photo {
+			storyText = "" // a caption, which combining stories can't edit as text
+		}
+		b.rememberPost(p, chatID, messageID, storyText)
+		sent = true
+		metrics.PostsSent++
+		metrics.PerFeedSent[feedURL]++
+		decision.Action = ACTION_SENT
+		fmt.Printf("   ✉️  Sent: %s\n", title)
+
+		if event != nil {
+			decision.Filters = append(decision.Filters, "event")
+			post := postLink(&ArchivedItem{Link: item.Link, ChatID: chatID, MessageID: messageID})
+			b.recordEvent(event, id, post)
+			if b.cfg.Events.ICS {
+				if err := b.sendEventICS(ctx, chatID, messageID, event, id, item.Link); err != nil {
+					fmt.Printf("   ⚠️  Calendar file failed: %v\n", err)
+				}
+			}
+		}
+
+		b.archive.Append(ArchivedItem{
+			ID:      id,
+			Feed:    feedURL,
+			Title:   title,
+			Link:    item.Link,
+			Summary: summary,
+			Content: p.content,
+			Tags:    p.tags,
+			Variant: p.variant,
+			SentAt:  time.Now().UTC(),
+
+			ChatID:    chatID,
+			MessageID: messageID,
+		})
+	} else {
+		metrics.SendFailures++
+		metrics.PerFeedFailure[feedURL]++
+		releaseItem(state, id)
+		decision.Action = ACTION_SEND_FAILED
+		decision.Reason = err.Error()
+		fmt.Printf("   ⚠️  Send failed, skipping item: %v\n", err)
+	}
+	b.decisions.Record(decision)
+	return sent
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Failure injection, to exercise retries, feed backoff and alerting in tests
+// and staging before a real incident does. Enabled with the hidden -chaos flag
+// or RSS_CHAOS, e.g. "feed=0.2,ai=0.1,telegram=0.3": the chance (0-1) that
+// each feed fetch, article fetch, AI summary or Telegram send fails.
+const (
+	CHAOS_FEED     = "feed"     // connection reset, which fetches retry
+	CHAOS_ARTICLE  = "article"  // same, for article pages
+	CHAOS_AI       = "ai"       // summary generation error
+	CHAOS_TELEGRAM = "telegram" // 429 Too Many Requests on sends, which are retried after retry_after
+)
+
+var chaos map[string]float64
+
+// Flags left out of -help
+var hiddenFlags = map[string]bool{"chaos": true}
+
+func init() {
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+		flag.VisitAll(func(f *flag.Flag) {
+			if hiddenFlags[f.Name] {
+				return
+			}
+			name, usage := flag.UnquoteUsage(f)
+			line := "  -" + f.Name
+			if name != "" {
+				line += " " + name
+			}
+			line += "\n    \t" + strings.ReplaceAll(usage, "\n", "\n    \t")
+			if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" {
+				line += fmt.Sprintf(" (default %q)", f.DefValue)
+			}
+			fmt.Fprintln(out, line)
+		})
+	}
+}
+
+// configureChaos parses a failure injection spec; empty turns it off
+func configureChaos(spec string) error {
+	chaos = nil
+	if spec == "" {
+		return nil
+	}
+	rates := map[string]float64{}
+	for _, part := range strings.Split(spec, ",") {
+		kind, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		p, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil || p < 0 || p > 1 {
+			return fmt.Errorf("chaos: bad entry %q (want kind=probability, e.g. feed=0.2)", part)
+		}
+		switch kind {
+		case CHAOS_FEED, CHAOS_ARTICLE, CHAOS_AI, CHAOS_TELEGRAM:
+			rates[kind] = p
+		default:
+			return fmt.Errorf("chaos: unknown kind %q (expected feed, article, ai or telegram)", kind)
+		}
+	}
+	chaos = rates
+	fmt.Printf("🐒 Failure injection on: %s\n", spec)
+	return nil
+}
+
+// chaosError returns an injected failure for kind, or nil most of the time
+func chaosError(kind string) error {
+	p := chaos[kind]
+	if p <= 0 || rand.Float64() >= p {
+		return nil
+	}
+	switch kind {
+	case CHAOS_FEED, CHAOS_ARTICLE:
+		return fmt.Errorf("chaos: injected %s failure: %w", kind, syscall.ECONNRESET)
+	case CHAOS_TELEGRAM:
+		return errors.New(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5 (chaos)","parameters":{"retry_after":5}}`)
+	default:
+		return fmt.Errorf("chaos: injected %s failure", kind)
+	}
+}
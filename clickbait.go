@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// Feeds with rewrite_titles have the model replace sensational titles ("You
+// won't believe…") with plain ones that name the subject. The post links the
+// new title and keeps the original under it.
+
+const TITLE_PROMPT = `Decide whether this article title is clickbait: sensational, vague or teasing instead of saying what the article is about ("You won't believe…", "This one trick…", "Everything changes now").
+
+If it is, write a neutral, informative title of at most 100 characters that states the actual subject, using only facts from the article; keep the title's language. If it isn't, leave title empty.
+
+Title: %s
+
+Content:
+%s`
+
+type TitleOutput struct {
+	Clickbait bool   `json:"clickbait"`
+	Title     string `json:"title"`
+}
+
+// rewriteTitle returns a neutral title for a clickbait one, or "" to keep
+// the title. A failed check keeps it too.
+func (b *Bot) rewriteTitle(ctx context.Context, p *pendingItem) string {
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	out, resp, err := genkit.GenerateData[TitleOutput](ctx, b.g,
+		ai.WithPrompt(fmt.Sprintf(TITLE_PROMPT, p.item.Title, truncate(p.content, 1500, "..."))),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	p.addUsage(resp)
+	if err != nil {
+		fmt.Printf("⚠️  Title check failed (%s): %v\n", p.item.Title, err)
+		return ""
+	}
+	title := strings.TrimSpace(out.Title)
+	if !out.Clickbait || title == "" || strings.EqualFold(title, strings.TrimSpace(p.item.Title)) {
+		return ""
+	}
+	fmt.Printf("   ✏️  Retitled: %s → %s\n", p.item.Title, title)
+	return truncate(title, 150, "…")
+}
+
+// originalTitleLine keeps a rewritten post's original title under its heading
+func originalTitleLine(original string) string {
+	return fmt.Sprintf("\n<i>Original title: %s</i>", html.EscapeString(original))
+}
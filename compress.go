@@ -0,0 +1,50 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decompressedBody closes both the decoder and the response body
+type decompressedBody struct {
+	io.Reader
+	body    io.Closer
+	decoder io.Closer // nil when the decoder has nothing to close
+}
+
+func (b decompressedBody) Close() error {
+	if b.decoder != nil {
+		b.decoder.Close()
+	}
+	return b.body.Close()
+}
+
+// decompress decodes a response we asked for compressed ourselves (brotli);
+// responses the transport already decoded are left alone
+func decompress(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var body decompressedBody
+	switch encoding {
+	case "br":
+		body = decompressedBody{Reader: brotli.NewReader(resp.Body), body: resp.Body}
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		body = decompressedBody{Reader: zr, body: resp.Body, decoder: zr}
+	default:
+		return nil
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
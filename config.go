@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const CONFIG_FILE = "config.yaml"
+const CONFIG_ENV = "RSS_CONFIG"
+const CONFIG_CACHE_ENV = "RSS_CONFIG_CACHE"
+const CONFIG_CACHE_FILE = "config.cache.yaml"
+
+// Config is the declarative bot configuration, usually read from config.yaml
+type Config struct {
+	Telegram     TelegramConfig           `yaml:"telegram"`
+	Channels     map[string]ChannelConfig `yaml:"channels"` // name -> chat, referenced by feeds
+	Tone         string                   `yaml:"tone"`     // default tone preset, see tone.go
+	Length       string                   `yaml:"length"`   // short, medium (default) or long summaries; see length.go
+	Language     string                   `yaml:"language"` // summaries' language, e.g. Russian; see language.go
+	Persona      string                   `yaml:"persona"`  // the readers, for a "Why You Should Care" section; see persona.go
+	AI           AIConfig                 `yaml:"ai"`
+	Feeds        []FeedConfig             `yaml:"feeds"`
+	Fetch        FetchConfig              `yaml:"fetch"`    // default user agent and proxy for feeds
+	Schedule     string                   `yaml:"schedule"` // cron expression for runs under `rss serve`
+	DecisionLog  string                   `yaml:"decision_log"`
+	Metrics      MetricsConfig            `yaml:"metrics"`
+	Archive      string                   `yaml:"archive"` // JSONL log of posted items, default archive.jsonl
+	GRPC         GRPCConfig               `yaml:"grpc"`
+	API          APIConfig                `yaml:"api"`
+	Site         SiteConfig               `yaml:"site"`
+	Audit        AuditConfig              `yaml:"audit"`
+	Retention    RetentionConfig          `yaml:"retention"`
+	Secrets      map[string]string        `yaml:"secrets"` // ENV_VAR -> vault://, awssm:// or gcpsm:// reference
+	Translation  TranslationConfig        `yaml:"translation"`
+	Ask          AskConfig                `yaml:"ask"`
+	State        StateConfig              `yaml:"state"`
+	Quiz         QuizConfig               `yaml:"quiz"`
+	Newsletter   NewsletterConfig         `yaml:"newsletter"` // weekly best-of by email and Telegraph
+	Project      ProjectConfig            `yaml:"project"`
+	Jobs         JobsConfig               `yaml:"jobs"` // destination for extracted job posts
+	Events       EventsConfig             `yaml:"events"`
+	Filters      FiltersConfig            `yaml:"filters"` // domain and keyword block/allow lists
+	Relevance    RelevanceConfig          `yaml:"relevance"`
+	Moderation   ModerationConfig         `yaml:"moderation"` // keep adult and abusive content out, see moderation.go
+	Topics       TopicsConfig             `yaml:"topics"`     // taxonomy for hashtags and routing, see topics.go
+	Stories      StoriesConfig            `yaml:"stories"`    // the same story under other links, see stories.go
+	Costs        CostsConfig              `yaml:"costs"`      // token prices and the per-run cost report
+	Bandwidth    BandwidthConfig          `yaml:"bandwidth"`
+	ArticleCache ArticleCacheConfig       `yaml:"article_cache"` // downloaded pages kept for reruns
+	Network      NetworkConfig            `yaml:"network"`       // IP family preference and DNS
+	Prompts      PromptsConfig            `yaml:"prompts"`       // per-category summary sections
+	FeedBackoff  FeedBackoffConfig        `yaml:"feed_backoff"`  // pause feeds that fail several runs in a row
+	Timeouts     TimeoutsConfig           `yaml:"timeouts"`      // per stage: feed, article, AI, Telegram
+
+	MinRating      int           `yaml:"min_rating"`        // skip items the model rates lower, out of 10; 0 posts everything
+	MaxPostsPerRun int           `yaml:"max_posts_per_run"` // default MAX_POSTS_PER_RUN
+	PostDelay      time.Duration `yaml:"post_delay"`        // pause between messages, default POST_DELAY
+
+	// text/template for posts, see templates.go; empty keeps the built-in layout
+	MessageTemplate string `yaml:"message_template"`
+
+	// Named overrides selected with -profile / RSS_PROFILE; any key above may be overridden
+	Profiles map[string]*Config `yaml:"profiles"`
+}
+
+type TelegramConfig struct {
+	Token     string `yaml:"token"`
+	ChannelID string `yaml:"channel_id"`
+	// Post items as the article's og:image with the message as its caption,
+	// when the page has one and the message fits in a caption
+	Photos bool `yaml:"photos"`
+}
+
+type ChannelConfig struct {
+	ChatID   string `yaml:"chat_id"`
+	Tone     string `yaml:"tone,omitempty"`     // overrides the global tone for posts to this chat
+	Length   string `yaml:"length,omitempty"`   // overrides the global summary length
+	Language string `yaml:"language,omitempty"` // overrides the global language of summaries
+	Persona  string `yaml:"persona,omitempty"`  // overrides the global reader persona
+}
+
+// UnmarshalYAML lets a channel be written either as a bare chat id or as a mapping
+func (ch *ChannelConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		ch.ChatID = node.Value
+		return nil
+	}
+	type plain ChannelConfig
+	return node.Decode((*plain)(ch))
+}
+
+type AIConfig struct {
+	APIKey     string        `yaml:"api_key"`     // Google AI (Gemini)
+	Model      string        `yaml:"model"`       // provider/model, see providers.go
+	Fallback   []string      `yaml:"fallback"`    // tried in order when model fails, see fallback.go
+	Attempts   int           `yaml:"attempts"`    // tries per model on 429, 5xx and timeouts; default 3
+	RetryDelay time.Duration `yaml:"retry_delay"` // before the first retry, doubling with jitter, unless the provider says; default 2s
+	Structured bool          `yaml:"structured"`  // summaries as JSON fields, see summary.go
+
+	Vertex    VertexConfig    `yaml:"vertex"`
+	OpenAI    OpenAIConfig    `yaml:"openai"`
+	Azure     AzureConfig     `yaml:"azure"`
+	Anthropic AnthropicConfig `yaml:"anthropic"`
+	Ollama    OllamaConfig    `yaml:"ollama"`
+}
+
+type FeedConfig struct {
+	URL      string `yaml:"url"`
+	Category string `yaml:"category,omitempty"`
+	Channel  string `yaml:"channel,omitempty"` // key into Config.Channels; default is telegram.channel_id
+	Kind     string `yaml:"kind,omitempty"`    // "" (articles), release or docs; see project.go
+	Jobs     string `yaml:"jobs,omitempty"`    // drop or extract job posts mixed into the feed; see jobs.go
+
+	MaxPosts  int           `yaml:"max_posts,omitempty"`  // per-run cap for this feed; 0 means only the global cap
+	MinRating int           `yaml:"min_rating,omitempty"` // overrides min_rating
+	Batch     int           `yaml:"batch,omitempty"`      // summarize up to this many short items per AI request, see batch.go
+	Length    string        `yaml:"length,omitempty"`     // overrides the channel's and global summary length
+	PostDelay time.Duration `yaml:"post_delay,omitempty"` // overrides post_delay
+
+	UserAgent string `yaml:"user_agent,omitempty"` // overrides fetch.user_agent
+	Proxy     string `yaml:"proxy,omitempty"`      // overrides fetch.proxy
+
+	MaxRedirects int    `yaml:"max_redirects,omitempty"` // overrides fetch.max_redirects
+	OffDomain    string `yaml:"off_domain,omitempty"`    // overrides fetch.off_domain
+
+	Timeout time.Duration `yaml:"timeout,omitempty"` // overrides fetch.timeout
+	TLS     TLSConfig     `yaml:"tls,omitempty"`     // replaces fetch.tls
+
+	Template   string `yaml:"template,omitempty"`    // overrides message_template
+	PromptFile string `yaml:"prompt_file,omitempty"` // summary prompt template, see PromptData; overrides the category's
+
+	// Have the model replace clickbait titles with informative ones, keeping
+	// the original under the heading; see clickbait.go
+	RewriteTitles bool `yaml:"rewrite_titles,omitempty"`
+
+	// Look every item up in state instead of skipping those published before
+	// the newest one handled, for feeds that backdate new posts
+	IgnoreDates bool `yaml:"ignore_dates,omitempty"`
+}
+
+// UnmarshalYAML lets a feed be written either as a bare URL or as a mapping
+func (f *FeedConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.URL = node.Value
+		return nil
+	}
+	type plain FeedConfig
+	return node.Decode((*plain)(f))
+}
+
+// envRef matches ${VAR} and ${VAR:-default}
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} references with values from the environment
+func expandEnv(s string) string {
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(m[1]); ok && v != "" {
+			return v
+		}
+		return m[2]
+	})
+}
+
+// expandNode expands env references in every scalar value of a YAML tree.
+// Expanding after parsing keeps secrets containing ':' or '#' from breaking the YAML.
+func expandNode(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag != "!!binary" {
+		node.Value = expandEnv(node.Value)
+	}
+	for _, child := range node.Content {
+		expandNode(child)
+	}
+}
+
+// configPath picks the config file (or URL) from the flag value, RSS_CONFIG, or the default name
+func configPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if p := os.Getenv(CONFIG_ENV); p != "" {
+		return p
+	}
+	return CONFIG_FILE
+}
+
+// loadConfig reads the config file at path, which may also be an https:// or s3:// URL.
+// A missing default file is not an error: the bot then runs on environment
+// variables and the built-in feed list.
+func loadConfig(path string, explicit bool, profile string) (*Config, error) {
+	cfg := &Config{}
+
+	if err := checkConfigScheme(path); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	if isRemoteConfig(path) {
+		data, err = fetchRemoteConfig(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+
+	switch {
+	case err == nil:
+		if err := parseConfig(data, cfg, profile); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	case os.IsNotExist(err) && !explicit && profile == "":
+	default:
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	cfg.applyDefaults()
+	return cfg, nil
+}
+
+// checkConfigScheme refuses a plain http:// config: it may carry bot tokens
+// and secret references, and is cached locally
+func checkConfigScheme(path string) error {
+	if strings.HasPrefix(path, "http://") {
+		return fmt.Errorf("config %s: plain http is not allowed, use https://", path)
+	}
+	return nil
+}
+
+func isRemoteConfig(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "s3://")
+}
+
+// fetchRemoteConfig downloads the config and refreshes the local cached copy.
+// When the remote is unreachable the last cached copy is used instead.
+func fetchRemoteConfig(path string) ([]byte, error) {
+	cachePath := os.Getenv(CONFIG_CACHE_ENV)
+	if cachePath == "" {
+		cachePath = CONFIG_CACHE_FILE
+	}
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(path, "s3://") {
+		data, _, err = s3Get(path)
+	} else {
+		data, err = httpGetConfig(path)
+	}
+
+	if err == nil {
+		// Refuse to cache something that isn't even YAML
+		var probe yaml.Node
+		if perr := yaml.Unmarshal(data, &probe); perr != nil {
+			err = fmt.Errorf("parse failed: %w", perr)
+		}
+	}
+
+	if err != nil {
+		cached, cacheErr := os.ReadFile(cachePath)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("remote config %s: %w (no cached copy)", path, err)
+		}
+		fmt.Printf("⚠️  Remote config unavailable (%v), using cached %s\n", err, cachePath)
+		return cached, nil
+	}
+
+	if err := os.WriteFile(cachePath, data, 0600); err != nil {
+		fmt.Printf("⚠️  Could not cache remote config: %v\n", err)
+	}
+	return data, nil
+}
+
+func httpGetConfig(url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(withPurpose(context.Background(), "config"), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	resp, err := httpDo(req, 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+	if resp.Request.URL.Scheme != "https" {
+		return nil, fmt.Errorf("redirected to plain http: %s", redactURL(resp.Request.URL))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	return body, nil
+}
+
+func parseConfig(data []byte, cfg *Config, profile string) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	if len(root.Content) == 0 {
+		if profile != "" {
+			return fmt.Errorf("profile %q not found: the config is empty", profile)
+		}
+		return nil
+	}
+	// The profile goes first so its secrets: entries override the base ones
+	// before any of them is resolved
+	if err := applyProfile(&root, profile); err != nil {
+		return err
+	}
+	if err := loadSecrets(&root); err != nil {
+		return err
+	}
+	expandNode(&root)
+
+	// Re-encode so the strict decoder can reject unknown keys
+	var buf bytes.Buffer
+	if err := yaml.NewEncoder(&buf).Encode(&root); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	dec := yaml.NewDecoder(&buf)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	return nil
+}
+
+// applyDefaults fills anything the file left empty from the environment and built-ins
+func (c *Config) applyDefaults() {
+	if c.Telegram.Token == "" {
+		c.Telegram.Token = os.Getenv("TG_BOT_TOKEN")
+	}
+	if c.Telegram.ChannelID == "" {
+		c.Telegram.ChannelID = os.Getenv("TG_CHANNEL_ID")
+	}
+	if c.AI.APIKey == "" {
+		c.AI.APIKey = os.Getenv("GEMINI_API_TOKEN")
+	}
+	if c.AI.Model == "" {
+		c.AI.Model = os.Getenv("GEMINI_MODEL")
+	}
+	if c.AI.Vertex.Project == "" {
+		c.AI.Vertex.Project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if c.AI.Vertex.Location == "" {
+		c.AI.Vertex.Location = os.Getenv("GOOGLE_CLOUD_LOCATION")
+	}
+	if c.AI.OpenAI.APIKey == "" {
+		c.AI.OpenAI.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if c.AI.Azure.APIKey == "" {
+		c.AI.Azure.APIKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	}
+	if c.AI.Anthropic.APIKey == "" {
+		c.AI.Anthropic.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if c.DecisionLog == "" {
+		c.DecisionLog = os.Getenv(DECISION_LOG_ENV)
+	}
+	if c.MaxPostsPerRun <= 0 {
+		c.MaxPostsPerRun = MAX_POSTS_PER_RUN
+	}
+	if c.PostDelay <= 0 {
+		c.PostDelay = POST_DELAY
+	}
+	if c.Project.Name != "" {
+		c.Feeds = append(c.Feeds, c.Project.feeds()...)
+	}
+	if len(c.Feeds) == 0 {
+		for _, url := range RSS_FEEDS {
+			c.Feeds = append(c.Feeds, FeedConfig{URL: url})
+		}
+	}
+}
+
+// postDelayFor is the pause after each message from feed
+func (c *Config) postDelayFor(feed FeedConfig) time.Duration {
+	if feed.PostDelay > 0 {
+		return feed.PostDelay
+	}
+	return c.PostDelay
+}
+
+// minRatingFor is the lowest rating a feed's items are posted with
+func (c *Config) minRatingFor(feed FeedConfig) int {
+	if feed.MinRating > 0 {
+		return feed.MinRating
+	}
+	return c.MinRating
+}
+
+// templateFor is the message template source for a feed, empty for the built-in layout
+func (c *Config) templateFor(feed FeedConfig) string {
+	if feed.Template != "" {
+		return feed.Template
+	}
+	return c.MessageTemplate
+}
+
+// chatFor resolves the Telegram chat a feed posts to
+func (c *Config) chatFor(feed FeedConfig) string {
+	if ch, ok := c.Channels[feed.Channel]; ok && feed.Channel != "" {
+		return ch.ChatID
+	}
+	return c.Telegram.ChannelID
+}
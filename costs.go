@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+)
+
+// CostsConfig prices the tokens each run uses, to see which feeds spend the
+// AI budget. Costs are estimates: every token is priced as ai.model's, fallback
+// and relevance models included.
+type CostsConfig struct {
+	Prices map[string]ModelPrice `yaml:"prices"`  // model -> price, added to and overriding DEFAULT_PRICES
+	ChatID string                `yaml:"chat_id"` // admin chat for the report after each run; empty only logs it
+	Top    int                   `yaml:"top"`     // feeds listed in the report, default 5
+}
+
+// ModelPrice is in US dollars per million tokens
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// List prices of common models; they change, so check them against the
+// provider's page and override them in costs.prices. Ollama models are free.
+var DEFAULT_PRICES = map[string]ModelPrice{
+	"googleai/gemini-2.5-pro":        {Input: 1.25, Output: 10},
+	"googleai/gemini-2.5-flash":      {Input: 0.30, Output: 2.50},
+	"googleai/gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40},
+	"googleai/gemini-2.0-flash":      {Input: 0.10, Output: 0.40},
+	"openai/gpt-4o":                  {Input: 2.50, Output: 10},
+	"openai/gpt-4o-mini":             {Input: 0.15, Output: 0.60},
+	"anthropic/claude-sonnet-4-5":    {Input: 3, Output: 15},
+	"anthropic/claude-haiku-4-5":     {Input: 1, Output: 5},
+}
+
+// price is what a model costs, and whether it is known
+func (c CostsConfig) price(model string) (ModelPrice, bool) {
+	if p, ok := c.Prices[model]; ok {
+		return p, true
+	}
+	if modelProvider(model) == PROVIDER_OLLAMA {
+		return ModelPrice{}, true
+	}
+	if modelProvider(model) == PROVIDER_VERTEXAI {
+		model = googleAIModel(model)
+	}
+	p, ok := DEFAULT_PRICES[model]
+	return p, ok
+}
+
+func (p ModelPrice) cost(input, output int) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1e6
+}
+
+// addTokens counts an item's tokens against the run and its feed
+func (m *RunMetrics) addTokens(feed string, input, output int) {
+	m.InputTokens += input
+	m.OutputTokens += output
+	if input+output > 0 {
+		m.PerFeedInputTokens[feed] += input
+		m.PerFeedOutputTokens[feed] += output
+	}
+}
+
+// costReport is the run's token use and estimated cost, with the feeds that
+// used the most; empty when the run used no tokens
+func (b *Bot) costReport(m *RunMetrics) string {
+	if m.InputTokens+m.OutputTokens == 0 {
+		return ""
+	}
+	model := b.cfg.AI.Model
+	price, known := b.cfg.Costs.price(model)
+
+	var r strings.Builder
+	fmt.Fprintf(&r, "💰 Tokens: %d in, %d out", m.InputTokens, m.OutputTokens)
+	if known {
+		fmt.Fprintf(&r, ", about $%.4f (%s)", price.cost(m.InputTokens, m.OutputTokens), model)
+	} else {
+		fmt.Fprintf(&r, " (no price for %s, see costs.prices)", model)
+	}
+
+	feeds := make([]string, 0, len(m.PerFeedInputTokens))
+	for feed := range m.PerFeedInputTokens {
+		feeds = append(feeds, feed)
+	}
+	total := func(feed string) int { return m.PerFeedInputTokens[feed] + m.PerFeedOutputTokens[feed] }
+	slices.SortFunc(feeds, func(a, b string) int {
+		return cmp.Or(cmp.Compare(total(b), total(a)), strings.Compare(a, b))
+	})
+	for _, feed := range feeds[:min(len(feeds), cmp.Or(b.cfg.Costs.Top, 5))] {
+		in, out := m.PerFeedInputTokens[feed], m.PerFeedOutputTokens[feed]
+		fmt.Fprintf(&r, "\n   %s: %d in, %d out", feed, in, out)
+		if known {
+			fmt.Fprintf(&r, ", $%.4f", price.cost(in, out))
+		}
+	}
+	return r.String()
+}
+
+// reportCosts logs the cost report and sends it to costs.chat_id
+func (b *Bot) reportCosts(m *RunMetrics) {
+	report := b.costReport(m)
+	if report == "" {
+		return
+	}
+	fmt.Println(report)
+	if b.cfg.Costs.ChatID == "" {
+		return
+	}
+	if _, err := sendToTelegram(context.Background(), b.cfg.Telegram.Token, b.cfg.Costs.ChatID, html.EscapeString(report)); err != nil {
+		fmt.Printf("⚠️  Sending cost report failed: %v\n", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const DECISION_LOG_ENV = "DECISION_LOG"
+
+// Decision is one structured record of everything the pipeline did with an item
+type Decision struct {
+	Time           time.Time          `json:"time"`
+	Feed           string             `json:"feed"`
+	ItemID         string             `json:"item_id"`
+	Title          string             `json:"title"`
+	Link           string             `json:"link"`
+	Filters        []string           `json:"filters,omitempty"`
+	Extractor      string             `json:"extractor,omitempty"`
+	FetchError     string             `json:"fetch_error,omitempty"`
+	Model          string             `json:"model,omitempty"`
+	InputTokens    int                `json:"input_tokens,omitempty"`
+	OutputTokens   int                `json:"output_tokens,omitempty"`
+	Batch          int                `json:"batch,omitempty"`  // items summarized in the same request
+	Cached         bool               `json:"cached,omitempty"` // the summary was generated by an earlier attempt
+	AIError        string             `json:"ai_error,omitempty"`
+	Scores         map[string]float64 `json:"scores,omitempty"`
+	Topic          string             `json:"topic,omitempty"`           // see topics.go
+	Flagged        string             `json:"flagged,omitempty"`         // what moderation objected to, see moderation.go
+	RewrittenTitle string             `json:"rewritten_title,omitempty"` // replaced a clickbait title, see clickbait.go
+	Variant        string             `json:"variant,omitempty"`         // prompt A/B variant, see prompts.go
+	Action         string             `json:"action"`
+	Reason         string             `json:"reason,omitempty"`
+}
+
+// score records one of the model's scores for the item
+func (d *Decision) score(name string, value float64) {
+	if d.Scores == nil {
+		d.Scores = map[string]float64{}
+	}
+	d.Scores[name] = value
+}
+
+// Final actions recorded for an item
+const (
+	ACTION_SENT        = "sent"
+	ACTION_SEND_FAILED = "send_failed"
+	ACTION_SKIPPED     = "skipped"
+	ACTION_DEFERRED    = "deferred" // left for the next run
+)
+
+// DecisionLog appends decisions to a JSONL file. A nil log discards everything.
+type DecisionLog struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// openDecisionLog opens (or creates) the JSONL file at path; empty path disables logging
+func openDecisionLog(path string) (*DecisionLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open decision log: %w", err)
+	}
+	return &DecisionLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record writes d as a single JSON line
+func (l *DecisionLog) Record(d *Decision) {
+	if l == nil {
+		return
+	}
+	if d.Time.IsZero() {
+		d.Time = time.Now().UTC()
+	}
+	if err := l.enc.Encode(d); err != nil {
+		fmt.Printf("⚠️  Decision log write failed: %v\n", err)
+	}
+}
+
+func (l *DecisionLog) Close() {
+	if l == nil {
+		return
+	}
+	_ = l.f.Close()
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// EvalSample is one labeled article in an eval set (JSONL, one per line)
+type EvalSample struct {
+	URL       string   `json:"url"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content,omitempty"` // fetched from url when empty
+	Category  string   `json:"category,omitempty"`
+	KeyPoints []string `json:"key_points"` // facts a good summary mentions
+}
+
+const JUDGE_PROMPT = `You are checking an article summary for faithfulness.
+Rate from 1 to 5 how well every claim in the summary is supported by the article:
+5 = everything is stated in the article, 1 = mostly invented or wrong.
+Opinion sections (thoughts, rating) count as supported unless they misstate facts.
+
+Article:
+%s
+
+Summary:
+%s`
+
+type judgeOutput struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// evalVariant is one model and tone combination under test
+type evalVariant struct {
+	Model, Tone string
+
+	Runs, Failures            int
+	Coverage                  float64 // summed fraction of key points mentioned
+	Faithfulness              float64 // summed judge score, 1-5
+	Judged                    int
+	Length                    int
+	Latency                   time.Duration
+	InputTokens, OutputTokens int
+	Details                   []string
+}
+
+// coverage is the share of key points the summary mentions; a point counts
+// when most of its significant words appear
+func coverage(summary string, points []string) (float64, []string) {
+	if len(points) == 0 {
+		return 1, nil
+	}
+	have := map[string]bool{}
+	for _, w := range evalWords(summary) {
+		have[w] = true
+	}
+	var missed []string
+	covered := 0
+	for _, p := range points {
+		words := evalWords(p)
+		hits := 0
+		for _, w := range words {
+			if have[w] {
+				hits++
+			}
+		}
+		if len(words) == 0 || float64(hits)/float64(len(words)) >= 0.6 {
+			covered++
+		} else {
+			missed = append(missed, p)
+		}
+	}
+	return float64(covered) / float64(len(points)), missed
+}
+
+// evalWords are the lowercase words of s longer than three letters, crudely stemmed
+func evalWords(s string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) <= 3 {
+			continue
+		}
+		for _, suffix := range []string{"ing", "ed", "es", "s"} {
+			if strings.HasSuffix(w, suffix) && len(w)-len(suffix) > 3 {
+				w = strings.TrimSuffix(w, suffix)
+				break
+			}
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func loadEvalSamples(path string) ([]EvalSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []EvalSample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var s EvalSample
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// runEval implements `rss eval`: every sample is summarized by every model and
+// tone, and the summaries are scored for key-point coverage and, with -judge,
+// faithfulness as rated by another model
+func (b *Bot) runEval(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	samplePath := fs.String("sample", "eval.jsonl", "labeled articles, one JSON object per line")
+	models := fs.String("models", b.cfg.AI.Model, "comma-separated models to compare")
+	tones := fs.String("tones", b.cfg.toneFor(FeedConfig{}), "comma-separated tones (prompts) to compare")
+	judge := fs.String("judge", "", "model that rates faithfulness 1-5 (default: coverage only)")
+	out := fs.String("o", "", "write the markdown report here (default stdout)")
+	fs.Parse(args)
+
+	samples, err := loadEvalSamples(*samplePath)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	for i := range samples {
+		s := &samples[i]
+		if s.Content != "" || s.URL == "" {
+			continue
+		}
+		page, err := fetchArticleContent(s.URL, b.cfg.fetchFor(FeedConfig{}))
+		if err != nil {
+			fmt.Printf("⚠️  %s: %v\n", s.URL, err)
+		}
+		s.Content = page.Text
+	}
+
+	var variants []*evalVariant
+	for _, model := range strings.Split(*models, ",") {
+		for _, tone := range strings.Split(*tones, ",") {
+			if err := checkTone(strings.TrimSpace(tone)); err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				return 2
+			}
+			variants = append(variants, &evalVariant{Model: strings.TrimSpace(model), Tone: strings.TrimSpace(tone)})
+		}
+	}
+
+	for _, v := range variants {
+		fmt.Printf("🧪 %s / %s\n", v.Model, v.Tone)
+		for _, s := range samples {
+			if s.Content == "" {
+				continue
+			}
+			b.evalSample(ctx, v, s, *judge)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	writeEvalReport(w, *samplePath, len(samples), variants, *judge != "")
+	if *out != "" {
+		fmt.Printf("📝 Report written to %s\n", *out)
+	}
+	return 0
+}
+
+func (b *Bot) evalSample(ctx context.Context, v *evalVariant, s EvalSample, judge string) {
+	v.Runs++
+	prompt := b.cfg.Prompts.withSections(promptFor(v.Tone), s.Category)
+	start := time.Now()
+	aiCtx, cancel := aiContext(ctx)
+	resp, err := genkit.Generate(aiCtx, b.g,
+		ai.WithPrompt(fmt.Sprintf(prompt, s.Title, s.Content)),
+		ai.WithModelName(v.Model),
+	)
+	cancel()
+	v.Latency += time.Since(start)
+	if err != nil {
+		v.Failures++
+		v.Details = append(v.Details, fmt.Sprintf("| %s | failed: %s | | |", s.Title, strings.ReplaceAll(err.Error(), "|", "/")))
+		return
+	}
+	summary := resp.Text()
+	if resp.Usage != nil {
+		v.InputTokens += resp.Usage.InputTokens
+		v.OutputTokens += resp.Usage.OutputTokens
+	}
+	if err := checkSummary(summary); err != nil {
+		v.Failures++
+		v.Details = append(v.Details, fmt.Sprintf("| %s | %s | | |", strings.ReplaceAll(s.Title, "|", "/"), strings.ReplaceAll(err.Error(), "|", "/")))
+		return
+	}
+
+	cov, missed := coverage(summary, s.KeyPoints)
+	v.Coverage += cov
+	v.Length += graphemeCount(summary)
+
+	faith := ""
+	if judge != "" {
+		judgeCtx, cancel := aiContext(ctx)
+		out, _, err := genkit.GenerateData[judgeOutput](judgeCtx, b.g,
+			ai.WithPrompt(fmt.Sprintf(JUDGE_PROMPT, truncate(s.Content, 8000, "..."), summary)),
+			ai.WithModelName(judge),
+		)
+		cancel()
+		if err == nil && out.Score >= 1 && out.Score <= 5 {
+			v.Faithfulness += float64(out.Score)
+			v.Judged++
+			faith = fmt.Sprint(out.Score)
+		} else if err != nil {
+			fmt.Printf("⚠️  Judge failed: %v\n", err)
+		}
+	}
+	v.Details = append(v.Details, fmt.Sprintf("| %s | %.0f%% | %s | %s |",
+		strings.ReplaceAll(s.Title, "|", "/"), cov*100, faith, strings.ReplaceAll(strings.Join(missed, "; "), "|", "/")))
+}
+
+func writeEvalReport(w io.Writer, samplePath string, samples int, variants []*evalVariant, judged bool) {
+	fmt.Fprintf(w, "# Summary eval\n\n%d sample(s) from %s, %s\n\n", samples, samplePath, time.Now().Format(time.DateTime))
+	fmt.Fprintln(w, "| Model | Tone | Runs | Failed | Coverage | Faithfulness | Avg length | Avg latency | Tokens in/out |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|---|---|")
+	for _, v := range variants {
+		ok := max(v.Runs-v.Failures, 1)
+		faith := "–"
+		if judged && v.Judged > 0 {
+			faith = fmt.Sprintf("%.2f / 5", v.Faithfulness/float64(v.Judged))
+		}
+		fmt.Fprintf(w, "| %s | %s | %d | %d | %.0f%% | %s | %d | %s | %d / %d |\n",
+			v.Model, v.Tone, v.Runs, v.Failures, v.Coverage/float64(ok)*100, faith,
+			v.Length/ok, (v.Latency / time.Duration(max(v.Runs, 1))).Round(time.Millisecond),
+			v.InputTokens, v.OutputTokens)
+	}
+	for _, v := range variants {
+		fmt.Fprintf(w, "\n## %s / %s\n\n| Article | Coverage | Faithfulness | Missed key points |\n|---|---|---|---|\n", v.Model, v.Tone)
+		for _, d := range v.Details {
+			fmt.Fprintln(w, d)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+const EVENTS_FILE = "events.json"
+
+// EventsConfig turns on event detection: posts announcing conferences, CFPs or
+// webinars get an "Add to calendar" link, and a pinned message lists what's coming up
+type EventsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	ICS     bool   `yaml:"ics"`  // also reply to the post with an .ics file
+	Pin     bool   `yaml:"pin"`  // keep a pinned "upcoming events" message in the main channel
+	File    string `yaml:"file"` // upcoming events and the pinned message id, default events.json
+}
+
+const EVENT_PROMPT = `Decide whether this article announces a specific upcoming event: a conference, a call for papers/proposals (CFP), a webinar, a meetup or a workshop.
+News about a past event or an article that merely mentions one is not an event announcement.
+If it is, extract it. Dates are YYYY-MM-DD; for a CFP, start is the submission deadline. Leave unknown fields empty.
+
+Title: %s
+Link: %s
+
+Content:
+%s`
+
+type EventInfo struct {
+	IsEvent  bool   `json:"is_event"`
+	Kind     string `json:"kind"` // conference, cfp, webinar, meetup, workshop
+	Name     string `json:"name"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Location string `json:"location"`
+	URL      string `json:"url"`
+}
+
+var eventHint = regexp.MustCompile(`(?i)\b(conference|summit|con \d{4}|cfp|call for (papers|proposals|speakers|talks)|webinar|meetup|workshop|hackathon|livestream|register now|registration (is )?open|save the date)\b`)
+
+func looksLikeEvent(item Item) bool {
+	return eventHint.MatchString(item.Title) || eventHint.MatchString(item.Description)
+}
+
+// startDate parses the event's start, reporting false when it is missing or malformed
+func (e *EventInfo) startDate() (time.Time, bool) {
+	t, err := time.Parse("2006-01-02", e.Start)
+	return t, err == nil
+}
+
+// endDate is the last day of the event, the start day when unknown
+func (e *EventInfo) endDate() time.Time {
+	start, _ := e.startDate()
+	if end, err := time.Parse("2006-01-02", e.End); err == nil && !end.Before(start) {
+		return end
+	}
+	return start
+}
+
+func (e *EventInfo) title() string {
+	if e.Kind == "cfp" {
+		return "CFP deadline: " + e.Name
+	}
+	return e.Name
+}
+
+func (e *EventInfo) link(fallback string) string {
+	if e.URL != "" {
+		return e.URL
+	}
+	return fallback
+}
+
+// detectEvent asks the model about items that look like announcements; nil means no usable event
+func (b *Bot) detectEvent(ctx context.Context, item Item, content string) (*EventInfo, *ai.ModelResponse) {
+	if !b.cfg.Events.Enabled || !looksLikeEvent(item) {
+		return nil, nil
+	}
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	event, resp, err := genkit.GenerateData[EventInfo](ctx, b.g,
+		ai.WithPrompt(fmt.Sprintf(EVENT_PROMPT, item.Title, item.Link, truncate(content, 3000, "..."))),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	if err != nil {
+		fmt.Printf("⚠️  Event check failed: %v\n", err)
+		return nil, resp
+	}
+	start, ok := event.startDate()
+	if !event.IsEvent || event.Name == "" || !ok || event.endDate().Before(start) || start.Before(time.Now().AddDate(0, 0, -1)) {
+		return nil, resp
+	}
+	return event, resp
+}
+
+// googleCalendarURL prefills a Google Calendar all-day event
+func googleCalendarURL(e *EventInfo, link string) string {
+	q := url.Values{}
+	q.Set("action", "TEMPLATE")
+	q.Set("text", e.title())
+	start, _ := e.startDate()
+	// All-day events end on the following day, exclusive
+	q.Set("dates", start.Format("20060102")+"/"+e.endDate().AddDate(0, 0, 1).Format("20060102"))
+	q.Set("details", e.link(link))
+	if e.Location != "" {
+		q.Set("location", e.Location)
+	}
+	return "https://calendar.google.com/calendar/render?" + q.Encode()
+}
+
+// icsEscape escapes TEXT values per RFC 5545
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// eventICS renders a single all-day event as an iCalendar file
+func eventICS(e *EventInfo, id, link string) []byte {
+	start, _ := e.startDate()
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//rss//events//EN",
+		"BEGIN:VEVENT",
+		"UID:" + id + "@rss",
+		"DTSTAMP:" + time.Now().UTC().Format("20060102T150405Z"),
+		"DTSTART;VALUE=DATE:" + start.Format("20060102"),
+		"DTEND;VALUE=DATE:" + e.endDate().AddDate(0, 0, 1).Format("20060102"),
+		"SUMMARY:" + icsEscape(e.title()),
+		"URL:" + e.link(link),
+	}
+	if e.Location != "" {
+		lines = append(lines, "LOCATION:"+icsEscape(e.Location))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR", "")
+	return []byte(strings.Join(lines, "\r\n"))
+}
+
+// eventLine is appended to a post announcing an event
+func eventLine(e *EventInfo, link string) string {
+	return fmt.Sprintf("\n📅 %s · <a href=\"%s\">Add to calendar</a>", eventDates(e), html.EscapeString(googleCalendarURL(e, link)))
+}
+
+func eventDates(e *EventInfo) string {
+	start, _ := e.startDate()
+	end := e.endDate()
+	switch {
+	case end.Equal(start):
+		return start.Format("Jan 2, 2006")
+	case end.Month() == start.Month() && end.Year() == start.Year():
+		return fmt.Sprintf("%s–%d, %d", start.Format("Jan 2"), end.Day(), start.Year())
+	default:
+		return fmt.Sprintf("%s – %s", start.Format("Jan 2"), end.Format("Jan 2, 2006"))
+	}
+}
+
+// sendEventICS replies to the post with the event as an .ics attachment
+func (b *Bot) sendEventICS(ctx context.Context, chatID string, replyTo int64, e *EventInfo, id, link string) error {
+	fields := map[string]string{
+		"chat_id":          chatID,
+		"reply_parameters": fmt.Sprintf(`{"message_id":%d,"allow_sending_without_reply":true}`, replyTo),
+	}
+	return telegramUpload(ctx, b.cfg.Telegram.Token, "sendDocument", fields, "document", "event.ics", eventICS(e, id, link), nil)
+}
+
+// upcomingEvent is one entry of the pinned list
+type upcomingEvent struct {
+	EventInfo
+	ItemID string `json:"item_id"`
+	Post   string `json:"post"` // link to our post about it
+}
+
+type eventsFile struct {
+	PinnedMessageID int64           `json:"pinned_message_id,omitempty"`
+	Events          []upcomingEvent `json:"events"`
+}
+
+func (b *Bot) eventsPath() string {
+	if b.cfg.Events.File != "" {
+		return b.cfg.Events.File
+	}
+	return EVENTS_FILE
+}
+
+func (b *Bot) loadEvents() *eventsFile {
+	ef := &eventsFile{}
+	data, err := os.ReadFile(b.eventsPath())
+	if err == nil {
+		_ = json.Unmarshal(data, ef)
+	}
+	return ef
+}
+
+func (b *Bot) saveEvents(ef *eventsFile) error {
+	data, _ := json.MarshalIndent(ef, "", "  ")
+	return writeFileAtomic(b.eventsPath(), data, 0644)
+}
+
+// recordEvent adds a posted event to the upcoming list
+func (b *Bot) recordEvent(e *EventInfo, itemID, post string) {
+	ef := b.loadEvents()
+	for _, existing := range ef.Events {
+		if existing.ItemID == itemID {
+			return
+		}
+	}
+	ef.Events = append(ef.Events, upcomingEvent{EventInfo: *e, ItemID: itemID, Post: post})
+	if err := b.saveEvents(ef); err != nil {
+		fmt.Printf("⚠️  Saving events failed: %v\n", err)
+	}
+}
+
+// updateEventsPin drops past events and rewrites the pinned upcoming-events message
+func (b *Bot) updateEventsPin(ctx context.Context) error {
+	if !b.cfg.Events.Enabled || !b.cfg.Events.Pin {
+		return nil
+	}
+	ef := b.loadEvents()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var upcoming []upcomingEvent
+	for _, e := range ef.Events {
+		if !e.endDate().Before(today) {
+			upcoming = append(upcoming, e)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].Start < upcoming[j].Start })
+	ef.Events = upcoming
+	if ef.PinnedMessageID == 0 && len(upcoming) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📅 <b>Upcoming events</b>\n")
+	if len(upcoming) == 0 {
+		sb.WriteString("\nNothing announced right now.")
+	}
+	for _, e := range upcoming {
+		line := fmt.Sprintf("\n• %s — <a href=\"%s\">%s</a>", eventDates(&e.EventInfo), html.EscapeString(e.link(e.Post)), html.EscapeString(e.title()))
+		if e.Location != "" {
+			line += " · " + html.EscapeString(e.Location)
+		}
+		if telegramTextLen(sb.String()+line) > TELEGRAM_MESSAGE_LIMIT {
+			break
+		}
+		sb.WriteString(line)
+	}
+	text := sb.String()
+
+	token, chatID := b.cfg.Telegram.Token, b.cfg.Telegram.ChannelID
+	if ef.PinnedMessageID != 0 {
+		err := telegramCall(ctx, token, "editMessageText", map[string]any{
+			"chat_id":                  chatID,
+			"message_id":               ef.PinnedMessageID,
+			"text":                     text,
+			"parse_mode":               "HTML",
+			"disable_web_page_preview": true,
+		}, nil)
+		if err == nil || strings.Contains(err.Error(), "message is not modified") {
+			return b.saveEvents(ef)
+		}
+		fmt.Printf("⚠️  Editing the events message failed, posting a new one: %v\n", err)
+	}
+
+	messageID, err := sendToTelegram(ctx, token, chatID, text)
+	if err != nil {
+		return err
+	}
+	ef.PinnedMessageID = messageID
+	if err := telegramCall(ctx, token, "pinChatMessage", map[string]any{
+		"chat_id":              chatID,
+		"message_id":           messageID,
+		"disable_notification": true,
+	}, nil); err != nil {
+		fmt.Printf("⚠️  Pinning the events message failed: %v\n", err)
+	}
+	fmt.Printf("📌 Pinned upcoming events (message %d)\n", messageID)
+	return b.saveEvents(ef)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// exportRow is one posted item joined with what the decision log knows about it
+type exportRow struct {
+	ID           string             `parquet:"id"`
+	Feed         string             `parquet:"feed"`
+	Category     string             `parquet:"category,optional"`
+	Domain       string             `parquet:"domain"`
+	Title        string             `parquet:"title"`
+	Link         string             `parquet:"link"`
+	SentAt       time.Time          `parquet:"sent_at,timestamp(millisecond)"`
+	ChatID       string             `parquet:"chat_id,optional"`
+	MessageID    int64              `parquet:"message_id,optional"`
+	Tags         []string           `parquet:"tags,list"`
+	Variant      string             `parquet:"variant,optional"`
+	SummaryChars int                `parquet:"summary_chars"`
+	ContentChars int                `parquet:"content_chars"`
+	Extractor    string             `parquet:"extractor,optional"`
+	Model        string             `parquet:"model,optional"`
+	InputTokens  int                `parquet:"input_tokens"`
+	OutputTokens int                `parquet:"output_tokens"`
+	Filters      []string           `parquet:"filters,list"`
+	Scores       map[string]float64 `parquet:"scores"`
+}
+
+var exportColumns = []string{
+	"id", "feed", "category", "domain", "title", "link", "sent_at", "chat_id", "message_id", "tags", "variant",
+	"summary_chars", "content_chars", "extractor", "model", "input_tokens", "output_tokens", "filters", "scores",
+}
+
+// sentDecisions indexes the decision log by item id, keeping the record of the actual send
+func sentDecisions(path string) (map[string]*Decision, error) {
+	decisions := map[string]*Decision{}
+	if path == "" {
+		return decisions, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return decisions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var d Decision
+		if json.Unmarshal(scanner.Bytes(), &d) == nil && d.Action == ACTION_SENT {
+			decisions[d.ItemID] = &d
+		}
+	}
+	return decisions, scanner.Err()
+}
+
+func exportRows(cfg *Config) ([]exportRow, error) {
+	items, err := openArchive(cfg.Archive).All()
+	if err != nil {
+		return nil, err
+	}
+	decisions, err := sentDecisions(cfg.DecisionLog)
+	if err != nil {
+		return nil, fmt.Errorf("decision log: %w", err)
+	}
+	categories := map[string]string{}
+	for _, feed := range cfg.Feeds {
+		categories[feed.URL] = feed.Category
+	}
+
+	rows := make([]exportRow, 0, len(items))
+	for _, item := range items {
+		row := exportRow{
+			ID:           item.ID,
+			Feed:         item.Feed,
+			Category:     categories[item.Feed],
+			Domain:       strings.TrimPrefix(hostOf(item.Link), "www."),
+			Title:        item.Title,
+			Link:         item.Link,
+			SentAt:       item.SentAt,
+			ChatID:       item.ChatID,
+			MessageID:    item.MessageID,
+			Tags:         item.Tags,
+			Variant:      item.Variant,
+			SummaryChars: len([]rune(item.Summary)),
+			ContentChars: len([]rune(item.Content)),
+		}
+		if d := decisions[item.ID]; d != nil {
+			row.Extractor = d.Extractor
+			row.Model = d.Model
+			row.InputTokens = d.InputTokens
+			row.OutputTokens = d.OutputTokens
+			row.Filters = d.Filters
+			row.Scores = d.Scores
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func writeCSV(w io.Writer, rows []exportRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write(exportColumns)
+	for _, r := range rows {
+		scores := ""
+		if len(r.Scores) > 0 {
+			b, _ := json.Marshal(r.Scores)
+			scores = string(b)
+		}
+		cw.Write([]string{
+			r.ID, r.Feed, r.Category, r.Domain, r.Title, r.Link, r.SentAt.Format(time.RFC3339), r.ChatID,
+			strconv.FormatInt(r.MessageID, 10), strings.Join(r.Tags, ";"), r.Variant,
+			strconv.Itoa(r.SummaryChars), strconv.Itoa(r.ContentChars), r.Extractor, r.Model,
+			strconv.Itoa(r.InputTokens), strconv.Itoa(r.OutputTokens), strings.Join(r.Filters, ";"), scores,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// runArchiveExport implements `rss archive export [-format csv|parquet] [-o file]`
+func runArchiveExport(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("archive export", flag.ExitOnError)
+	format := fs.String("format", "csv", "csv or parquet")
+	out := fs.String("o", "", "output file (default archive.<format>)")
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Println("Usage: rss archive export [-format csv|parquet] [-o file]")
+		return 2
+	}
+	fs.Parse(args[1:])
+
+	if *format != "csv" && *format != "parquet" {
+		fmt.Printf("Unknown format %q (expected csv or parquet)\n", *format)
+		return 2
+	}
+	path := *out
+	if path == "" {
+		path = "archive." + *format
+	}
+
+	rows, err := exportRows(cfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	if *format == "parquet" {
+		err = parquet.Write(f, rows)
+	} else {
+		err = writeCSV(f, rows)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Export failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("📦 Exported %d item(s) to %s\n", len(rows), path)
+	return 0
+}
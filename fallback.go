@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// withFallback makes a model call retry transient failures (see airetry.go)
+// and then try the models in ai.fallback, in order, when ai.model still fails
+// (quota, overload, outage), before giving up. They answer the same request,
+// prompt and output schema included, within the same timeouts.ai.
+func withFallback(g *genkit.Genkit, cfg AIConfig) ai.CommonGenOption {
+	return ai.WithMiddleware(func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			resp, err := retryModel(ctx, cfg, cfg.Model, func(ctx context.Context) (*ai.ModelResponse, error) {
+				return next(ctx, req, cb)
+			})
+			failed := cfg.Model
+			for _, name := range cfg.Fallback {
+				if err == nil || ctx.Err() != nil {
+					break
+				}
+				model := genkit.LookupModel(g, name)
+				if model == nil {
+					fmt.Printf("⚠️  Fallback model %s is not available\n", name)
+					continue
+				}
+				fmt.Printf("🔁 %s failed (%v), falling back to %s\n", failed, err, name)
+				resp, err = retryModel(ctx, cfg, name, func(ctx context.Context) (*ai.ModelResponse, error) {
+					return model.Generate(ctx, req, cb)
+				})
+				failed = name
+			}
+			return resp, err
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+)
+
+// discoverFeed returns pageURL itself if it is a feed, otherwise the first
+// RSS/Atom feed advertised by the page's <link rel="alternate"> tags
+func discoverFeed(pageURL string, opts FetchConfig) (string, error) {
+	resp, err := opts.get("feed", pageURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read failed: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	trimmed := bytes.TrimSpace(body)
+	if strings.Contains(contentType, "xml") || bytes.HasPrefix(trimmed, []byte("<?xml")) ||
+		bytes.HasPrefix(trimmed, []byte("<rss")) || bytes.HasPrefix(trimmed, []byte("<feed")) {
+		return pageURL, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("parse failed: %w", err)
+	}
+
+	var found string
+	doc.Find(`link[rel="alternate"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		t, _ := s.Attr("type")
+		href, ok := s.Attr("href")
+		if !ok || (t != "application/rss+xml" && t != "application/atom+xml") {
+			return true
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			return true
+		}
+		found = resp.Request.URL.ResolveReference(ref).String()
+		return false
+	})
+
+	if found == "" {
+		return "", fmt.Errorf("%s is not a feed and advertises no RSS/Atom link", pageURL)
+	}
+	return found, nil
+}
+
+// loadConfigNode reads the config file as a YAML tree so it can be edited
+// without losing comments or expanding ${VAR} references
+func loadConfigNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("config %s: parse failed: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config %s: top level must be a mapping", path)
+	}
+	return &root, nil
+}
+
+func saveConfigNode(path string, root *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// feedsNode returns the feeds sequence, creating it when missing. Because an
+// empty list means "use the built-in feeds", a new list starts with those.
+func feedsNode(root *yaml.Node) *yaml.Node {
+	top := root.Content[0]
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value == "feeds" && top.Content[i+1].Kind == yaml.SequenceNode {
+			if len(top.Content[i+1].Content) > 0 {
+				return top.Content[i+1]
+			}
+			top.Content = append(top.Content[:i], top.Content[i+2:]...)
+			break
+		}
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, feed := range RSS_FEEDS {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: feed})
+	}
+	top.Content = append(top.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "feeds"}, seq)
+	fmt.Printf("ℹ️  Config had no feed list; starting from the %d built-in feeds\n", len(RSS_FEEDS))
+	return seq
+}
+
+// feedNodeURL returns the URL of a feed entry written as a scalar or a mapping
+func feedNodeURL(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "url" {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// runFeedCommand implements `rss feed add|remove`
+func runFeedCommand(cfg *Config, path string, args []string) int {
+	usage := "Usage: rss feed add [-category name] [-channel name] [-no-check] <url>\n       rss feed remove <url>"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return 2
+	}
+	if isRemoteConfig(path) {
+		fmt.Printf("⚠️  %s is a remote config; edit it at the source\n", path)
+		return 1
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("feed add", flag.ExitOnError)
+		category := fs.String("category", "", "category for the feed")
+		channel := fs.String("channel", "", "name of the channel (under channels:) to post to")
+		noCheck := fs.Bool("no-check", false, "skip autodiscovery and the validation fetch")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			fmt.Println(usage)
+			return 2
+		}
+		if err := feedAdd(cfg, path, fs.Arg(0), *category, *channel, !*noCheck); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return 1
+		}
+	case "remove":
+		if len(args) != 2 {
+			fmt.Println(usage)
+			return 2
+		}
+		if err := feedRemove(path, args[1]); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Println(usage)
+		return 2
+	}
+	return 0
+}
+
+func feedAdd(cfg *Config, path, rawURL, category, channel string, check bool) error {
+	if err := checkHTTPURL(rawURL); err != nil {
+		return err
+	}
+
+	feedURL := rawURL
+	if check {
+		// Fetched as the bot would, so a feed it can reach is accepted here
+		discovered, err := discoverFeed(rawURL, cfg.fetchFor(FeedConfig{URL: rawURL}))
+		if err != nil {
+			return err
+		}
+		if discovered != rawURL {
+			fmt.Printf("🔎 Discovered feed: %s\n", discovered)
+		}
+		feedURL = discovered
+
+		rss, err := fetchRSS(feedURL, cfg.fetchFor(FeedConfig{URL: feedURL}))
+		if err != nil {
+			return fmt.Errorf("validation fetch of %s: %w", feedURL, err)
+		}
+		if len(rss.Channel.Items) == 0 {
+			fmt.Printf("⚠️  %s parsed but has no items right now\n", feedURL)
+		} else {
+			fmt.Printf("   Found %d items, latest: %s\n", len(rss.Channel.Items), rss.Channel.Items[0].Title)
+		}
+	}
+
+	root, err := loadConfigNode(path)
+	if err != nil {
+		return err
+	}
+
+	if channel != "" {
+		cfg := &Config{}
+		if err := root.Decode(cfg); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+		if _, ok := cfg.Channels[channel]; !ok {
+			return fmt.Errorf("unknown channel %q; define it under channels: first", channel)
+		}
+	}
+
+	feeds := feedsNode(root)
+	for _, node := range feeds.Content {
+		if feedKey(feedNodeURL(node)) == feedKey(feedURL) {
+			return fmt.Errorf("%s is already in %s", feedURL, path)
+		}
+	}
+
+	entry := &yaml.Node{Kind: yaml.ScalarNode, Value: feedURL}
+	if category != "" || channel != "" {
+		entry = &yaml.Node{Kind: yaml.MappingNode}
+		add := func(k, v string) {
+			entry.Content = append(entry.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: v})
+		}
+		add("url", feedURL)
+		if category != "" {
+			add("category", category)
+		}
+		if channel != "" {
+			add("channel", channel)
+		}
+	}
+	feeds.Content = append(feeds.Content, entry)
+
+	if err := saveConfigNode(path, root); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Added %s to %s\n", feedURL, path)
+	return nil
+}
+
+func feedRemove(path, feedURL string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	root, err := loadConfigNode(path)
+	if err != nil {
+		return err
+	}
+
+	feeds := feedsNode(root)
+	kept := feeds.Content[:0]
+	removed := 0
+	for _, node := range feeds.Content {
+		if feedKey(feedNodeURL(node)) == feedKey(feedURL) {
+			removed++
+			continue
+		}
+		kept = append(kept, node)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%s is not in %s", feedURL, path)
+	}
+	feeds.Content = kept
+
+	if err := saveConfigNode(path, root); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Removed %s from %s\n", feedURL, path)
+	if len(kept) == 0 {
+		fmt.Println("⚠️  The feed list is now empty, so the built-in feeds will be used")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// FeedStatus is the fetch history of one feed, kept in the state store
+type FeedStatus struct {
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+	Failures    int       `json:"failures,omitempty"` // consecutive failed runs
+
+	// Hash of the last feed body whose items were all handled; the same
+	// body again is skipped without parsing
+	BodyHash string `json:"body_hash,omitempty"`
+	// Items published at or before this were all handled and aren't looked up again
+	LastPubDate time.Time `json:"last_pub_date,omitzero"`
+}
+
+// newItems drops the items published at or before the feed's cutoff
+func (s FeedStatus) newItems(items []Item) []Item {
+	if s.LastPubDate.IsZero() {
+		return items
+	}
+	fresh := make([]Item, 0, len(items))
+	for _, item := range items {
+		if date := item.published(); date.IsZero() || date.After(s.LastPubDate) {
+			fresh = append(fresh, item)
+		}
+	}
+	return fresh
+}
+
+// FeedBackoffConfig pauses feeds that keep failing. After `after` failed runs
+// in a row the feed is skipped for `base`, doubling with every further failure
+// up to `max`; the first successful fetch resets it.
+type FeedBackoffConfig struct {
+	After int           `yaml:"after"` // default 3, -1 never backs off
+	Base  time.Duration `yaml:"base"`  // default 1h
+	Max   time.Duration `yaml:"max"`   // default 24h
+}
+
+// retryAt is when a feed with this status may be fetched again, zero if it isn't backed off
+func (c FeedBackoffConfig) retryAt(status FeedStatus) time.Time {
+	after, base, limit := c.After, c.Base, c.Max
+	if after == 0 {
+		after = 3
+	}
+	if base <= 0 {
+		base = time.Hour
+	}
+	if limit <= 0 {
+		limit = 24 * time.Hour
+	}
+	if after < 0 || status.Failures < after {
+		return time.Time{}
+	}
+
+	wait := base
+	for i := after; i < status.Failures && wait < limit; i++ {
+		wait *= 2
+	}
+	return status.LastErrorAt.Add(min(wait, limit))
+}
+
+// recordFetch updates a feed's status after a fetch attempt
+func recordFetch(state StateStore, feedURL string, status FeedStatus, err error) FeedStatus {
+	now := time.Now().UTC()
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		status.LastErrorAt = now
+	} else {
+		status.Failures = 0
+		status.LastSuccess = now
+	}
+	if err := state.SetFeedStatus(feedURL, status); err != nil {
+		fmt.Printf("⚠️  Could not record feed status: %v\n", err)
+	}
+	return status
+}
+
+// recordHandled runs after a feed's new items were processed. It remembers
+// the feed body once every item in it is seen, so an unchanged feed is
+// skipped next run, and moves the pubDate cutoff up to just before the oldest
+// item still pending (send failures, post limits) so those are retried.
+func recordHandled(state StateStore, feedURL string, status FeedStatus, bodyHash string, items []Item, useDates bool) {
+	type dated struct {
+		date time.Time
+		seen bool
+	}
+	var byDate []dated
+	for _, item := range items {
+		seen, err := seenLink(state, item.Link)
+		seen = seen && err == nil
+		if !seen {
+			bodyHash = ""
+		}
+		if date := item.published(); !date.IsZero() {
+			byDate = append(byDate, dated{date, seen})
+		}
+	}
+
+	cutoff := status.LastPubDate
+	if useDates {
+		var pending time.Time // oldest item not yet handled
+		for _, d := range byDate {
+			if !d.seen && (pending.IsZero() || d.date.Before(pending)) {
+				pending = d.date
+			}
+		}
+		for _, d := range byDate {
+			if (pending.IsZero() || d.date.Before(pending)) && d.date.After(cutoff) {
+				cutoff = d.date
+			}
+		}
+	}
+
+	if status.BodyHash == bodyHash && status.LastPubDate.Equal(cutoff) {
+		return
+	}
+	status.BodyHash, status.LastPubDate = bodyHash, cutoff
+	if err := state.SetFeedStatus(feedURL, status); err != nil {
+		fmt.Printf("⚠️  Could not record feed status: %v\n", err)
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchConfig controls how feeds and articles are requested. Set globally
+// under `fetch:` and overridden per feed.
+type FetchConfig struct {
+	UserAgent string    `yaml:"user_agent,omitempty"` // default rss-bot/VERSION (+contact)
+	Contact   string    `yaml:"contact,omitempty"`    // URL or email for site owners, put in the default user agent
+	Proxy     string    `yaml:"proxy,omitempty"`      // http://, https:// or socks5:// URL
+	TLS       TLSConfig `yaml:"tls,omitempty"`
+
+	MaxRedirects int    `yaml:"max_redirects,omitempty"` // default 10; -1 follows none
+	OffDomain    string `yaml:"off_domain,omitempty"`    // redirects to another site: allow (default), deny or canonical
+
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // per request, body included; default timeouts.feed / timeouts.article
+	Attempts   int           `yaml:"attempts,omitempty"`    // tries per request on timeouts, resets, 429 and 5xx; default 3
+	RetryDelay time.Duration `yaml:"retry_delay,omitempty"` // before the first retry, doubling with jitter; default 1s
+
+	// Responses are always requested gzip-compressed; this also accepts
+	// brotli, which is smaller still for large feeds
+	Brotli bool `yaml:"brotli,omitempty"`
+
+	// Check each site's robots.txt (cached for a day) before downloading an
+	// article page; disallowed articles are summarized from the feed's own text
+	Robots bool `yaml:"robots,omitempty"`
+
+	// Internal ranges fetches may reach, as CIDRs or addresses, e.g.
+	// 10.20.0.0/16; everything else private, loopback or link-local is
+	// refused. Only the global setting applies.
+	AllowNetworks []string `yaml:"allow_networks,omitempty"`
+
+	// Spacing of requests to one host, shared by all feeds; only the global setting applies
+	HostRate  float64            `yaml:"host_rate,omitempty"`  // requests per second to any one host, 0 for no limit
+	HostBurst int                `yaml:"host_burst,omitempty"` // requests allowed back to back, default 1
+	HostRates map[string]float64 `yaml:"host_rates,omitempty"` // per domain (subdomains included), e.g. medium.com: 0.5
+
+	maxBytes int64 // article download cap from bandwidth.max_download_kb
+}
+
+// fetchFor merges a feed's overrides over the global fetch settings
+func (c *Config) fetchFor(feed FeedConfig) FetchConfig {
+	opts := c.Fetch
+	if feed.UserAgent != "" {
+		opts.UserAgent = feed.UserAgent
+	}
+	if feed.Proxy != "" {
+		opts.Proxy = feed.Proxy
+	}
+	if feed.MaxRedirects != 0 {
+		opts.MaxRedirects = feed.MaxRedirects
+	}
+	if feed.OffDomain != "" {
+		opts.OffDomain = feed.OffDomain
+	}
+	if feed.Timeout > 0 {
+		opts.Timeout = feed.Timeout
+	}
+	if !feed.TLS.isZero() {
+		opts.TLS = feed.TLS
+	}
+	opts.maxBytes = int64(c.Bandwidth.MaxDownloadKB) << 10
+	return opts
+}
+
+// Where site owners can find out about the bot, unless fetch.contact says otherwise
+const DEFAULT_CONTACT = "https://github.com/andrewMyronov/rss"
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// botVersion is the build's version: the linker flag, else the module version, else "dev"
+func botVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// userAgent is the configured user agent or one naming the bot, its version and a contact
+func (o FetchConfig) userAgent() string {
+	if o.UserAgent != "" {
+		return o.UserAgent
+	}
+	contact := o.Contact
+	if contact == "" {
+		contact = DEFAULT_CONTACT
+	}
+	return fmt.Sprintf("rss-bot/%s (+%s)", botVersion(), contact)
+}
+
+// baseTransport is captured before installAudit replaces http.DefaultTransport
+var baseTransport = http.DefaultTransport.(*http.Transport)
+
+// fetchTransports keeps one transport per proxy and TLS setup so connections
+// are reused across feeds and runs
+var fetchTransports sync.Map
+
+// hostLimits throttles requests per host, see configureHostLimits
+var hostLimits struct {
+	sync.Mutex
+	cfg      FetchConfig
+	limiters map[string]*keyedLimiter // by host_rates domain, "" for host_rate
+	paused   map[string]time.Time     // hosts that sent a Retry-After, until when
+}
+
+// configureHostLimits applies fetch.host_rate and host_rates
+func configureHostLimits(cfg FetchConfig) {
+	hostLimits.Lock()
+	defer hostLimits.Unlock()
+	hostLimits.cfg = cfg
+	hostLimits.limiters = map[string]*keyedLimiter{}
+	hostLimits.paused = map[string]time.Time{}
+}
+
+// pauseHost holds requests to host back until the time it asked for
+func pauseHost(host string, until time.Time) {
+	hostLimits.Lock()
+	defer hostLimits.Unlock()
+	if hostLimits.paused == nil {
+		hostLimits.paused = map[string]time.Time{}
+	}
+	if until.After(hostLimits.paused[strings.ToLower(host)]) {
+		hostLimits.paused[strings.ToLower(host)] = until
+	}
+}
+
+// checkPaused waits out a short pause of host, and fails with a
+// throttledError when the host asked for a longer one
+func checkPaused(host string) error {
+	hostLimits.Lock()
+	until := hostLimits.paused[strings.ToLower(host)]
+	hostLimits.Unlock()
+	wait := time.Until(until)
+	if wait > MAX_RETRY_AFTER {
+		return &throttledError{Host: host, Until: until}
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return nil
+}
+
+// waitForHost blocks until another request to host is allowed. Hosts under a
+// host_rates domain share one bucket, so medium.com and its subdomains count together.
+func waitForHost(host string) {
+	host = strings.ToLower(host)
+	hostLimits.Lock()
+	rate, domain := hostLimits.cfg.HostRate, ""
+	for d, r := range hostLimits.cfg.HostRates {
+		if d = strings.ToLower(d); host == d || strings.HasSuffix(host, "."+d) {
+			rate, domain = r, d
+			break
+		}
+	}
+	if rate <= 0 {
+		hostLimits.Unlock()
+		return
+	}
+	limiter, ok := hostLimits.limiters[domain]
+	if !ok {
+		limiter = newKeyedLimiter(float64(max(hostLimits.cfg.HostBurst, 1)), rate)
+		hostLimits.limiters[domain] = limiter
+	}
+	hostLimits.Unlock()
+
+	if domain != "" {
+		host = domain
+	}
+	limiter.Wait(host)
+}
+
+func checkProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("bad proxy url %q", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("bad proxy url %q (want http://, https:// or socks5://)", raw)
+	}
+}
+
+// Off-domain redirect policies (FetchConfig.OffDomain)
+const (
+	REDIRECT_ALLOW     = "allow"     // the default
+	REDIRECT_DENY      = "deny"      // fail the fetch instead of leaving the feed's site
+	REDIRECT_CANONICAL = "canonical" // follow, then post the landing page's canonical URL instead of the tracker link
+)
+
+// sameSite reports whether b is on a's host or a parent or subdomain of it
+func sameSite(a, b *url.URL) bool {
+	ha := strings.TrimPrefix(strings.ToLower(a.Hostname()), "www.")
+	hb := strings.TrimPrefix(strings.ToLower(b.Hostname()), "www.")
+	return ha == hb || strings.HasSuffix(ha, "."+hb) || strings.HasSuffix(hb, "."+ha)
+}
+
+// checkRedirect applies the redirect limit and the off-domain policy
+func (o FetchConfig) checkRedirect(req *http.Request, via []*http.Request) error {
+	limit := o.MaxRedirects
+	if limit == 0 {
+		limit = 10
+	}
+	if len(via) > limit {
+		return fmt.Errorf("stopped after %d redirects", max(limit, 0))
+	}
+	if o.OffDomain == REDIRECT_DENY && !sameSite(via[0].URL, req.URL) {
+		return fmt.Errorf("redirect from %s to %s refused (off_domain: deny)", via[0].URL.Host, req.URL.Host)
+	}
+	return nil
+}
+
+// timeout is fetch.timeout or a feed's, else the stage timeout for purpose
+func (o FetchConfig) timeout(purpose string) time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	if purpose == "article" {
+		return timeouts.Article
+	}
+	return timeouts.Feed
+}
+
+// customTransport reports whether a fetch needs its own transport
+func (o FetchConfig) customTransport() bool {
+	return o.Proxy != "" || !o.TLS.isZero()
+}
+
+// transport is the shared transport for a fetch's proxy and TLS settings
+func (o FetchConfig) transport() (http.RoundTripper, error) {
+	key := fmt.Sprintf("%s|%s|%t", o.Proxy, o.TLS.CAFile, o.TLS.InsecureSkipVerify)
+	if rt, ok := fetchTransports.Load(key); ok {
+		return rt.(http.RoundTripper), nil
+	}
+	t := baseTransport.Clone()
+	if o.Proxy != "" {
+		proxyURL, err := checkProxyURL(o.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	tlsConfig, err := o.TLS.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig
+		if o.TLS.InsecureSkipVerify {
+			fmt.Printf("⚠️  TLS certificate checks are off for fetches using insecure_skip_verify\n")
+		}
+	}
+
+	var rt http.RoundTripper = t
+	if audit != nil {
+		rt = &auditTransport{base: t}
+	}
+	actual, _ := fetchTransports.LoadOrStore(key, rt)
+	return actual.(http.RoundTripper), nil
+}
+
+// get issues a GET with the configured user agent and proxy, retrying
+// transient failures. A response with an error status is returned as is once
+// the attempts run out.
+func (o FetchConfig) get(purpose, target string) (*http.Response, error) {
+	if o.customTransport() {
+		if _, err := o.transport(); err != nil {
+			return nil, fmt.Errorf("fetch failed: %w", err)
+		}
+	}
+
+	ctx := withFetchOpts(withPurpose(context.Background(), purpose), o)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	req.Header.Set("User-Agent", o.userAgent())
+	if o.Brotli {
+		// Setting the header turns off the transport's own gzip handling, see decompress
+		req.Header.Set("Accept-Encoding", "br, gzip")
+	}
+
+	host := req.URL.Hostname()
+	attempts := o.attempts()
+	for n := 1; ; n++ {
+		if err := checkPaused(host); err != nil {
+			return nil, fmt.Errorf("fetch failed: %w", err)
+		}
+		waitForHost(host)
+		var resp *http.Response
+		err := chaosError(purpose)
+		if err == nil {
+			resp, err = httpDo(req, o.timeout(purpose))
+		}
+		if err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+			if d, ok := retryAfter(resp); ok && d > 0 {
+				until := time.Now().Add(d)
+				pauseHost(host, until)
+				if d > MAX_RETRY_AFTER {
+					resp.Body.Close()
+					return nil, fmt.Errorf("fetch failed: %w", &throttledError{Host: host, Until: until})
+				}
+			}
+		}
+		retry := n < attempts && (err != nil && retryableError(err) || err == nil && retryableStatus(resp.StatusCode))
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("fetch failed: %w", err)
+			}
+			if err := decompress(resp); err != nil {
+				resp.Body.Close()
+				return nil, fmt.Errorf("fetch failed: %w", err)
+			}
+			return resp, nil
+		}
+
+		delay := o.retryDelay(n, resp)
+		if err != nil {
+			fmt.Printf("   🔁 %v, retrying in %s\n", err, delay.Round(time.Millisecond))
+		} else {
+			fmt.Printf("   🔁 %s: status %d, retrying in %s\n", target, resp.StatusCode, delay.Round(time.Millisecond))
+			resp.Body.Close()
+		}
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FILTERS_REFRESH   = 6 * time.Hour
+	FILTERS_CACHE_DIR = "filters.cache"
+)
+
+// FiltersConfig drops items by domain or keyword before anything is fetched.
+// Lists can be kept inline or loaded from URLs, so several deployments can
+// share one curated list.
+type FiltersConfig struct {
+	Block    FilterList    `yaml:"block"`     // items matching any entry are skipped
+	Allow    FilterList    `yaml:"allow"`     // when not empty, only matching items are posted
+	Refresh  time.Duration `yaml:"refresh"`   // how often remote lists are downloaded again, default 6h
+	CacheDir string        `yaml:"cache_dir"` // last good copy of each remote list, default filters.cache
+}
+
+// FilterList is a set of domains and keywords. A remote list has one entry per
+// line: a domain, or "keyword: <text>"; blank lines and # comments are ignored.
+type FilterList struct {
+	Domains  []string `yaml:"domains"`  // matches the domain and its subdomains
+	Keywords []string `yaml:"keywords"` // case-insensitive, matched in the title and description
+	URLs     []string `yaml:"urls"`     // https:// or s3:// lists
+}
+
+func (l FilterList) empty() bool {
+	return len(l.Domains) == 0 && len(l.Keywords) == 0 && len(l.URLs) == 0
+}
+
+// filterSet is a list with its remote parts resolved
+type filterSet struct {
+	domains  map[string]bool
+	keywords []string
+}
+
+func (s *filterSet) add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if kw, ok := strings.CutPrefix(entry, "keyword:"); ok {
+		if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+			s.keywords = append(s.keywords, kw)
+		}
+		return
+	}
+	if entry = strings.TrimPrefix(strings.ToLower(entry), "www."); entry != "" {
+		s.domains[entry] = true
+	}
+}
+
+// match returns the entry the item matches, if any
+func (s *filterSet) match(item Item) (string, bool) {
+	for host := hostOf(item.Link); host != ""; {
+		if s.domains[host] {
+			return host, true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			break
+		}
+		host = parent
+	}
+	text := strings.ToLower(item.Title + " " + item.Description)
+	for _, kw := range s.keywords {
+		if strings.Contains(text, kw) {
+			return "keyword " + kw, true
+		}
+	}
+	return "", false
+}
+
+// Filters holds the resolved block and allow lists and reloads remote ones when they get stale
+type Filters struct {
+	cfg FiltersConfig
+
+	mu       sync.Mutex
+	block    *filterSet
+	allow    *filterSet
+	loadedAt time.Time
+}
+
+func newFilters(cfg FiltersConfig) *Filters {
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = FILTERS_REFRESH
+	}
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = FILTERS_CACHE_DIR
+	}
+	return &Filters{cfg: cfg}
+}
+
+// Reject reports whether an item should be skipped and why
+func (f *Filters) Reject(item Item) (string, bool) {
+	if f.cfg.Block.empty() && f.cfg.Allow.empty() {
+		return "", false
+	}
+
+	f.mu.Lock()
+	if f.block == nil || time.Since(f.loadedAt) > f.cfg.Refresh {
+		f.block = f.load(f.cfg.Block)
+		f.allow = f.load(f.cfg.Allow)
+		f.loadedAt = time.Now()
+	}
+	block, allow := f.block, f.allow
+	f.mu.Unlock()
+
+	if entry, ok := block.match(item); ok {
+		return "blocklist: " + entry, true
+	}
+	if !f.cfg.Allow.empty() {
+		if _, ok := allow.match(item); !ok {
+			return "not on allowlist", true
+		}
+	}
+	return "", false
+}
+
+func (f *Filters) load(list FilterList) *filterSet {
+	s := &filterSet{domains: map[string]bool{}}
+	for _, d := range list.Domains {
+		s.add(d)
+	}
+	for _, kw := range list.Keywords {
+		s.add("keyword:" + kw)
+	}
+	for _, u := range list.URLs {
+		data, err := f.fetchList(u)
+		if err != nil {
+			fmt.Printf("⚠️  Filter list %s skipped: %v\n", u, err)
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line, _, _ = strings.Cut(line, "#"); strings.TrimSpace(line) != "" {
+				s.add(line)
+			}
+		}
+	}
+	return s
+}
+
+// fetchList downloads a remote list and refreshes its cached copy; when the
+// remote is unreachable the last cached copy is used instead
+func (f *Filters) fetchList(u string) ([]byte, error) {
+	cachePath := filepath.Join(f.cfg.CacheDir, hash(u)+".txt")
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(u, "s3://") {
+		data, _, err = s3Get(u)
+	} else {
+		data, err = httpGetConfig(u)
+	}
+
+	if err != nil {
+		cached, cacheErr := os.ReadFile(cachePath)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("%w (no cached copy)", err)
+		}
+		fmt.Printf("⚠️  Filter list %s unavailable (%v), using cached copy\n", u, err)
+		return cached, nil
+	}
+
+	err = os.MkdirAll(f.cfg.CacheDir, 0755)
+	if err == nil {
+		err = writeFileAtomic(cachePath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Could not cache filter list: %v\n", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// The model steps of the pipeline run as genkit flows, so the Genkit
+// developer UI (genkit start -- go run . serve) lists them, keeps a trace of
+// every run and can invoke each on its own input: summarize takes a finished
+// prompt (see summaryPrompt), translate a task and text, and classify an
+// article's title and text.
+
+type SummarizeInput struct {
+	Prompt     string `json:"prompt"`
+	Structured bool   `json:"structured,omitempty"` // ask for SummaryOutput's fields
+	Tone       string `json:"tone,omitempty"`       // lays the fields out as text
+}
+
+type SummarizeResult struct {
+	Text   string         `json:"text"`
+	Fields *SummaryOutput `json:"fields,omitempty"` // structured summaries only
+	Tags   []string       `json:"tags,omitempty"`
+	FlowUsage
+}
+
+type TranslateInput struct {
+	Task string `json:"task"` // title, summary or ..., see translate.go
+	Text string `json:"text"`
+}
+
+type ClassifyInput struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+type ClassifyResult struct {
+	Topic string `json:"topic"` // as the model named it, empty for none
+	FlowUsage
+}
+
+// FlowUsage is what a flow's model calls cost, for the item's token counts
+type FlowUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u *FlowUsage) add(resp *ai.ModelResponse) {
+	if resp != nil && resp.Usage != nil {
+		u.InputTokens += resp.Usage.InputTokens
+		u.OutputTokens += resp.Usage.OutputTokens
+	}
+}
+
+type botFlows struct {
+	summarize *core.Flow[SummarizeInput, SummarizeResult, struct{}]
+	translate *core.Flow[TranslateInput, string, struct{}]
+	classify  *core.Flow[ClassifyInput, ClassifyResult, struct{}]
+}
+
+// defineFlows registers the bot's flows with its genkit instance
+func (b *Bot) defineFlows() {
+	b.flows.summarize = genkit.DefineFlow(b.g, "summarize", b.summarizeFlow)
+	b.flows.translate = genkit.DefineFlow(b.g, "translate", b.translateFlow)
+	b.flows.classify = genkit.DefineFlow(b.g, "classify", b.classifyFlow)
+}
+
+// summarizeFlow asks the model for a summary, each call bounded by timeouts.ai
+func (b *Bot) summarizeFlow(ctx context.Context, in SummarizeInput) (SummarizeResult, error) {
+	var res SummarizeResult
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	opts := []ai.GenerateOption{
+		ai.WithPrompt(in.Prompt),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	}
+	if !in.Structured {
+		resp, err := genkit.Generate(ctx, b.g, opts...)
+		if err != nil {
+			return res, err
+		}
+		res.add(resp)
+		res.Text = resp.Text()
+		return res, nil
+	}
+	out, resp, err := genkit.GenerateData[SummaryOutput](ctx, b.g, opts...)
+	if err != nil {
+		return res, err
+	}
+	res.add(resp)
+	res.Text, res.Fields, res.Tags = out.text(cmp.Or(in.Tone, DEFAULT_TONE)), out, out.tags()
+	return res, nil
+}
+
+// translateFlow translates text with the translator of its task
+func (b *Bot) translateFlow(ctx context.Context, in TranslateInput) (string, error) {
+	t, ok := b.translators[in.Task]
+	if !ok {
+		return "", fmt.Errorf("no translator for %q (see translation.tasks)", in.Task)
+	}
+	return t.Translate(ctx, in.Text, b.cfg.Translation.TargetLanguage)
+}
+
+// classifyFlow asks the model which of topics.list an article is about
+func (b *Bot) classifyFlow(ctx context.Context, in ClassifyInput) (ClassifyResult, error) {
+	var res ClassifyResult
+	cfg := b.cfg.Topics
+	if len(cfg.List) == 0 {
+		return res, fmt.Errorf("no topics configured (see topics.list)")
+	}
+	var list strings.Builder
+	for _, t := range cfg.List {
+		fmt.Fprintf(&list, "- %s", t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(&list, ": %s", t.Description)
+		}
+		list.WriteString("\n")
+	}
+	aiCfg := b.cfg.AI
+	aiCfg.Model = cmp.Or(cfg.Model, aiCfg.Model)
+
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	out, resp, err := genkit.GenerateData[TopicOutput](ctx, b.g,
+		ai.WithPrompt(fmt.Sprintf(TOPIC_PROMPT, strings.TrimSpace(list.String()), in.Title, truncate(in.Content, 3000, "..."))),
+		ai.WithModelName(aiCfg.Model),
+		withFallback(b.g, aiCfg),
+	)
+	res.add(resp)
+	if err != nil {
+		return res, err
+	}
+	res.Topic = strings.TrimPrefix(strings.TrimSpace(out.Topic), "#")
+	return res, nil
+}
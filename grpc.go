@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"rss/rsspb"
+)
+
+const DEFAULT_LIST_LIMIT = 50
+
+type GRPCConfig struct {
+	Listen string `yaml:"listen"` // e.g. ":9090"; empty disables the gRPC server
+	Token  string `yaml:"token"`  // optional bearer token required from clients
+}
+
+type grpcServer struct {
+	rsspb.UnimplementedRSSServiceServer
+	bot *Bot
+}
+
+func toProtoItem(item *ArchivedItem) *rsspb.Item {
+	return &rsspb.Item{
+		Id:      item.ID,
+		Feed:    item.Feed,
+		Title:   item.Title,
+		Link:    item.Link,
+		Summary: item.Summary,
+		SentAt:  timestamppb.New(item.SentAt),
+	}
+}
+
+func (s *grpcServer) ListItems(ctx context.Context, req *rsspb.ListItemsRequest) (*rsspb.ListItemsResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = DEFAULT_LIST_LIMIT
+	}
+
+	items, err := s.bot.archive.Recent(limit, req.GetFeed())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &rsspb.ListItemsResponse{}
+	for i := range items {
+		resp.Items = append(resp.Items, toProtoItem(&items[i]))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetItem(ctx context.Context, req *rsspb.GetItemRequest) (*rsspb.Item, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	item, err := s.bot.archive.Get(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if item == nil {
+		return nil, status.Errorf(codes.NotFound, "item %s not found", req.GetId())
+	}
+	return toProtoItem(item), nil
+}
+
+func (s *grpcServer) TriggerRun(ctx context.Context, req *rsspb.TriggerRunRequest) (*rsspb.TriggerRunResponse, error) {
+	if !req.GetWait() {
+		// The run must outlive this call, so it gets its own context
+		if err := s.bot.Start(context.Background()); err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return &rsspb.TriggerRunResponse{Started: true}, nil
+	}
+
+	metrics, err := s.bot.Run(ctx)
+	if errors.Is(err, errRunInProgress) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &rsspb.TriggerRunResponse{
+		Started:    true,
+		PostsSent:  int32(metrics.PostsSent),
+		FeedErrors: int32(metrics.FeedErrors),
+	}, nil
+}
+
+func (s *grpcServer) StreamItems(req *rsspb.StreamItemsRequest, stream grpc.ServerStreamingServer[rsspb.Item]) error {
+	// Subscribe before replaying so nothing posted in between is missed
+	items, cancel := s.bot.archive.Subscribe()
+	defer cancel()
+
+	if n := int(req.GetReplay()); n > 0 {
+		recent, err := s.bot.archive.Recent(n, "")
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		for i := len(recent) - 1; i >= 0; i-- {
+			if err := stream.Send(toProtoItem(&recent[i])); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case item := <-items:
+			if err := stream.Send(toProtoItem(&item)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// grpcAuth rejects calls without the configured bearer token
+func grpcAuth(token string) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	check := func(ctx context.Context) error {
+		if token == "" {
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(v, "Bearer ")), []byte(token)) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return unary, stream
+}
+
+// serveGRPC runs the gRPC API until ctx is cancelled
+func serveGRPC(ctx context.Context, bot *Bot, cfg GRPCConfig) error {
+	lis, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("grpc listen: %w", err)
+	}
+
+	unary, stream := grpcAuth(cfg.Token)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream))
+	rsspb.RegisterRSSServiceServer(srv, &grpcServer{bot: bot})
+
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	fmt.Printf("🛰️  gRPC API listening on %s\n", cfg.Listen)
+	return srv.Serve(lis)
+}
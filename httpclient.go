@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpClient sends every outbound request we make ourselves: feeds, articles,
+// Telegram, translation, secrets and storage. Sharing it keeps one pool of
+// keep-alive connections per host across feeds and runs. Timeouts are per
+// call (httpDo), and redirects and proxies follow the FetchConfig a fetch
+// puts in the request context.
+var httpClient = &http.Client{
+	Transport:     routedTransport{},
+	CheckRedirect: checkFetchRedirect,
+}
+
+// Default timeout for a feed request, body included
+const FETCH_TIMEOUT = 15 * time.Second
+
+type fetchOptsKey struct{}
+
+// withFetchOpts makes the shared client apply opts' redirect policy and proxy
+func withFetchOpts(ctx context.Context, opts FetchConfig) context.Context {
+	return context.WithValue(ctx, fetchOptsKey{}, opts)
+}
+
+func fetchOptsFrom(ctx context.Context) (FetchConfig, bool) {
+	opts, ok := ctx.Value(fetchOptsKey{}).(FetchConfig)
+	return opts, ok
+}
+
+// httpDo sends req on the shared client. The timeout covers reading the body
+// as well, and is released when the body is closed.
+func httpDo(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelBody{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelBody cancels the request's context once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// routedTransport sends fetches with a proxy or TLS settings through their own
+// transport, and everything else through http.DefaultTransport. The default is looked
+// up per request because installAudit replaces it after startup.
+type routedTransport struct{}
+
+func (routedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	opts, ok := fetchOptsFrom(req.Context())
+	if !ok {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	req, err := guardRequest(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.customTransport() {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	rt, err := opts.transport()
+	if err != nil {
+		return nil, err
+	}
+	return rt.RoundTrip(req)
+}
+
+// checkFetchRedirect applies the fetch's redirect settings, or the usual limit
+// of 10 for requests that aren't fetches
+func checkFetchRedirect(req *http.Request, via []*http.Request) error {
+	opts, _ := fetchOptsFrom(req.Context())
+	return opts.checkRedirect(req, via)
+}
+
+// configureTransport sizes the connection pool every transport is cloned from
+func configureTransport(cfg NetworkConfig) {
+	perHost := cfg.MaxIdlePerHost
+	if perHost <= 0 {
+		perHost = 8
+	}
+	baseTransport.MaxIdleConnsPerHost = perHost
+	baseTransport.MaxIdleConns = max(100, perHost*4)
+	if cfg.IdleTimeout > 0 {
+		baseTransport.IdleConnTimeout = cfg.IdleTimeout
+	}
+	if cfg.TLSTimeout > 0 {
+		baseTransport.TLSHandshakeTimeout = cfg.TLSTimeout
+	}
+}
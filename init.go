@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/plugins/googlegenai"
+)
+
+// Feed groups offered by `rss init`, a curated subset of RSS_FEEDS
+var INIT_FEED_GROUPS = []struct {
+	Name     string
+	Category string
+	Feeds    []string
+}{
+	{"General tech news", "", []string{
+		"https://news.ycombinator.com/rss",
+		"https://arstechnica.com/feed/",
+		"https://www.theverge.com/rss/index.xml",
+	}},
+	{"AI & research", "research", []string{
+		"https://openai.com/blog/rss/",
+		"https://blog.research.google/feeds/posts/default",
+	}},
+	{"Security", "security", []string{
+		"https://krebsonsecurity.com/feed/",
+		"https://www.schneier.com/feed/atom/",
+	}},
+	{"Go & backend", "", []string{
+		"https://go.dev/blog/feed.atom",
+		"https://dave.cheney.net/feed",
+	}},
+	{"Cloud & infrastructure", "", []string{
+		"https://kubernetes.io/feed.xml",
+		"https://blog.cloudflare.com/rss/",
+		"https://aws.amazon.com/blogs/aws/feed/",
+	}},
+}
+
+const INIT_DEFAULT_MODEL = "googleai/gemini-2.5-flash"
+
+// wizard reads answers from the terminal
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints a question and returns the answer, or def when it is left empty
+func (w *wizard) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" && err != nil {
+		// stdin closed; nothing more is coming
+		fmt.Fprintln(w.out)
+		os.Exit(1)
+	}
+	if line == "" {
+		return def
+	}
+	return line
+}
+
+func (w *wizard) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	switch strings.ToLower(w.ask(question+" ("+hint+")", "")) {
+	case "":
+		return def
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// runInit implements `rss init`: it asks for credentials, checks them against
+// Telegram and the AI provider, writes .env and a starter config, and marks
+// what the feeds already contain as seen so the first run doesn't flood the
+// channel
+func runInit(path, envFile string, args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite an existing config file")
+	noSeed := fs.Bool("no-seed", false, "don't mark current feed items as seen")
+	fs.Parse(args)
+
+	if isRemoteConfig(path) {
+		fmt.Printf("⚠️  %s is a remote config; init writes a local file\n", path)
+		return 1
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		fmt.Printf("⚠️  %s already exists; use -force to overwrite it\n", path)
+		return 1
+	}
+
+	ctx := context.Background()
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	fmt.Println("👋 Let's set up the bot. Press Enter to keep the value in brackets.")
+
+	fmt.Println("\n1. Telegram: create a bot with @BotFather and add it as an admin of your channel.")
+	var token string
+	for {
+		token = w.ask("Bot token", os.Getenv("TG_BOT_TOKEN"))
+		var me TelegramUser
+		if err := telegramCall(ctx, token, "getMe", map[string]any{}, &me); err != nil {
+			fmt.Printf("⚠️  Telegram rejected the token: %v\n", err)
+			continue
+		}
+		fmt.Printf("✅ Connected as @%s\n", me.Username)
+		break
+	}
+	var chatID string
+	for {
+		chatID = w.ask("Channel (@username or numeric chat id)", os.Getenv("TG_CHANNEL_ID"))
+		var chat TelegramChat
+		if err := telegramCall(ctx, token, "getChat", map[string]any{"chat_id": chatID}, &chat); err != nil {
+			fmt.Printf("⚠️  The bot can't see %s (is it a member?): %v\n", chatID, err)
+			continue
+		}
+		fmt.Printf("✅ Found %s %d\n", chat.Type, chat.ID)
+		break
+	}
+
+	fmt.Println("\n2. AI provider: Google Gemini; get a key at https://aistudio.google.com/apikey")
+	var apiKey, model string
+	for {
+		apiKey = w.ask("API key", os.Getenv("GEMINI_API_TOKEN"))
+		model = w.ask("Model", cmp.Or(os.Getenv("GEMINI_MODEL"), INIT_DEFAULT_MODEL))
+		if err := checkAI(ctx, apiKey, model); err != nil {
+			fmt.Printf("⚠️  AI check failed: %v\n", err)
+			continue
+		}
+		fmt.Printf("✅ %s answered\n", model)
+		break
+	}
+
+	fmt.Println("\n3. Feeds: pick the groups to start with (you can add more with `rss feed add`).")
+	for i, group := range INIT_FEED_GROUPS {
+		fmt.Printf("   %d) %s (%d feeds)\n", i+1, group.Name, len(group.Feeds))
+	}
+	var picked []int
+	for {
+		picked = picked[:0]
+		answer := w.ask("Groups, comma-separated", "1,3,4")
+		ok := true
+		for _, part := range strings.Split(answer, ",") {
+			var n int
+			if _, err := fmt.Sscan(strings.TrimSpace(part), &n); err != nil || n < 1 || n > len(INIT_FEED_GROUPS) {
+				fmt.Printf("⚠️  %q is not a group number\n", strings.TrimSpace(part))
+				ok = false
+				break
+			}
+			picked = append(picked, n-1)
+		}
+		if ok {
+			break
+		}
+	}
+	tone := w.ask("Summary tone (editor, neutral or eli5)", TONE_EDITOR)
+	for checkTone(tone) != nil {
+		tone = w.ask("Summary tone (editor, neutral or eli5)", TONE_EDITOR)
+	}
+
+	secrets := map[string]string{
+		"TG_BOT_TOKEN":     token,
+		"TG_CHANNEL_ID":    chatID,
+		"GEMINI_API_TOKEN": apiKey,
+		"GEMINI_MODEL":     model,
+	}
+	if err := writeEnvFile(envFile, secrets); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	fmt.Printf("\n🔑 Credentials saved to %s (keep it out of version control)\n", envFile)
+	for k, v := range secrets {
+		os.Setenv(k, v)
+	}
+
+	if err := os.WriteFile(path, []byte(starterConfig(tone, picked)), 0o644); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	fmt.Printf("📝 Config written to %s\n", path)
+
+	if *noSeed || !w.confirm("Mark what the feeds have now as seen, so the first run only posts new items?", true) {
+		fmt.Println("\n🚀 Done. Start the bot with `rss run` or `rss serve`.")
+		return 0
+	}
+	cfg, err := loadConfig(path, true, "")
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	if code := runMarkSeen(cfg, nil); code != 0 {
+		return code
+	}
+	fmt.Println("\n🚀 Done. Start the bot with `rss run` or `rss serve`.")
+	return 0
+}
+
+// checkAI asks the model for a one-word reply to prove the key and model work
+func checkAI(ctx context.Context, apiKey, model string) error {
+	if apiKey == "" {
+		return fmt.Errorf("no API key")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	g := genkit.Init(ctx, genkit.WithPlugins(&googlegenai.GoogleAI{APIKey: apiKey}))
+	resp, err := genkit.Generate(ctx, g,
+		ai.WithPrompt("Reply with the single word OK."),
+		ai.WithModelName(model),
+	)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(resp.Text()) == "" {
+		return fmt.Errorf("empty reply from %s", model)
+	}
+	return nil
+}
+
+// starterConfig is the config `rss init` writes; secrets stay in .env
+func starterConfig(tone string, groups []int) string {
+	var b strings.Builder
+	b.WriteString(`# Written by rss init. See config.example.yaml for every option, and check
+# changes with ` + "`rss config validate`" + `.
+
+telegram:
+  token: ${TG_BOT_TOKEN}
+  channel_id: ${TG_CHANNEL_ID}
+
+ai:
+  api_key: ${GEMINI_API_TOKEN}
+  model: ${GEMINI_MODEL:-` + INIT_DEFAULT_MODEL + `}
+
+# Summary tone: editor, neutral or eli5
+tone: ` + tone + `
+
+# Cron expression for runs under ` + "`rss serve`" + `
+schedule: "*/30 * * * *"
+
+max_posts_per_run: 10
+
+feeds:
+`)
+	seen := map[int]bool{}
+	for _, i := range groups {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		group := INIT_FEED_GROUPS[i]
+		fmt.Fprintf(&b, "  # %s\n", group.Name)
+		for _, url := range group.Feeds {
+			if group.Category == "" {
+				fmt.Fprintf(&b, "  - %s\n", url)
+			} else {
+				fmt.Fprintf(&b, "  - url: %s\n    category: %s\n", url, group.Category)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeEnvFile sets keys in a dotenv file, keeping its other lines
+func writeEnvFile(path string, values map[string]string) error {
+	var kept []string
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			key, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+			if _, ok := values[strings.TrimSpace(key)]; !ok {
+				kept = append(kept, line)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("env file: %w", err)
+	}
+	for _, key := range []string{"TG_BOT_TOKEN", "TG_CHANNEL_ID", "GEMINI_API_TOKEN", "GEMINI_MODEL"} {
+		if v, ok := values[key]; ok {
+			kept = append(kept, fmt.Sprintf("%s=%q", key, v))
+		}
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("env file: %w", err)
+	}
+	return nil
+}
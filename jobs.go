@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// What to do with job posts found in a feed (FeedConfig.Jobs)
+const (
+	JOBS_KEEP    = ""        // treat them like any other item
+	JOBS_DROP    = "drop"    // skip them
+	JOBS_EXTRACT = "extract" // post structured fields to the jobs topic instead
+)
+
+// JobsConfig is where extracted job posts go
+type JobsConfig struct {
+	Channel  string `yaml:"channel"`   // key into channels; default telegram.channel_id
+	ThreadID int64  `yaml:"thread_id"` // forum topic in that chat, 0 for none
+}
+
+const JOB_PROMPT = `Decide whether this page is a job posting (an offer to hire someone), not an article about jobs or hiring.
+If it is, extract the fields; leave unknown fields empty. Stack lists languages, frameworks and tools named as requirements.
+
+Title: %s
+
+Content:
+%s`
+
+type JobPosting struct {
+	IsJob    bool     `json:"is_job"`
+	Role     string   `json:"role"`
+	Company  string   `json:"company"`
+	Location string   `json:"location"`
+	Remote   bool     `json:"remote"`
+	Stack    []string `json:"stack"`
+	Salary   string   `json:"salary"`
+}
+
+// jobHint is a cheap first pass so the model is only asked about likely job posts
+var jobHint = regexp.MustCompile(`(?i)\b(hiring|we'?re hiring|job opening|vacanc(y|ies)|careers?|position|remote job|(senior|junior|staff|lead|principal)\s+\w+\s+(engineer|developer))\b|\[hiring\]|/jobs?/|/careers?/|/vacanc`)
+
+func looksLikeJob(item Item) bool {
+	return jobHint.MatchString(item.Title) || jobHint.MatchString(item.Link)
+}
+
+// classifyJob asks the model whether the item is a job post and for its fields
+func (b *Bot) classifyJob(ctx context.Context, item Item, content string) (*JobPosting, *ai.ModelResponse, error) {
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	return genkit.GenerateData[JobPosting](ctx, b.g,
+		ai.WithPrompt(fmt.Sprintf(JOB_PROMPT, item.Title, truncate(content, 3000, "..."))),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+}
+
+func formatJob(job *JobPosting, item Item) string {
+	var sb strings.Builder
+	role := job.Role
+	if role == "" {
+		role = item.Title
+	}
+	fmt.Fprintf(&sb, "💼 <b><a href=\"%s\">%s</a></b>", html.EscapeString(item.Link), html.EscapeString(role))
+	if job.Company != "" {
+		fmt.Fprintf(&sb, " at <b>%s</b>", html.EscapeString(job.Company))
+	}
+
+	location := job.Location
+	if job.Remote {
+		location = strings.TrimSpace(location + " (remote)")
+	}
+	for _, field := range []struct{ label, value string }{
+		{"📍", location},
+		{"🧰", strings.Join(job.Stack, ", ")},
+		{"💰", job.Salary},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&sb, "\n%s %s", field.label, html.EscapeString(field.value))
+		}
+	}
+	return sb.String()
+}
+
+// sendJob posts an extracted job to the jobs chat and topic
+func (b *Bot) sendJob(ctx context.Context, text string) (int64, error) {
+	params := map[string]any{
+		"chat_id":                  b.cfg.chatFor(FeedConfig{Channel: b.cfg.Jobs.Channel}),
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if b.cfg.Jobs.ThreadID != 0 {
+		params["message_thread_id"] = b.cfg.Jobs.ThreadID
+	}
+
+	var sent TelegramMessage
+	if err := telegramCall(ctx, b.cfg.Telegram.Token, "sendMessage", params, &sent); err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// handleJob deals with a likely job post according to the feed's jobs setting.
+// It returns false when the item turns out not to be a job and should be posted normally.
+func (b *Bot) handleJob(ctx context.Context, feed FeedConfig, id string, item Item, content string, state StateStore, decision *Decision, metrics *RunMetrics) bool {
+	job, resp, err := b.classifyJob(ctx, item, content)
+	if resp != nil && resp.Usage != nil {
+		decision.InputTokens += resp.Usage.InputTokens
+		decision.OutputTokens += resp.Usage.OutputTokens
+		metrics.addTokens(feed.URL, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Job check failed, treating as an article: %v\n", err)
+		return false
+	}
+	if !job.IsJob {
+		return false
+	}
+	decision.Filters = append(decision.Filters, "job")
+
+	if feed.Jobs == JOBS_DROP {
+		if err := state.Mark(id, StateEntry{Title: item.Title, Link: item.Link, Feed: feed.URL, Skipped: "job posting"}); err != nil {
+			fmt.Printf("   ⚠️  Could not record item as seen: %v\n", err)
+		}
+		decision.Action = ACTION_SKIPPED
+		decision.Reason = "job posting"
+		fmt.Printf("   🚫 Dropped job post: %s\n", item.Title)
+		return true
+	}
+
+	messageID, err := b.sendJob(withAuditItem(ctx, id, item.Link), formatJob(job, item))
+	if err != nil {
+		releaseItem(state, id)
+		metrics.SendFailures++
+		metrics.PerFeedFailure[feed.URL]++
+		decision.Action = ACTION_SEND_FAILED
+		decision.Reason = err.Error()
+		fmt.Printf("   ⚠️  Job post failed: %v\n", err)
+		return true
+	}
+
+	jobsChat := b.cfg.chatFor(FeedConfig{Channel: b.cfg.Jobs.Channel})
+	entry := StateEntry{Title: item.Title, Link: item.Link, Feed: feed.URL, ChatID: jobsChat, MessageID: messageID}
+	if err := state.Mark(id, entry); err != nil {
+		fmt.Printf("   ⚠️  Could not record item as sent: %v\n", err)
+	}
+	metrics.PostsSent++
+	metrics.PerFeedSent[feed.URL]++
+	decision.Action = ACTION_SENT
+	decision.Reason = "job posting, sent to the jobs topic"
+	fmt.Printf("   💼 Sent job: %s\n", item.Title)
+	pacing.pause(b.cfg.postDelayFor(feed))
+	return true
+}
@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// Summaries come out in English, the language of the prompts, unless a
+// language is set, globally or per channel: then the model writes them in it
+// whatever the article's language, which reads better than translating an
+// English summary after the fact (translation.tasks.summary, see
+// translate.go). Titles are still posted as-is unless translation.tasks.title
+// is set, and structured summaries keep their English section labels.
+
+const LANGUAGE_INSTRUCTION = `
+
+Write the whole answer in %s, section headers included, whatever the language of the article. Keep names, code and "AI FAILED" as they are.`
+
+// languageFor picks the language of the channel a feed posts to, falling
+// back to the global language; empty is the prompt's own
+func (c *Config) languageFor(feed FeedConfig) string {
+	if ch, ok := c.Channels[feed.Channel]; ok && feed.Channel != "" && ch.Language != "" {
+		return ch.Language
+	}
+	return c.Language
+}
+
+// withLanguage asks for the answer to a prompt in language
+func withLanguage(prompt, language string) string {
+	if language == "" {
+		return prompt
+	}
+	return prompt + fmt.Sprintf(LANGUAGE_INSTRUCTION, language)
+}
@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// Length presets trim or stretch the summary the tone asks for, set per feed,
+// per channel or globally: short for phone-first channels that want a few
+// sentences, long for readers who want the full analysis. Medium is the
+// prompts as written.
+const (
+	LENGTH_SHORT  = "short"
+	LENGTH_MEDIUM = "medium"
+	LENGTH_LONG   = "long"
+)
+
+const SHORT_INSTRUCTION = `
+
+Keep it short: answer with at most 3 plain sentences covering what happened and why it matters, instead of the sections above. No headers and no lists, but keep any rating line and hashtags asked for above. If you can't summarize, still output: AI FAILED`
+
+const LONG_INSTRUCTION = `
+
+Go into depth: make the summary a full paragraph, give 5-7 key points with specifics (numbers, names, versions) from the article, and make the analysis a thorough take on context, implications and open questions, keeping the sections above.`
+
+const SHORT_FIELDS_INSTRUCTION = `
+
+Keep it short: make summary at most 3 sentences covering what happened and why it matters, and leave key_points and thoughts empty.`
+
+const LONG_FIELDS_INSTRUCTION = `
+
+Go into depth: make summary a full paragraph, give 5-7 key_points with specifics (numbers, names, versions) from the article, and make thoughts a thorough take on context, implications and open questions.`
+
+func checkLength(length string) error {
+	switch length {
+	case "", LENGTH_SHORT, LENGTH_MEDIUM, LENGTH_LONG:
+		return nil
+	}
+	return fmt.Errorf("unknown length %q (expected short, medium or long)", length)
+}
+
+// lengthFor picks the feed's length, then its channel's, then the global one
+func (c *Config) lengthFor(feed FeedConfig) string {
+	if feed.Length != "" {
+		return feed.Length
+	}
+	if ch, ok := c.Channels[feed.Channel]; ok && feed.Channel != "" && ch.Length != "" {
+		return ch.Length
+	}
+	return c.Length
+}
+
+// withLength adjusts a prompt to the length preset; medium leaves it alone
+func withLength(prompt, length string, structured bool) string {
+	switch {
+	case length == LENGTH_SHORT && structured:
+		return prompt + SHORT_FIELDS_INSTRUCTION
+	case length == LENGTH_SHORT:
+		return prompt + SHORT_INSTRUCTION
+	case length == LENGTH_LONG && structured:
+		return prompt + LONG_FIELDS_INSTRUCTION
+	case length == LENGTH_LONG:
+		return prompt + LONG_INSTRUCTION
+	}
+	return prompt
+}
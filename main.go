@@ -7,19 +7,17 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
+	"flag"
 	"fmt"
 	"io"
-	"math/rand"
-	"net/http"
+	"net/url"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/firebase/genkit/go/ai"
-	"github.com/firebase/genkit/go/genkit"
-	"github.com/firebase/genkit/go/plugins/googlegenai"
 )
 
 // Top 10 RSS feeds for software engineers
@@ -132,6 +130,7 @@ Content:
 %s`
 const STATE_FILE = "state.json"
 const MAX_POSTS_PER_RUN = 200
+const POST_DELAY = 2 * time.Second
 
 type RSS struct {
 	Channel struct {
@@ -142,46 +141,191 @@ type RSS struct {
 type Item struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
-	Description string `xml:"description"` // Some RSS feeds include short description
+	Description string `xml:"description"`                                      // Some RSS feeds include short description
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"` // full text, when the feed carries it
+	PubDate     string `xml:"pubDate"`
 }
 
-// convertToTelegramHTML converts simple markdown to Telegram-compatible HTML
-func convertToTelegramHTML(text string) string {
-	// Convert **bold** to <b>bold</b>
-	re := regexp.MustCompile(`\*\*([^*]+)\*\*`)
-	text = re.ReplaceAllString(text, "<b>$1</b>")
+// Date formats seen in feeds besides RFC 1123 and RFC 3339
+var PUB_DATE_LAYOUTS = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", time.RFC822Z, time.RFC822,
+}
 
-	// Convert *italic* to <i>italic</i>
-	re = regexp.MustCompile(`\*([^*]+)\*`)
-	text = re.ReplaceAllString(text, "<i>$1</i>")
+// published is the item's pubDate, zero when it is missing or unreadable
+func (i Item) published() time.Time {
+	s := strings.TrimSpace(i.PubDate)
+	for _, layout := range PUB_DATE_LAYOUTS {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
 
-	// Escape special HTML characters
-	text = strings.ReplaceAll(text, "&", "&amp;")
-	text = strings.ReplaceAll(text, "<", "&lt;")
-	text = strings.ReplaceAll(text, ">", "&gt;")
+// Atom is the other common feed format (GitHub releases and commits, many blogs)
+type Atom struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
 
-	// Restore our converted tags
-	text = strings.ReplaceAll(text, "&lt;b&gt;", "<b>")
-	text = strings.ReplaceAll(text, "&lt;/b&gt;", "</b>")
-	text = strings.ReplaceAll(text, "&lt;i&gt;", "<i>")
-	text = strings.ReplaceAll(text, "&lt;/i&gt;", "</i>")
+// items maps Atom entries onto RSS items
+func (a *Atom) items() []Item {
+	items := make([]Item, 0, len(a.Entries))
+	for _, e := range a.Entries {
+		item := Item{Title: strings.TrimSpace(e.Title), Description: e.Content, Content: e.Content, PubDate: e.Published}
+		if item.Description == "" {
+			item.Description = e.Summary
+		}
+		if item.PubDate == "" {
+			item.PubDate = e.Updated
+		}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = l.Href
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// stateFile is the content of state.json: sent items by id and the fetch status of each feed
+type stateFile struct {
+	Items     map[string]StateEntry    `json:"items"`
+	Feeds     map[string]FeedStatus    `json:"feeds,omitempty"`
+	Summaries map[string]CachedSummary `json:"summaries,omitempty"` // see summarycache.go
 
-	return text
+	mu sync.Mutex // guards Summaries, which the summarize stage uses while delivery saves
 }
 
-func loadState() map[string]bool {
-	state := map[string]bool{}
-	data, err := os.ReadFile(STATE_FILE)
-	if err != nil {
+func newStateFile() *stateFile {
+	return &stateFile{Items: map[string]StateEntry{}, Feeds: map[string]FeedStatus{}, Summaries: map[string]CachedSummary{}}
+}
+
+// loadState reads the dedup state, falling back to the backup copy when the
+// file is missing or damaged so a bad write never causes a full repost
+func loadState(path string) *stateFile {
+	for _, p := range []string{path, path + ".bak"} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		state, err := parseState(data)
+		if err != nil {
+			fmt.Printf("⚠️  %s is damaged (%v)\n", p, err)
+			continue
+		}
+		if p != path {
+			fmt.Printf("⚠️  Restored state from %s\n", p)
+		}
 		return state
 	}
-	_ = json.Unmarshal(data, &state)
-	return state
+	return newStateFile()
+}
+
+// parseState decodes state.json. Older versions stored the items map at the
+// top level, and before that "id": true without a time; those entries count
+// as sent now, so retention starts with the upgrade.
+func parseState(data []byte) (*stateFile, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	state := newStateFile()
+	if items, ok := raw["items"]; ok {
+		// Item ids are hex hashes, so "items" only appears in the current layout
+		if feeds, ok := raw["feeds"]; ok {
+			if err := json.Unmarshal(feeds, &state.Feeds); err != nil {
+				return nil, fmt.Errorf("feeds: %w", err)
+			}
+		}
+		if summaries, ok := raw["summaries"]; ok {
+			if err := json.Unmarshal(summaries, &state.Summaries); err != nil {
+				return nil, fmt.Errorf("summaries: %w", err)
+			}
+		}
+		raw = nil
+		if err := json.Unmarshal(items, &raw); err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	for id, v := range raw {
+		var entry StateEntry
+		if string(v) != "true" {
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil, fmt.Errorf("entry %s: %w", id, err)
+			}
+		}
+		if entry.SentAt.IsZero() {
+			entry.SentAt = now
+		}
+		state.Items[id] = entry
+	}
+	return state, nil
 }
 
-func saveState(state map[string]bool) {
+// saveState replaces the state file atomically, keeping the previous version as a backup
+func saveState(path string, state *stateFile) error {
+	state.mu.Lock()
 	data, _ := json.MarshalIndent(state, "", "  ")
-	_ = os.WriteFile(STATE_FILE, data, 0644)
+	state.mu.Unlock()
+
+	if prev, err := os.ReadFile(path); err == nil && json.Valid(prev) {
+		if err := writeFileAtomic(path+".bak", prev, 0644); err != nil {
+			return fmt.Errorf("backup state: %w", err)
+		}
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// writeFileAtomic writes to a temp file, syncs it and renames it over path,
+// so readers see either the old or the new content, never a partial write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
 }
 
 func hash(s string) string {
@@ -189,38 +333,64 @@ func hash(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
-func sendToTelegram(token, chatID, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
+// sendToTelegram posts text and returns the new message's id
+func sendToTelegram(ctx context.Context, token, chatID, text string) (int64, error) {
+	return sendToTopic(ctx, token, chatID, 0, text)
+}
 
+// sendToTopic posts text to a forum topic of the chat, or to the chat itself
+// when threadID is 0
+func sendToTopic(ctx context.Context, token, chatID string, threadID int64, text string) (int64, error) {
 	body := map[string]any{
 		"chat_id":                  chatID,
 		"text":                     text,
 		"parse_mode":               "HTML",
 		"disable_web_page_preview": true,
 	}
+	if threadID != 0 {
+		body["message_thread_id"] = threadID
+	}
 
-	b, _ := json.Marshal(body)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return err
+	var sent TelegramMessage
+	if err := telegramCall(ctx, token, "sendMessage", body, &sent); err != nil {
+		return 0, err
 	}
-	defer resp.Body.Close()
+	return sent.MessageID, nil
+}
 
-	if resp.StatusCode >= 300 {
-		rb, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%s", string(rb))
+// sendPhotoToTopic posts a photo by URL with an HTML caption, which Telegram
+// limits to TELEGRAM_CAPTION_LIMIT
+func sendPhotoToTopic(ctx context.Context, token, chatID string, threadID int64, photo, caption string) (int64, error) {
+	body := map[string]any{
+		"chat_id":    chatID,
+		"photo":      photo,
+		"caption":    caption,
+		"parse_mode": "HTML",
 	}
-	return nil
+	if threadID != 0 {
+		body["message_thread_id"] = threadID
+	}
+
+	var sent TelegramMessage
+	if err := telegramCall(ctx, token, "sendPhoto", body, &sent); err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
 }
 
-func fetchRSS(url string) (*RSS, error) {
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+func fetchRSS(url string, opts FetchConfig) (*RSS, error) {
+	body, err := fetchFeed(url, opts)
+	if err != nil {
+		return nil, err
 	}
+	return parseFeed(body)
+}
 
-	resp, err := client.Get(url)
+// fetchFeed downloads a feed without parsing it
+func fetchFeed(url string, opts FetchConfig) ([]byte, error) {
+	resp, err := opts.get("feed", url)
 	if err != nil {
-		return nil, fmt.Errorf("fetch failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -232,6 +402,17 @@ func fetchRSS(url string) (*RSS, error) {
 	if err != nil {
 		return nil, fmt.Errorf("read failed: %w", err)
 	}
+	return body, nil
+}
+
+// parseFeed reads an Atom or RSS document
+func parseFeed(body []byte) (*RSS, error) {
+	var atom Atom
+	if xml.Unmarshal(body, &atom) == nil {
+		var rss RSS
+		rss.Channel.Items = atom.items()
+		return &rss, nil
+	}
 
 	var rss RSS
 	if err := xml.Unmarshal(body, &rss); err != nil {
@@ -241,32 +422,92 @@ func fetchRSS(url string) (*RSS, error) {
 	return &rss, nil
 }
 
-// fetchArticleContent extracts text content from a URL
-func fetchArticleContent(url string) (string, error) {
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+// fetchArticlePage downloads an article's HTML, or takes it from the article
+// cache, along with the URL it was served from after redirects
+func fetchArticlePage(rawURL string, opts FetchConfig) ([]byte, *url.URL, error) {
+	if page, final, ok := articles.Get(rawURL); ok {
+		return page, final, nil
 	}
 
-	resp, err := client.Get(url)
+	resp, err := opts.get("article", rawURL)
 	if err != nil {
-		return "", fmt.Errorf("fetch failed: %w", err)
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("bad status: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("bad status: %d", resp.StatusCode)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	var body io.Reader = resp.Body
+	if opts.maxBytes > 0 {
+		body = io.LimitReader(resp.Body, opts.maxBytes)
+	}
+	page, err := io.ReadAll(body)
 	if err != nil {
-		return "", fmt.Errorf("parse failed: %w", err)
+		return nil, nil, fmt.Errorf("read failed: %w", err)
+	}
+	articles.Put(rawURL, resp.Request.URL, page)
+	return page, resp.Request.URL, nil
+}
+
+// ArticlePage is what fetchArticleContent takes from an article's page
+type ArticlePage struct {
+	Text      string
+	Extractor string // the selector that matched
+	Canonical string // with off_domain: canonical, the URL to post instead when redirects led to another site
+	Image     string // the page's og:image (or twitter:image), absolute
+}
+
+// fetchArticleContent extracts text content from a URL, and the page's
+// preview image and canonical URL
+func fetchArticleContent(url string, opts FetchConfig) (ArticlePage, error) {
+	if opts.Robots {
+		allowed, err := robotsAllowed(url, opts)
+		if err != nil {
+			return ArticlePage{}, err
+		}
+		if !allowed {
+			return ArticlePage{}, errRobotsDisallowed
+		}
+	}
+
+	page, final, err := fetchArticlePage(url, opts)
+	if err != nil {
+		return ArticlePage{}, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return ArticlePage{}, fmt.Errorf("parse failed: %w", err)
+	}
+
+	// With off_domain: canonical, a link that redirected to another site is
+	// replaced by where it landed, or by that page's own canonical URL
+	canonical := ""
+	if start, err := final.Parse(url); err == nil && opts.OffDomain == REDIRECT_CANONICAL && !sameSite(start, final) {
+		canonical = final.String()
+		if href, ok := doc.Find(`link[rel="canonical"]`).First().Attr("href"); ok {
+			if ref, err := final.Parse(strings.TrimSpace(href)); err == nil && (ref.Scheme == "https" || ref.Scheme == "http") {
+				canonical = ref.String()
+			}
+		}
+	}
+
+	image := ""
+	for _, sel := range []string{`meta[property="og:image:secure_url"]`, `meta[property="og:image"]`, `meta[name="twitter:image"]`} {
+		if src, ok := doc.Find(sel).First().Attr("content"); ok {
+			if ref, err := final.Parse(strings.TrimSpace(src)); err == nil && (ref.Scheme == "https" || ref.Scheme == "http") {
+				image = ref.String()
+				break
+			}
+		}
 	}
 
 	// Remove script, style, nav, footer, header elements
 	doc.Find("script, style, nav, footer, header, aside, .advertisement, .ad").Remove()
 
 	// Try to find main content (common article selectors)
-	var text string
+	var text, extractor string
 	selectors := []string{
 		"article",
 		"[role='main']",
@@ -281,6 +522,7 @@ func fetchArticleContent(url string) (string, error) {
 		content := doc.Find(selector).First()
 		if content.Length() > 0 {
 			text = content.Text()
+			extractor = selector
 			break
 		}
 	}
@@ -288,6 +530,7 @@ func fetchArticleContent(url string) (string, error) {
 	// Fallback to body if no article found
 	if text == "" {
 		text = doc.Find("body").Text()
+		extractor = "body"
 	}
 
 	// Clean up whitespace
@@ -303,107 +546,150 @@ func fetchArticleContent(url string) (string, error) {
 	text = strings.Join(cleaned, " ")
 
 	// Limit to ~3000 characters to avoid token limits
-	if len(text) > 3000 {
-		text = text[:3000] + "..."
-	}
+	text = truncate(text, 3000, "...")
 
-	return text, nil
+	return ArticlePage{Text: text, Extractor: extractor, Canonical: canonical, Image: image}, nil
 }
 
 func main() {
-	token := os.Getenv("TG_BOT_TOKEN")
-	chatID := os.Getenv("TG_CHANNEL_ID")
-	aiApiToken := os.Getenv("GEMINI_API_TOKEN")
-	aiModel := os.Getenv("GEMINI_MODEL")
+	configFlag := flag.String("config", "", "path to config file (default $RSS_CONFIG or config.yaml)")
+	envFileFlag := flag.String("env-file", "", "file with KEY=VALUE environment variables (default .env if present)")
+	profileFlag := flag.String("profile", "", "config profile to apply, e.g. dev (default $RSS_PROFILE)")
+	maxPostsFlag := flag.Int("max-posts", 0, "cap on messages per run (overrides max_posts_per_run)")
+	postDelayFlag := flag.Duration("post-delay", 0, "pause between messages, e.g. 5s (overrides post_delay)")
+	chaosFlag := flag.String("chaos", "", "failure injection, e.g. feed=0.2,ai=0.1,telegram=0.3 (default $RSS_CHAOS)")
+	plainFlag := flag.Bool("plain", false, "print every log line instead of the live progress view on a terminal")
+	flag.Parse()
+
+	envFile := *envFileFlag
+	if envFile == "" {
+		envFile = DOTENV_FILE
+	}
+	if err := loadDotEnv(envFile, *envFileFlag != ""); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(1)
+	}
 
-	if token == "" || chatID == "" {
-		fmt.Println("Missing TG_BOT_TOKEN or TG_CHANNEL_ID")
-		return
+	if flag.Arg(0) == "config" {
+		if flag.Arg(1) != "validate" {
+			fmt.Println("Usage: rss [-config path] config validate")
+			os.Exit(2)
+		}
+		os.Exit(runConfigValidate(configPath(*configFlag), profileName(*profileFlag)))
+	}
+	if flag.Arg(0) == "init" {
+		os.Exit(runInit(configPath(*configFlag), envFile, flag.Args()[1:]))
 	}
 
-	if aiApiToken == "" || aiModel == "" {
-		fmt.Println("Missing GEMINI_API_TOKEN or GEMINI_MODEL")
-		return
+	profile := profileName(*profileFlag)
+	cfg, err := loadConfig(configPath(*configFlag), *configFlag != "" || os.Getenv(CONFIG_ENV) != "", profile)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(1)
+	}
+	if profile != "" {
+		fmt.Printf("🧪 Using config profile %q\n", profile)
+	}
+	if *maxPostsFlag > 0 {
+		cfg.MaxPostsPerRun = *maxPostsFlag
+	}
+	if *postDelayFlag > 0 {
+		cfg.PostDelay = *postDelayFlag
+	}
+	if err := configureNetwork(cfg.Network); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(1)
+	}
+	configureHostLimits(cfg.Fetch)
+	configureArticleCache(cfg.ArticleCache)
+	configureTimeouts(cfg.Timeouts)
+	chaosSpec := *chaosFlag
+	if chaosSpec == "" {
+		chaosSpec = os.Getenv("RSS_CHAOS")
+	}
+	if err := configureChaos(chaosSpec); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(2)
 	}
 
-	ctx := context.Background()
-	g := genkit.Init(ctx, genkit.WithPlugins(&googlegenai.GoogleAI{
-		APIKey: aiApiToken,
-	}))
+	// Commands that only work on local data don't need credentials
+	switch flag.Arg(0) {
+	case "feed":
+		os.Exit(runFeedCommand(cfg, configPath(*configFlag), flag.Args()[1:]))
+	case "site":
+		os.Exit(runSiteBuild(cfg, flag.Args()[1:]))
+	case "purge":
+		os.Exit(runPurge(cfg, flag.Args()[1:]))
+	case "archive":
+		os.Exit(runArchiveExport(cfg, flag.Args()[1:]))
+	case "state":
+		os.Exit(runStateCommand(cfg, flag.Args()[1:]))
+	case "mark-seen":
+		os.Exit(runMarkSeen(cfg, flag.Args()[1:]))
+	}
 
-	state := loadState()
-	defer saveState(state) // 🔒 ALWAYS save state
+	if cfg.Telegram.Token == "" || cfg.Telegram.ChannelID == "" {
+		fmt.Println("Missing TG_BOT_TOKEN or TG_CHANNEL_ID")
+		return
+	}
 
-	postsSent := 0
+	if cfg.AI.Model == "" {
+		fmt.Println("Missing GEMINI_MODEL")
+		return
+	}
+	if err := cfg.AI.checkModel(cfg.AI.Model); err != nil {
+		fmt.Printf("Missing credentials: %v\n", err)
+		return
+	}
 
-	rand.Seed(time.Now().UnixNano())
+	if err := installAudit(cfg.Audit); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(1)
+	}
+	defer audit.Close()
 
-	// Shuffle RSS_FEEDS
-	rand.Shuffle(len(RSS_FEEDS), func(i, j int) {
-		RSS_FEEDS[i], RSS_FEEDS[j] = RSS_FEEDS[j], RSS_FEEDS[i]
-	})
+	ctx := context.Background()
+	bot, err := newBot(ctx, cfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		os.Exit(1)
+	}
+	defer bot.Close()
 
-	for _, feedURL := range RSS_FEEDS {
-		if postsSent >= MAX_POSTS_PER_RUN {
-			fmt.Printf("✅ Reached limit of %d posts, stopping\n", MAX_POSTS_PER_RUN)
-			break
+	switch cmd := flag.Arg(0); cmd {
+	case "", "run":
+		if !*plainFlag {
+			bot.progress = newProgress()
 		}
-
-		fmt.Printf("📡 Fetching: %s\n", feedURL)
-
-		rss, err := fetchRSS(feedURL)
-		if err != nil {
-			fmt.Printf("⚠️RSS feed failed (%s): %v\n", feedURL, err)
-			continue // Skip this feed and move to next
+		bot.Run(ctx)
+	case "serve":
+		if err := serve(ctx, bot); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			os.Exit(1)
 		}
-
-		fmt.Printf("   Found %d items\n", len(rss.Channel.Items))
-
-		// Process from oldest to newest
-		for i := len(rss.Channel.Items) - 1; i >= 0; i-- {
-			if postsSent >= MAX_POSTS_PER_RUN {
-				break
-			}
-
-			item := rss.Channel.Items[i]
-			id := hash(item.Link)
-
-			if state[id] {
-				continue
-			}
-
-			fmt.Printf("📄 Fetching article content...\n")
-			articleContent, fetchErr := fetchArticleContent(item.Link)
-
-			aiDescript := "NO AI DESCRIPTION"
-			if fetchErr == nil {
-				resp, aiErr := genkit.Generate(ctx, g,
-					ai.WithPrompt(fmt.Sprintf(AI_PROMPT, item.Title, articleContent)),
-					ai.WithModelName(aiModel),
-				)
-
-				if aiErr == nil {
-					aiDescript = convertToTelegramHTML(resp.Text())
-				} else {
-					fmt.Printf("⚠️  AI summary failed: %v\n", aiErr)
-				}
-			}
-
-			msg := fmt.Sprintf("<b><a href=\"%s\">%s</a></b>\n<blockquote expandable>%s</blockquote>",
-				item.Link, item.Title, aiDescript)
-
-			err := sendToTelegram(token, chatID, msg)
-			if err == nil {
-				state[id] = true
-				postsSent++
-				fmt.Printf("   ✉️  Sent: %s\n", item.Title)
-			} else {
-				fmt.Printf("   ⚠️  Send failed, skipping item: %v\n", err)
-			}
-
-			time.Sleep(2 * time.Second) // safe pacing
+	case "quiz":
+		if err := bot.PostQuiz(ctx); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			os.Exit(1)
 		}
+	case "newsletter":
+		if err := bot.PostNewsletter(ctx); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			os.Exit(1)
+		}
+	case "eval":
+		os.Exit(bot.runEval(ctx, flag.Args()[1:]))
+	case "resend":
+		if flag.NArg() != 2 {
+			fmt.Println("Usage: rss resend <item-id|url>")
+			os.Exit(2)
+		}
+		if err := bot.Resend(ctx, flag.Arg(1)); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown command %q (expected run, serve, quiz, newsletter, resend or eval)\n", cmd)
+		os.Exit(2)
 	}
-
-	fmt.Printf("\n🎉 Job finished: %d posts sent\n", postsSent)
 }
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Markdown as models write it, for long-form output such as the newsletter:
+// headings, paragraphs, lists, quotes, rules, and bold, italic, code and
+// links inside them. It renders to HTML and to Telegraph's node format, and
+// summaries line by line to the HTML subset Telegram messages accept.
+
+// mdNode is a block or inline element; Tag is empty for plain text
+type mdNode struct {
+	Tag      string
+	Text     string // plain text and code
+	Href     string
+	Children []mdNode
+}
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBullet  = regexp.MustCompile(`^(?:[-*•+]|\d+[.)])\s+(.*)$`)
+	mdRule    = regexp.MustCompile(`^(?:-{3,}|\*{3,}|_{3,})$`)
+	mdFence   = regexp.MustCompile("^```\\s*([\\w+#.-]*)\\s*$")
+	mdInline  = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__|\*([^*\s][^*]*?)\*|\b_([^_\s][^_]*?)_\b|` + "`([^`]+)`" + `|\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// parseMarkdown splits text into blocks
+func parseMarkdown(text string) []mdNode {
+	var blocks []mdNode
+	var para []string
+	var list *mdNode
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, mdNode{Tag: "p", Children: parseInline(strings.Join(para, " "))})
+			para = nil
+		}
+		if list != nil {
+			blocks = append(blocks, *list)
+			list = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case mdRule.MatchString(line):
+			flush()
+			blocks = append(blocks, mdNode{Tag: "hr"})
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			blocks = append(blocks, mdNode{Tag: fmt.Sprintf("h%d", len(m[1])), Children: parseInline(strings.TrimRight(m[2], "# "))})
+		case mdBullet.MatchString(line):
+			if len(para) > 0 {
+				flush()
+			}
+			tag := "ul"
+			if line[0] >= '0' && line[0] <= '9' {
+				tag = "ol"
+			}
+			if list == nil || list.Tag != tag {
+				flush()
+				list = &mdNode{Tag: tag}
+			}
+			list.Children = append(list.Children, mdNode{Tag: "li", Children: parseInline(mdBullet.FindStringSubmatch(line)[1])})
+		case strings.HasPrefix(line, ">"):
+			flush()
+			blocks = append(blocks, mdNode{Tag: "blockquote", Children: parseInline(strings.TrimSpace(strings.TrimPrefix(line, ">")))})
+		default:
+			if list != nil {
+				flush()
+			}
+			para = append(para, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// parseInline splits a line into text and inline elements
+func parseInline(s string) []mdNode {
+	var nodes []mdNode
+	last := 0
+	for _, m := range mdInline.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			nodes = append(nodes, mdNode{Text: s[last:m[0]]})
+		}
+		group := func(i int) string { return s[m[2*i]:m[2*i+1]] }
+		switch {
+		case m[2] >= 0:
+			nodes = append(nodes, mdNode{Tag: "b", Children: parseInline(group(1))})
+		case m[4] >= 0:
+			nodes = append(nodes, mdNode{Tag: "b", Children: parseInline(group(2))})
+		case m[6] >= 0:
+			nodes = append(nodes, mdNode{Tag: "i", Children: parseInline(group(3))})
+		case m[8] >= 0:
+			nodes = append(nodes, mdNode{Tag: "i", Children: parseInline(group(4))})
+		case m[10] >= 0:
+			nodes = append(nodes, mdNode{Tag: "code", Text: group(5)})
+		default:
+			nodes = append(nodes, mdNode{Tag: "a", Href: group(7), Children: parseInline(group(6))})
+		}
+		last = m[1]
+	}
+	if last < len(s) {
+		nodes = append(nodes, mdNode{Text: s[last:]})
+	}
+	return nodes
+}
+
+// markdownHTML renders Markdown as HTML
+func markdownHTML(text string) string {
+	var sb strings.Builder
+	for _, block := range parseMarkdown(text) {
+		writeHTML(&sb, block)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func writeHTML(sb *strings.Builder, n mdNode) {
+	switch n.Tag {
+	case "":
+		sb.WriteString(html.EscapeString(n.Text))
+		return
+	case "hr":
+		sb.WriteString("<hr>")
+		return
+	case "code":
+		fmt.Fprintf(sb, "<code>%s</code>", html.EscapeString(n.Text))
+		return
+	case "a":
+		fmt.Fprintf(sb, `<a href="%s">`, html.EscapeString(n.Href))
+	default:
+		fmt.Fprintf(sb, "<%s>", n.Tag)
+	}
+	for _, c := range n.Children {
+		writeHTML(sb, c)
+	}
+	fmt.Fprintf(sb, "</%s>", n.Tag)
+}
+
+// telegraphNodes renders Markdown as Telegraph page content, which only has
+// h3 and h4 headings
+func telegraphNodes(text string) []any {
+	var nodes []any
+	for _, block := range parseMarkdown(text) {
+		nodes = append(nodes, telegraphNode(block))
+	}
+	return nodes
+}
+
+func telegraphNode(n mdNode) any {
+	switch n.Tag {
+	case "":
+		return n.Text
+	case "code", "hr":
+		node := map[string]any{"tag": n.Tag}
+		if n.Text != "" {
+			node["children"] = []any{n.Text}
+		}
+		return node
+	}
+	tag := n.Tag
+	switch tag {
+	case "h1", "h2":
+		tag = "h3"
+	case "h5", "h6":
+		tag = "h4"
+	}
+	node := map[string]any{"tag": tag}
+	if n.Href != "" {
+		node["attrs"] = map[string]string{"href": n.Href}
+	}
+	var children []any
+	for _, c := range n.Children {
+		children = append(children, telegraphNode(c))
+	}
+	if len(children) > 0 {
+		node["children"] = children
+	}
+	return node
+}
+
+// convertToTelegramHTML renders a summary for a Telegram message, keeping its
+// lines: headings become bold, list items bullets, quotes italics (the
+// layouts already put summaries in a blockquote, which can't nest) and fenced
+// code a pre block. Everything else is escaped, so <, > and & in the text,
+// code samples included, come out as written.
+func convertToTelegramHTML(text string) string {
+	var lines []string
+	var code []string
+	inCode, lang := false, ""
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := mdFence.FindStringSubmatch(trimmed); m != nil {
+			if inCode {
+				lines = append(lines, preBlock(lang, code))
+				inCode, code = false, nil
+			} else {
+				inCode, lang = true, m[1]
+			}
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+		switch {
+		case trimmed == "" || mdRule.MatchString(trimmed):
+			lines = append(lines, "")
+		case mdHeading.MatchString(trimmed):
+			m := mdHeading.FindStringSubmatch(trimmed)
+			lines = append(lines, "<b>"+inlineHTML(strings.TrimRight(m[2], "# "))+"</b>")
+		case mdBullet.MatchString(trimmed):
+			marker := "•"
+			if trimmed[0] >= '0' && trimmed[0] <= '9' {
+				marker, _, _ = strings.Cut(trimmed, " ")
+			}
+			lines = append(lines, marker+" "+inlineHTML(mdBullet.FindStringSubmatch(trimmed)[1]))
+		case strings.HasPrefix(trimmed, ">"):
+			lines = append(lines, "<i>"+inlineHTML(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))+"</i>")
+		default:
+			lines = append(lines, inlineHTML(trimmed))
+		}
+	}
+	if inCode { // the model never closed the fence
+		lines = append(lines, preBlock(lang, code))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// inlineHTML renders a line's bold, italic, code and links
+func inlineHTML(s string) string {
+	var sb strings.Builder
+	for _, n := range parseInline(s) {
+		writeHTML(&sb, n)
+	}
+	return sb.String()
+}
+
+func preBlock(lang string, code []string) string {
+	body := html.EscapeString(strings.Join(code, "\n"))
+	if lang == "" {
+		return "<pre>" + body + "</pre>"
+	}
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, html.EscapeString(lang), body)
+}
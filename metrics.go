@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type MetricsConfig struct {
+	PushgatewayURL string            `yaml:"pushgateway_url"`
+	Job            string            `yaml:"job"`
+	Labels         map[string]string `yaml:"labels"` // extra grouping labels, e.g. instance
+}
+
+// RunMetrics holds per-run counters. Cron runs can't be scraped, so they are
+// pushed to a Prometheus Pushgateway once the run finishes.
+type RunMetrics struct {
+	Start               time.Time
+	FeedsFetched        int
+	FeedErrors          int
+	FeedsSkipped        int // backed off after repeated failures
+	FeedsUnchanged      int // same body as the last run, not parsed
+	FeedsDeferred       int // host asked for a break, tried again later in the run
+	ItemsNew            int
+	ItemsIrrelevant     int // scored below relevance.threshold
+	ItemsDuplicate      int // a story posted recently, see stories.go
+	ItemsLowRated       int // rated below min_rating by the model
+	ItemsModerated      int // blocked by moderation
+	PostsSent           int
+	SendFailures        int
+	FetchFailures       int
+	AIFailures          int
+	ItemsDeferred       int // model unavailable, left for the next run
+	InputTokens         int
+	OutputTokens        int
+	BytesIn             int64 // on the wire, by the whole process while the run was active
+	BytesOut            int64
+	PerFeedSent         map[string]int
+	PerFeedFailure      map[string]int
+	PerFeedInputTokens  map[string]int // see costs.go
+	PerFeedOutputTokens map[string]int
+}
+
+func newRunMetrics() *RunMetrics {
+	return &RunMetrics{
+		Start:               time.Now(),
+		PerFeedSent:         map[string]int{},
+		PerFeedFailure:      map[string]int{},
+		PerFeedInputTokens:  map[string]int{},
+		PerFeedOutputTokens: map[string]int{},
+	}
+}
+
+// exposition renders the metrics in the Prometheus text format
+func (m *RunMetrics) exposition(success bool) []byte {
+	var b bytes.Buffer
+
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	perFeed := func(name, help string, values map[string]int) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		feeds := make([]string, 0, len(values))
+		for feed := range values {
+			feeds = append(feeds, feed)
+		}
+		sort.Strings(feeds)
+		for _, feed := range feeds {
+			fmt.Fprintf(&b, "%s{feed=\"%s\"} %d\n", name, escapeLabel(feed), values[feed])
+		}
+	}
+
+	gauge("rss_run_duration_seconds", "Wall time of the last run.", time.Since(m.Start).Seconds())
+	gauge("rss_run_feeds_fetched", "Feeds fetched successfully in the last run.", float64(m.FeedsFetched))
+	gauge("rss_run_feed_errors", "Feeds that failed to fetch or parse in the last run.", float64(m.FeedErrors))
+	gauge("rss_run_feeds_skipped", "Feeds skipped in the last run because they kept failing.", float64(m.FeedsSkipped))
+	gauge("rss_run_feeds_unchanged", "Feeds whose body was unchanged since the previous run in the last run.", float64(m.FeedsUnchanged))
+	gauge("rss_run_feeds_deferred", "Feeds put back in the last run because their host sent Retry-After.", float64(m.FeedsDeferred))
+	gauge("rss_run_items_new", "Unseen items considered in the last run.", float64(m.ItemsNew))
+	gauge("rss_run_items_irrelevant", "Items skipped in the last run for scoring below the relevance threshold.", float64(m.ItemsIrrelevant))
+	gauge("rss_run_items_duplicate", "Items skipped in the last run as stories posted recently under another link.", float64(m.ItemsDuplicate))
+	gauge("rss_run_items_low_rated", "Items skipped in the last run for a rating below min_rating.", float64(m.ItemsLowRated))
+	gauge("rss_run_items_moderated", "Items blocked by moderation in the last run.", float64(m.ItemsModerated))
+	gauge("rss_run_posts_sent", "Messages sent to Telegram in the last run.", float64(m.PostsSent))
+	gauge("rss_run_send_failures", "Telegram sends that failed in the last run.", float64(m.SendFailures))
+	gauge("rss_run_article_fetch_failures", "Article pages that could not be fetched in the last run.", float64(m.FetchFailures))
+	gauge("rss_run_ai_failures", "AI summaries that failed in the last run.", float64(m.AIFailures))
+	gauge("rss_run_items_deferred", "Items left for the next run in the last run because the AI model was unavailable.", float64(m.ItemsDeferred))
+	gauge("rss_run_input_tokens", "Prompt tokens used in the last run.", float64(m.InputTokens))
+	gauge("rss_run_output_tokens", "Response tokens used in the last run.", float64(m.OutputTokens))
+	gauge("rss_run_bytes_received", "Bytes received over the network during the last run.", float64(m.BytesIn))
+	gauge("rss_run_bytes_sent", "Bytes sent over the network during the last run.", float64(m.BytesOut))
+	perFeed("rss_run_feed_posts_sent", "Messages sent per feed in the last run.", m.PerFeedSent)
+	perFeed("rss_run_feed_failures", "Failures per feed in the last run.", m.PerFeedFailure)
+	perFeed("rss_run_feed_input_tokens", "Prompt tokens used per feed in the last run.", m.PerFeedInputTokens)
+	perFeed("rss_run_feed_output_tokens", "Response tokens used per feed in the last run.", m.PerFeedOutputTokens)
+
+	if success {
+		gauge("rss_run_last_success_timestamp_seconds", "Unix time the last run finished.", float64(time.Now().Unix()))
+	}
+	return b.Bytes()
+}
+
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// pushMetrics replaces this job's metric group on the Pushgateway
+func pushMetrics(cfg MetricsConfig, m *RunMetrics, success bool) error {
+	if cfg.PushgatewayURL == "" {
+		return nil
+	}
+
+	job := cfg.Job
+	if job == "" {
+		job = "rss"
+	}
+	target := strings.TrimRight(cfg.PushgatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+
+	keys := make([]string, 0, len(cfg.Labels))
+	for k := range cfg.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		target += "/" + url.PathEscape(k) + "/" + url.PathEscape(cfg.Labels[k])
+	}
+
+	req, err := http.NewRequestWithContext(withPurpose(context.Background(), "metrics"), http.MethodPut, target, bytes.NewReader(m.exposition(success)))
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := httpDo(req, 15*time.Second)
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("push failed: %d %s", resp.StatusCode, string(rb))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	MODERATION_BLOCK = "block" // skip flagged items (default)
+	MODERATION_FLAG  = "flag"  // post them with a warning, the summary behind a spoiler
+)
+
+const MODERATION_MODEL = "omni-moderation-latest"
+
+// ModerationConfig screens article pages before they are summarized, so an
+// open aggregator channel doesn't pass on adult or abusive content scraped
+// along with the news
+type ModerationConfig struct {
+	Action   string   `yaml:"action"`   // block or flag
+	Keywords []string `yaml:"keywords"` // whole words or phrases, case-insensitive, matched in the title and article text
+	Provider string   `yaml:"provider"` // openai to also ask OpenAI's moderation API with ai.openai's key; empty for keywords only
+	Model    string   `yaml:"model"`    // default omni-moderation-latest
+}
+
+func (c ModerationConfig) enabled() bool {
+	return len(c.Keywords) > 0 || c.Provider != ""
+}
+
+// moderationWords matches any of the keywords as whole words, nil without any
+func moderationWords(keywords []string) *regexp.Regexp {
+	var alts []string
+	for _, kw := range keywords {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			alts = append(alts, regexp.QuoteMeta(kw))
+		}
+	}
+	if len(alts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alts, "|") + `)\b`)
+}
+
+// checkModeration reports what flagged an item, or "" when nothing did. A
+// failed API call lets the item through, like the other model checks.
+func (b *Bot) checkModeration(ctx context.Context, p *pendingItem) string {
+	cfg := b.cfg.Moderation
+	if !cfg.enabled() {
+		return ""
+	}
+	text := p.item.Title + "\n\n" + p.content
+	if b.moderationWords != nil {
+		if m := b.moderationWords.FindString(text); m != "" {
+			return fmt.Sprintf("keyword %q", strings.ToLower(m))
+		}
+	}
+	if cfg.Provider != PROVIDER_OPENAI {
+		return ""
+	}
+
+	categories, err := openAIModeration(ctx, b.cfg.AI.OpenAI, cmp.Or(cfg.Model, MODERATION_MODEL), text)
+	if err != nil {
+		fmt.Printf("⚠️  Moderation check failed (%s): %v\n", p.item.Title, err)
+		return ""
+	}
+	if len(categories) == 0 {
+		return ""
+	}
+	return strings.Join(categories, ", ")
+}
+
+// openAIModeration asks the moderation endpoint about text and returns the
+// categories it flagged, sorted
+func openAIModeration(ctx context.Context, cfg OpenAIConfig, model, text string) ([]string, error) {
+	if cfg.APIKey == "" && cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai: no API key (set ai.openai.api_key or OPENAI_API_KEY)")
+	}
+	endpoint, header := (&openAIPlugin{cfg: cfg}).endpoint()
+	var out struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	body := map[string]any{"model": model, "input": text}
+	if err := postProvider(ctx, PROVIDER_OPENAI, endpoint+"/moderations", header, body, &out); err != nil {
+		return nil, err
+	}
+
+	var categories []string
+	for _, r := range out.Results {
+		if !r.Flagged {
+			continue
+		}
+		for name, hit := range r.Categories {
+			if hit {
+				categories = append(categories, name)
+			}
+		}
+		if len(categories) == 0 {
+			categories = append(categories, "flagged")
+		}
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+// flaggedSummary puts a flagged item's summary behind a spoiler, under a
+// warning saying why
+func flaggedSummary(reason, aiDescript string) string {
+	return fmt.Sprintf("⚠️ <i>Sensitive content (%s)</i>\n<tg-spoiler>%s</tg-spoiler>", html.EscapeString(reason), aiDescript)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NetworkConfig controls how outbound connections are resolved and dialed
+type NetworkConfig struct {
+	// "" leaves it to the resolver's order; prefer-ipv4 / prefer-ipv6 try that
+	// family first and fall back to the other after fallback_delay (Happy
+	// Eyeballs); ipv4 / ipv6 only ever dial that family
+	IP            string        `yaml:"ip"`
+	FallbackDelay time.Duration `yaml:"fallback_delay"` // default 300ms
+	DNS           []string      `yaml:"dns"`            // resolvers as host or host:port, tried in order; default the system's
+	DNSCacheTTL   time.Duration `yaml:"dns_cache_ttl"`  // reuse lookups this long, and past it when the resolver fails; 0 disables
+
+	// All outbound traffic (Telegram, AI, feeds) goes through this http://,
+	// https:// or socks5:// proxy; fetch.proxy and per-feed proxies still win
+	// for feeds. Default: HTTPS_PROXY / HTTP_PROXY, else ALL_PROXY, minus NO_PROXY.
+	Proxy string `yaml:"proxy"`
+
+	// Keep-alive pool shared by all requests
+	MaxIdlePerHost int           `yaml:"max_idle_per_host"` // idle connections kept per host, default 8
+	IdleTimeout    time.Duration `yaml:"idle_timeout"`      // close idle connections after this, default 90s
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`   // TCP connect, default 30s
+	TLSTimeout     time.Duration `yaml:"tls_timeout"`       // TLS handshake, default 10s
+}
+
+const (
+	IP_ANY         = ""
+	IP_PREFER_IPV4 = "prefer-ipv4"
+	IP_PREFER_IPV6 = "prefer-ipv6"
+	IP_ONLY_IPV4   = "ipv4"
+	IP_ONLY_IPV6   = "ipv6"
+)
+
+// dialContext is what every HTTP connection is dialed with, see configureNetwork
+var dialContext = newNetDialer(30 * time.Second).DialContext
+
+// newNetDialer connects to resolved addresses, refusing internal ones for fetches (guardDial)
+func newNetDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, ControlContext: guardDial}
+}
+
+// configureNetwork sets the egress proxy and connection pool, and replaces
+// the default dialer when any dialing option is set
+func configureNetwork(cfg NetworkConfig) error {
+	if err := configureProxy(cfg.Proxy); err != nil {
+		return err
+	}
+	configureTransport(cfg)
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = 30 * time.Second
+	}
+	if cfg.IP == IP_ANY && len(cfg.DNS) == 0 && cfg.DNSCacheTTL == 0 {
+		dialContext = newNetDialer(cfg.ConnectTimeout).DialContext
+		return nil
+	}
+	switch cfg.IP {
+	case IP_ANY, IP_PREFER_IPV4, IP_PREFER_IPV6, IP_ONLY_IPV4, IP_ONLY_IPV6:
+	default:
+		return fmt.Errorf("network.ip: unknown value %q (expected prefer-ipv4, prefer-ipv6, ipv4 or ipv6)", cfg.IP)
+	}
+	if cfg.FallbackDelay <= 0 {
+		cfg.FallbackDelay = 300 * time.Millisecond
+	}
+
+	d := &dialer{
+		cfg:    cfg,
+		net:    newNetDialer(cfg.ConnectTimeout),
+		cached: map[string]dnsEntry{},
+	}
+	d.resolver = net.DefaultResolver
+	if len(cfg.DNS) > 0 {
+		servers := make([]string, len(cfg.DNS))
+		for i, s := range cfg.DNS {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			servers[i] = s
+		}
+		// Not d.net: its guard would refuse resolvers at private addresses
+		// (a VPC's 10.x.0.2, 169.254.169.253), which network.dns may well name
+		dns := &net.Dialer{Timeout: cfg.ConnectTimeout}
+		d.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var err error
+				for _, server := range servers {
+					var conn net.Conn
+					if conn, err = dns.DialContext(ctx, network, server); err == nil {
+						return conn, nil
+					}
+				}
+				return nil, err
+			},
+		}
+	}
+	dialContext = d.DialContext
+	return nil
+}
+
+// configureProxy points the shared transport at network.proxy, or at ALL_PROXY
+// when the standard HTTP(S)_PROXY variables aren't set (Go only reads those)
+func configureProxy(raw string) error {
+	if raw == "" && os.Getenv("HTTPS_PROXY") == "" && os.Getenv("https_proxy") == "" &&
+		os.Getenv("HTTP_PROXY") == "" && os.Getenv("http_proxy") == "" {
+		raw = cmp.Or(os.Getenv("ALL_PROXY"), os.Getenv("all_proxy"))
+		if raw == "" {
+			return nil
+		}
+	}
+	if raw == "" {
+		return nil // http.ProxyFromEnvironment, the transport's default
+	}
+	proxyURL, err := checkProxyURL(raw)
+	if err != nil {
+		return fmt.Errorf("network.proxy: %w", err)
+	}
+	baseTransport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if host := req.URL.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() || noProxy(host) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+	return nil
+}
+
+// noProxy reports whether NO_PROXY lists host or a parent domain of it
+func noProxy(host string) bool {
+	for _, entry := range strings.Split(cmp.Or(os.Getenv("NO_PROXY"), os.Getenv("no_proxy")), ",") {
+		entry = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(entry)), ".")
+		if entry == "*" || entry != "" && (host == entry || strings.HasSuffix(host, "."+entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+type dnsEntry struct {
+	addrs   []netip.Addr
+	expires time.Time
+}
+
+type dialer struct {
+	cfg      NetworkConfig
+	net      *net.Dialer
+	resolver *net.Resolver
+
+	mu     sync.Mutex
+	cached map[string]dnsEntry
+}
+
+// lookup resolves host, serving from the cache while fresh and a stale entry
+// when the resolver fails, so a DNS hiccup doesn't cost a whole feed
+func (d *dialer) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if d.cfg.DNSCacheTTL > 0 {
+		d.mu.Lock()
+		entry, ok := d.cached[host]
+		d.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.addrs, nil
+		}
+	}
+
+	addrs, err := d.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		if d.cfg.DNSCacheTTL > 0 {
+			d.mu.Lock()
+			entry, ok := d.cached[host]
+			d.mu.Unlock()
+			if ok {
+				fmt.Printf("⚠️  DNS lookup for %s failed (%v), using the cached address\n", host, err)
+				return entry.addrs, nil
+			}
+		}
+		return nil, err
+	}
+	if d.cfg.DNSCacheTTL > 0 {
+		d.mu.Lock()
+		d.cached[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.cfg.DNSCacheTTL)}
+		d.mu.Unlock()
+	}
+	return addrs, nil
+}
+
+// order splits addresses into the family tried first and the fallback family
+func (d *dialer) order(addrs []netip.Addr) (primary, fallback []netip.Addr) {
+	for _, a := range addrs {
+		a = a.Unmap()
+		switch d.cfg.IP {
+		case IP_ONLY_IPV4:
+			if a.Is4() {
+				primary = append(primary, a)
+			}
+		case IP_ONLY_IPV6:
+			if a.Is6() {
+				primary = append(primary, a)
+			}
+		case IP_PREFER_IPV4:
+			if a.Is4() {
+				primary = append(primary, a)
+			} else {
+				fallback = append(fallback, a)
+			}
+		case IP_PREFER_IPV6:
+			if a.Is6() {
+				primary = append(primary, a)
+			} else {
+				fallback = append(fallback, a)
+			}
+		default:
+			// Keep the resolver's order, falling back to the other family like net.Dialer does
+			if len(primary) == 0 || a.Is4() == primary[0].Is4() {
+				primary = append(primary, a)
+			} else {
+				fallback = append(fallback, a)
+			}
+		}
+	}
+	return primary, fallback
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return d.net.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	primary, fallback := d.order(addrs)
+	if len(primary) == 0 {
+		primary, fallback = fallback, nil
+	}
+	if len(primary) == 0 {
+		return nil, fmt.Errorf("dial %s: no %s address", host, d.cfg.IP)
+	}
+	if len(fallback) == 0 {
+		return d.dialSerial(ctx, network, port, primary)
+	}
+
+	// Happy Eyeballs: give the preferred family a head start, then race both
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, 2)
+	primaryFailed := make(chan struct{})
+	go func() {
+		conn, err := d.dialSerial(ctx, network, port, primary)
+		if err != nil {
+			close(primaryFailed)
+		}
+		results <- result{conn, err}
+	}()
+	go func() {
+		select {
+		case <-time.After(d.cfg.FallbackDelay):
+		case <-primaryFailed:
+		case <-ctx.Done():
+			results <- result{err: ctx.Err()}
+			return
+		}
+		conn, err := d.dialSerial(ctx, network, port, fallback)
+		results <- result{conn, err}
+	}()
+
+	var firstErr error
+	for i := range 2 {
+		r := <-results
+		if r.err == nil {
+			cancel()
+			if i == 0 {
+				// The other attempt may still connect; close it when it does
+				go func() {
+					if other := <-results; other.conn != nil {
+						other.conn.Close()
+					}
+				}()
+			}
+			return r.conn, nil
+		}
+		if firstErr == nil || errors.Is(firstErr, context.Canceled) {
+			firstErr = r.err
+		}
+	}
+	return nil, firstErr
+}
+
+// dialSerial tries each address in turn
+func (d *dialer) dialSerial(ctx context.Context, network, port string, addrs []netip.Addr) (net.Conn, error) {
+	var err error
+	for _, a := range addrs {
+		var conn net.Conn
+		if conn, err = d.net.DialContext(ctx, network, net.JoinHostPort(a.String(), port)); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// NewsletterConfig is the weekly "best of": the top-rated posts of the week
+// written up as one long-form issue and delivered by email, as a Telegraph
+// page announced in the channel, and/or to a file
+type NewsletterConfig struct {
+	Schedule string `yaml:"schedule"` // cron expression for issues under `rss serve`, e.g. "0 9 * * 1"
+	Title    string `yaml:"title"`    // default "Weekly digest"
+	Days     int    `yaml:"days"`     // how far back to look for posts, default 7
+	Items    int    `yaml:"items"`    // posts in an issue, default 10
+	File     string `yaml:"file"`     // also write the issue's Markdown here; {date} is replaced
+
+	Email     NewsletterEmail     `yaml:"email"`
+	Telegraph NewsletterTelegraph `yaml:"telegraph"`
+}
+
+// NewsletterEmail sends issues over SMTP as HTML with a Markdown text part
+type NewsletterEmail struct {
+	SMTP     string   `yaml:"smtp"` // host:port, e.g. smtp.example.com:587; empty disables email
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// NewsletterTelegraph publishes issues on telegra.ph and posts the link
+type NewsletterTelegraph struct {
+	AccessToken string `yaml:"access_token"` // from createAccount; empty disables Telegraph
+	AuthorName  string `yaml:"author_name"`
+	Channel     string `yaml:"channel"` // key into channels for the announcement; default telegram.channel_id
+}
+
+const NEWSLETTER_PROMPT = `Write this week's issue of a newsletter for software engineers, "%s", from the best articles we posted.
+
+Rules:
+- Markdown: ## headings, paragraphs, - bullets, **bold**, [text](url) links
+- Open with a short paragraph on the week's themes
+- Then one section per article, most important first: a ## heading with the
+  title, 2-4 sentences on why it matters, and a [Read more](url) link to its url
+- Group closely related articles in one section when that reads better
+- End with a one-line sign-off
+- Do not invent facts beyond the summaries
+- Output only the newsletter, no preamble
+
+Articles:
+%s`
+
+// newsletterPick is an archived post and the rating it went out with
+type newsletterPick struct {
+	*ArchivedItem
+	Rating int
+}
+
+// pickNewsletterItems are the best-rated summarized posts since cutoff,
+// newer first among equal ratings
+func pickNewsletterItems(all []ArchivedItem, cutoff time.Time, n int) []newsletterPick {
+	seen := map[string]bool{}
+	var picks []newsletterPick
+	for i := range all {
+		item := &all[i]
+		if item.SentAt.Before(cutoff) || item.Summary == "" || seen[item.ID] {
+			continue
+		}
+		seen[item.ID] = true
+		picks = append(picks, newsletterPick{item, summaryRating(item.Summary)})
+	}
+	sort.SliceStable(picks, func(i, j int) bool {
+		if picks[i].Rating != picks[j].Rating {
+			return picks[i].Rating > picks[j].Rating
+		}
+		return picks[i].SentAt.After(picks[j].SentAt)
+	})
+	if len(picks) > n {
+		picks = picks[:n]
+	}
+	return picks
+}
+
+// PostNewsletter writes an issue from the period's best posts and delivers
+// it everywhere configured
+func (b *Bot) PostNewsletter(ctx context.Context) error {
+	cfg := b.cfg.Newsletter
+	if cfg.Title == "" {
+		cfg.Title = "Weekly digest"
+	}
+	if cfg.Days <= 0 {
+		cfg.Days = 7
+	}
+	if cfg.Items <= 0 {
+		cfg.Items = 10
+	}
+	if cfg.File == "" && cfg.Email.SMTP == "" && cfg.Telegraph.AccessToken == "" {
+		return fmt.Errorf("newsletter has nowhere to go: set newsletter.file, newsletter.email.smtp or newsletter.telegraph.access_token")
+	}
+
+	all, err := b.archive.Recent(0, "")
+	if err != nil {
+		return err
+	}
+	picks := pickNewsletterItems(all, time.Now().AddDate(0, 0, -cfg.Days), cfg.Items)
+	if len(picks) == 0 {
+		return fmt.Errorf("no summarized posts in the last %d days", cfg.Days)
+	}
+
+	var articles strings.Builder
+	for _, p := range picks {
+		fmt.Fprintf(&articles, "\ntitle: %s\nurl: %s\n", p.Title, p.Link)
+		if p.Rating > 0 {
+			fmt.Fprintf(&articles, "rating: %d/10\n", p.Rating)
+		}
+		fmt.Fprintf(&articles, "summary: %s\n", truncate(p.Summary, 1500, "…"))
+	}
+
+	fmt.Printf("📰 Writing the newsletter from %d post(s)\n", len(picks))
+	aiCtx, cancel := aiContext(ctx)
+	defer cancel()
+	resp, err := genkit.Generate(aiCtx, b.g,
+		ai.WithPrompt(withLanguage(fmt.Sprintf(NEWSLETTER_PROMPT, cfg.Title, articles.String()), b.cfg.Language)),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	if err != nil {
+		return fmt.Errorf("newsletter generation failed: %w", err)
+	}
+	body := strings.TrimSpace(resp.Text())
+	if err := checkSummary(body); err != nil {
+		return fmt.Errorf("newsletter generation failed: %w", err)
+	}
+
+	title := fmt.Sprintf("%s, %s", cfg.Title, time.Now().Format("2 January 2006"))
+	var failed []string
+	if cfg.File != "" {
+		path := strings.ReplaceAll(cfg.File, "{date}", time.Now().Format("2006-01-02"))
+		if err := os.WriteFile(path, []byte("# "+title+"\n\n"+body+"\n"), 0644); err != nil {
+			failed = append(failed, fmt.Sprintf("file: %v", err))
+		} else {
+			fmt.Printf("📰 Newsletter written to %s\n", path)
+		}
+	}
+	if cfg.Email.SMTP != "" {
+		if err := sendNewsletterEmail(cfg.Email, title, body); err != nil {
+			failed = append(failed, fmt.Sprintf("email: %v", err))
+		} else {
+			fmt.Printf("📰 Newsletter emailed to %d recipient(s)\n", len(cfg.Email.To))
+		}
+	}
+	if cfg.Telegraph.AccessToken != "" {
+		if err := b.publishNewsletter(ctx, cfg.Telegraph, title, body); err != nil {
+			failed = append(failed, fmt.Sprintf("telegraph: %v", err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("newsletter delivery failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// sendNewsletterEmail mails the issue as HTML with the Markdown as the plain-text part
+func sendNewsletterEmail(cfg NewsletterEmail, title, body string) error {
+	host, _, err := net.SplitHostPort(cfg.SMTP)
+	if err != nil {
+		return fmt.Errorf("bad smtp address %q: %w", cfg.SMTP, err)
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+
+	boundary := fmt.Sprintf("rss-%d", time.Now().UnixNano())
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&msg, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", boundary, body)
+	fmt.Fprintf(&msg, "--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n<h1>%s</h1>\n%s\r\n", boundary, html.EscapeString(title), markdownHTML(body))
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+
+	return smtp.SendMail(cfg.SMTP, auth, cfg.From, cfg.To, msg.Bytes())
+}
+
+// publishNewsletter creates a Telegraph page for the issue and announces it
+func (b *Bot) publishNewsletter(ctx context.Context, cfg NewsletterTelegraph, title, body string) error {
+	content, _ := json.Marshal(telegraphNodes(body))
+	params, _ := json.Marshal(map[string]any{
+		"access_token": cfg.AccessToken,
+		"title":        title,
+		"author_name":  cfg.AuthorName,
+		"content":      string(content),
+	})
+	req, err := http.NewRequestWithContext(withPurpose(ctx, "telegraph"), http.MethodPost, "https://api.telegra.ph/createPage", bytes.NewReader(params))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpDo(req, timeouts.Telegram)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	var page struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Result struct {
+			URL string `json:"url"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	if !page.OK {
+		return fmt.Errorf("%s", page.Error)
+	}
+	fmt.Printf("📰 Newsletter published at %s\n", page.Result.URL)
+
+	chatID := b.cfg.chatFor(FeedConfig{Channel: cfg.Channel})
+	text := fmt.Sprintf("📰 <b>%s</b>\n\n<a href=\"%s\">Read this week's issue</a>", html.EscapeString(title), html.EscapeString(page.Result.URL))
+	if _, err := sendToTelegram(ctx, b.cfg.Telegram.Token, chatID, text); err != nil {
+		return fmt.Errorf("announcement failed: %w", err)
+	}
+	return nil
+}
+
+// runNewsletterScheduled sends an issue at every tick of the newsletter schedule
+func runNewsletterScheduled(ctx context.Context, bot *Bot, schedule *cronSchedule) error {
+	return runEvery(ctx, schedule, "newsletter", func() {
+		if err := bot.PostNewsletter(ctx); err != nil {
+			fmt.Printf("⚠️  Newsletter failed: %v\n", err)
+		}
+	})
+}
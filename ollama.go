@@ -0,0 +1,185 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+)
+
+// OllamaConfig points ollama/* models (e.g. ollama/llama3.1:8b) at an Ollama
+// server, for summaries that cost nothing and stay on the machine. Other
+// local servers (llama.cpp, LM Studio, vLLM) speak the OpenAI API instead;
+// use openai/... with ai.openai.base_url for those.
+type OllamaConfig struct {
+	BaseURL       string `yaml:"base_url"`       // default http://localhost:11434
+	ContextWindow int    `yaml:"context_window"` // tokens the model sees, prompt and reply; default 8192
+	MaxTokens     int    `yaml:"max_tokens"`     // cap on each reply; default 1024
+}
+
+const (
+	OLLAMA_BASE_URL       = "http://localhost:11434"
+	OLLAMA_CONTEXT_WINDOW = 8192
+	OLLAMA_MAX_TOKENS     = 1024
+
+	// Rough size of a token, for fitting prompts into the context window
+	// without a tokenizer; English averages more, so prompts err on the short side
+	CHARS_PER_TOKEN = 3
+)
+
+// ollamaPlugin resolves ollama/<model> names for genkit
+type ollamaPlugin struct {
+	cfg OllamaConfig
+}
+
+func (p *ollamaPlugin) Name() string { return PROVIDER_OLLAMA }
+
+func (p *ollamaPlugin) Init(ctx context.Context) []api.Action { return nil }
+
+func (p *ollamaPlugin) ListActions(ctx context.Context) []api.ActionDesc { return nil }
+
+func (p *ollamaPlugin) ResolveAction(atype api.ActionType, name string) api.Action {
+	switch atype {
+	case api.ActionTypeModel:
+		return providerModel(PROVIDER_OLLAMA, name, func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+			return p.generate(ctx, name, req)
+		})
+	case api.ActionTypeEmbedder:
+		return providerEmbedder(PROVIDER_OLLAMA, name, func(ctx context.Context, texts []string) ([][]float32, error) {
+			return p.embed(ctx, name, texts)
+		})
+	}
+	return nil
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	NumCtx      int      `json:"num_ctx"`
+	NumPredict  int      `json:"num_predict"`
+	Temperature float64  `json:"temperature,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	TopK        int      `json:"top_k,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// generate sends one /api/chat request
+func (p *ollamaPlugin) generate(ctx context.Context, model string, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+	cfg := commonConfig(req)
+	body := ollamaRequest{
+		Model: model,
+		Options: ollamaOptions{
+			NumCtx:      cmp.Or(p.cfg.ContextWindow, OLLAMA_CONTEXT_WINDOW),
+			NumPredict:  cmp.Or(cfg.MaxOutputTokens, p.cfg.MaxTokens, OLLAMA_MAX_TOKENS),
+			Temperature: cfg.Temperature,
+			TopP:        cfg.TopP,
+			TopK:        cfg.TopK,
+			Stop:        cfg.StopSequences,
+		},
+	}
+	var roles []string
+	var texts [][]string
+	for _, m := range req.Messages {
+		role := string(m.Role)
+		switch m.Role {
+		case ai.RoleModel:
+			role = "assistant"
+		case ai.RoleTool:
+			continue
+		}
+		roles = append(roles, role)
+		texts = append(texts, textParts(m))
+	}
+	if fitContext(texts, body.Options.NumCtx-body.Options.NumPredict) {
+		fmt.Printf("✂️  Prompt trimmed to fit the %d-token context window of %s\n", body.Options.NumCtx, model)
+	}
+	for i, role := range roles {
+		body.Messages = append(body.Messages, ollamaMessage{Role: role, Content: strings.Join(texts[i], "\n\n")})
+	}
+	if req.Output != nil && req.Output.Format == "json" {
+		body.Format = "json"
+	}
+
+	endpoint := strings.TrimSuffix(cmp.Or(p.cfg.BaseURL, OLLAMA_BASE_URL), "/")
+	start := time.Now()
+	var out ollamaResponse
+	if err := postProvider(ctx, PROVIDER_OLLAMA, endpoint+"/api/chat", http.Header{}, body, &out); err != nil {
+		return nil, err
+	}
+
+	reason := ai.FinishReasonStop
+	if out.DoneReason == "length" {
+		reason = ai.FinishReasonLength
+	}
+	return &ai.ModelResponse{
+		Message:      ai.NewModelTextMessage(out.Message.Content),
+		FinishReason: reason,
+		LatencyMs:    float64(time.Since(start).Milliseconds()),
+		Usage: &ai.GenerationUsage{
+			InputTokens:  out.PromptEvalCount,
+			OutputTokens: out.EvalCount,
+			TotalTokens:  out.PromptEvalCount + out.EvalCount,
+		},
+	}, nil
+}
+
+// embed sends one /api/embed request; Ollama cuts texts longer than the
+// model's context itself
+func (p *ollamaPlugin) embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	endpoint := strings.TrimSuffix(cmp.Or(p.cfg.BaseURL, OLLAMA_BASE_URL), "/")
+	var out struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	body := map[string]any{"model": model, "input": texts}
+	if err := postProvider(ctx, PROVIDER_OLLAMA, endpoint+"/api/embed", http.Header{}, body, &out); err != nil {
+		return nil, err
+	}
+	return out.Embeddings, nil
+}
+
+// fitContext cuts the longest text part so the prompt fits in budget tokens,
+// and reports whether it had to. Ollama would otherwise drop the start of the
+// prompt, where the instructions are; the longest part is nearly always the
+// article, and output instructions are parts of their own.
+func fitContext(texts [][]string, budget int) bool {
+	limit := max(budget, 1) * CHARS_PER_TOKEN
+	size := 0
+	longest, longestPart, longestLen := 0, 0, -1
+	for i, parts := range texts {
+		for j, text := range parts {
+			n := graphemeCount(text)
+			size += n
+			if n > longestLen {
+				longest, longestPart, longestLen = i, j, n
+			}
+		}
+	}
+	if size <= limit || longestLen < 0 {
+		return false
+	}
+	texts[longest][longestPart] = truncate(texts[longest][longestPart], max(longestLen-(size-limit), 0), "…")
+	return true
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+)
+
+// OpenAIConfig points openai/* models at OpenAI's chat completions API, or
+// at any service that speaks it (OpenRouter, Groq, Together, a local server)
+type OpenAIConfig struct {
+	APIKey    string `yaml:"api_key"`
+	BaseURL   string `yaml:"base_url"`   // default https://api.openai.com/v1
+	MaxTokens int    `yaml:"max_tokens"` // cap on each reply; 0 leaves it to the service
+}
+
+const OPENAI_BASE_URL = "https://api.openai.com/v1"
+
+// openAIPlugin resolves openai/<model> names for genkit
+type openAIPlugin struct {
+	cfg OpenAIConfig
+}
+
+func (p *openAIPlugin) Name() string { return PROVIDER_OPENAI }
+
+func (p *openAIPlugin) Init(ctx context.Context) []api.Action { return nil }
+
+func (p *openAIPlugin) ListActions(ctx context.Context) []api.ActionDesc { return nil }
+
+func (p *openAIPlugin) ResolveAction(atype api.ActionType, name string) api.Action {
+	switch atype {
+	case api.ActionTypeModel:
+		return providerModel(PROVIDER_OPENAI, name, func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+			return p.generate(ctx, name, req)
+		})
+	case api.ActionTypeEmbedder:
+		return providerEmbedder(PROVIDER_OPENAI, name, func(ctx context.Context, texts []string) ([][]float32, error) {
+			return p.embed(ctx, name, texts)
+		})
+	}
+	return nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	MaxTokens      int               `json:"max_tokens,omitempty"`
+	Temperature    float64           `json:"temperature,omitempty"`
+	TopP           float64           `json:"top_p,omitempty"`
+	Stop           []string          `json:"stop,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// generate sends one chat completion request
+func (p *openAIPlugin) generate(ctx context.Context, model string, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+	if p.cfg.APIKey == "" && p.cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai: no API key (set ai.openai.api_key or OPENAI_API_KEY)")
+	}
+
+	start := time.Now()
+	endpoint, header := p.endpoint()
+	var out openAIResponse
+	if err := postProvider(ctx, PROVIDER_OPENAI, endpoint+"/chat/completions", header, openAIChatRequest(model, p.cfg.MaxTokens, req), &out); err != nil {
+		return nil, err
+	}
+	return openAIChatResponse(PROVIDER_OPENAI, out, start)
+}
+
+// openAIChatRequest is a chat completion request for a genkit one, for
+// OpenAI and the services that share its API
+func openAIChatRequest(model string, maxTokens int, req *ai.ModelRequest) openAIRequest {
+	body := openAIRequest{Model: model}
+	for _, m := range req.Messages {
+		role := string(m.Role)
+		switch m.Role {
+		case ai.RoleModel:
+			role = "assistant"
+		case ai.RoleTool:
+			continue
+		}
+		body.Messages = append(body.Messages, openAIMessage{Role: role, Content: messageText(m)})
+	}
+	cfg := commonConfig(req)
+	body.MaxTokens, body.Temperature, body.TopP, body.Stop = cmp.Or(cfg.MaxOutputTokens, maxTokens), cfg.Temperature, cfg.TopP, cfg.StopSequences
+	if req.Output != nil && req.Output.Format == "json" {
+		// genkit has already put the schema in the prompt
+		body.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+	return body
+}
+
+// openAIChatResponse turns a chat completion into genkit's response
+func openAIChatResponse(provider string, out openAIResponse, start time.Time) (*ai.ModelResponse, error) {
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("%s: no choices in response", provider)
+	}
+
+	choice := out.Choices[0]
+	return &ai.ModelResponse{
+		Message:      ai.NewModelTextMessage(choice.Message.Content),
+		FinishReason: openAIFinishReason(choice.FinishReason),
+		LatencyMs:    float64(time.Since(start).Milliseconds()),
+		Usage: &ai.GenerationUsage{
+			InputTokens:  out.Usage.PromptTokens,
+			OutputTokens: out.Usage.CompletionTokens,
+			TotalTokens:  out.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// embed sends one /embeddings request
+func (p *openAIPlugin) embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	if p.cfg.APIKey == "" && p.cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai: no API key (set ai.openai.api_key or OPENAI_API_KEY)")
+	}
+	endpoint, header := p.endpoint()
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	body := map[string]any{"model": model, "input": texts}
+	if err := postProvider(ctx, PROVIDER_OPENAI, endpoint+"/embeddings", header, body, &out); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(out.Data))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// endpoint is the API's base URL and the headers every request carries
+func (p *openAIPlugin) endpoint() (string, http.Header) {
+	header := http.Header{}
+	if p.cfg.APIKey != "" {
+		header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+	return strings.TrimSuffix(cmp.Or(p.cfg.BaseURL, OPENAI_BASE_URL), "/"), header
+}
+
+func openAIFinishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "stop":
+		return ai.FinishReasonStop
+	case "length":
+		return ai.FinishReasonLength
+	case "content_filter":
+		return ai.FinishReasonBlocked
+	case "":
+		return ai.FinishReasonUnknown
+	default:
+		return ai.FinishReasonOther
+	}
+}
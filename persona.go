@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+// A reader persona, set globally or per channel, gets every summary a
+// "Why You Should Care" section written for that audience, e.g. "backend Go
+// engineers at a fintech". Prompt files get the instruction appended too.
+
+const PERSONA_INSTRUCTION = `
+
+Our readers are %s. After the other sections, before any rating, add:
+
+**Why You Should Care:** 1-2 sentences on what this means for these readers in particular: what they might change, try or watch out for. If it barely concerns them, say so briefly.`
+
+const PERSONA_FIELD_INSTRUCTION = `
+
+Our readers are %s. Also fill in:
+- why_care: 1-2 sentences on what this means for these readers in particular: what they might change, try or watch out for; if it barely concerns them, say so briefly`
+
+// personaFor picks the persona of the channel a feed posts to, falling back
+// to the global one; empty adds no section
+func (c *Config) personaFor(feed FeedConfig) string {
+	if ch, ok := c.Channels[feed.Channel]; ok && feed.Channel != "" && ch.Persona != "" {
+		return ch.Persona
+	}
+	return c.Persona
+}
+
+// withPersona asks for the persona's section, as a field for structured summaries
+func withPersona(prompt, persona string, structured bool) string {
+	if persona == "" {
+		return prompt
+	}
+	if structured {
+		return prompt + fmt.Sprintf(PERSONA_FIELD_INSTRUCTION, persona)
+	}
+	return prompt + fmt.Sprintf(PERSONA_INSTRUCTION, persona)
+}
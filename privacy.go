@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type RetentionConfig struct {
+	// Days to keep extracted article text in the archive. Summaries are kept
+	// regardless; 0 keeps full text forever.
+	ContentDays int `yaml:"content_days"`
+	// Days to remember sent items in the dedup state; 0 remembers them forever.
+	// An item still in its feed after this long would be posted again.
+	StateDays int `yaml:"state_days"`
+}
+
+// linkMatcher decides whether a stored link falls under a purge request
+type linkMatcher func(link string) bool
+
+func matchDomain(domain string) linkMatcher {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+	return func(link string) bool {
+		u, err := url.Parse(link)
+		if err != nil {
+			return false
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+}
+
+func matchURL(target string) linkMatcher {
+	key := feedKey(target)
+	return func(link string) bool {
+		return feedKey(link) == key
+	}
+}
+
+// applyRetention drops extracted article text older than the retention window
+func applyRetention(archive *Archive, cfg RetentionConfig) (int, error) {
+	if cfg.ContentDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -cfg.ContentDays)
+	stripped := 0
+	_, err := archive.Rewrite(func(item *ArchivedItem) bool {
+		if item.Content != "" && item.SentAt.Before(cutoff) {
+			item.Content = ""
+			stripped++
+		}
+		return true
+	})
+	return stripped, err
+}
+
+// rewriteJSONL drops lines of a JSONL file whose "link" field matches
+func rewriteJSONL(path string, match linkMatcher) (int, error) {
+	in, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	w := bufio.NewWriter(out)
+	for scanner.Scan() {
+		var rec struct {
+			Link string `json:"link"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil && rec.Link != "" && match(rec.Link) {
+			removed++
+			continue
+		}
+		w.Write(scanner.Bytes())
+		w.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return removed, os.Rename(tmp, path)
+}
+
+// runPurge implements `rss purge -domain example.com | -url https://... | -expired`
+func runPurge(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	domain := fs.String("domain", "", "remove everything stored for this domain (and its subdomains)")
+	link := fs.String("url", "", "remove everything stored for this article URL")
+	expired := fs.Bool("expired", false, "apply retention.content_days now")
+	fs.Parse(args)
+
+	archive := openArchive(cfg.Archive)
+
+	if *expired {
+		stripped, err := applyRetention(archive, cfg.Retention)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return 1
+		}
+		fmt.Printf("🧹 Dropped full text from %d archived item(s)\n", stripped)
+		return 0
+	}
+
+	var match linkMatcher
+	switch {
+	case *domain != "" && *link == "":
+		match = matchDomain(*domain)
+	case *link != "" && *domain == "":
+		match = matchURL(*link)
+	default:
+		fmt.Println("Usage: rss purge -domain example.com | -url https://example.com/post | -expired")
+		return 2
+	}
+
+	removed, err := archive.Rewrite(func(item *ArchivedItem) bool { return !match(item.Link) })
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	fmt.Printf("🗑️  Archive: removed %d item(s) (content, summaries, tags)\n", removed)
+
+	if cfg.DecisionLog != "" {
+		n, err := rewriteJSONL(cfg.DecisionLog, match)
+		if err != nil {
+			fmt.Printf("⚠️  Decision log: %v\n", err)
+			return 1
+		}
+		fmt.Printf("🗑️  Decision log: removed %d record(s)\n", n)
+	}
+
+	if cfg.Site.Dir != "" && removed > 0 {
+		if err := renderSite(archive, cfg.Site); err != nil {
+			fmt.Printf("⚠️  Site rebuild failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("🌍 Site rebuilt in %s\n", cfg.Site.Dir)
+	}
+
+	// Dedup state holds only URL hashes and must stay, or the items would be reposted;
+	// the audit log is append-only by design and is not rewritten.
+	fmt.Println("ℹ️  Dedup state (URL hashes) and the audit log were left untouched")
+	return 0
+}
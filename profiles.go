@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const PROFILE_ENV = "RSS_PROFILE"
+
+// profileName picks the profile from the flag value or RSS_PROFILE
+func profileName(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(PROFILE_ENV)
+}
+
+// applyProfile removes the profiles section from a parsed config document and,
+// when name is set, deep-merges that profile over the top-level settings
+func applyProfile(root *yaml.Node, name string) error {
+	top := root.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var profiles *yaml.Node
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value == "profiles" {
+			profiles = top.Content[i+1]
+			top.Content = append(top.Content[:i], top.Content[i+2:]...)
+			break
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	var available []string
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if profiles.Content[i].Value == name {
+				mergeNode(top, profiles.Content[i+1])
+				return nil
+			}
+			available = append(available, profiles.Content[i].Value)
+		}
+	}
+	sort.Strings(available)
+	if len(available) == 0 {
+		return fmt.Errorf("profile %q not found: the config defines no profiles", name)
+	}
+	return fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(available, ", "))
+}
+
+// mergeNode overlays src onto dst: mappings merge key by key, anything else
+// (scalars, lists) replaces the base value outright
+func mergeNode(dst, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		merged := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				mergeNode(dst.Content[j+1], value)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress is the live view of a run on an interactive terminal: one line per
+// finished feed and a footer with totals and an ETA, instead of every log
+// line. Warnings still come through. A nil Progress does nothing, which is
+// what non-TTY runs (cron, containers, -plain) get.
+type Progress struct {
+	term *os.File // the real stdout while it is captured
+
+	mu        sync.Mutex
+	total     int
+	done      int
+	feed      string // feed being processed
+	item      string // item being summarized and sent
+	start     time.Time
+	feedStart RunMetrics // counters when the current feed started
+	now       RunMetrics // latest counters
+	drawn     int        // footer lines on screen
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newProgress returns a Progress when stdout is a terminal, nil otherwise
+func newProgress() *Progress {
+	fi, err := os.Stdout.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 || os.Getenv("TERM") == "dumb" {
+		return nil
+	}
+	return &Progress{}
+}
+
+// Start captures stdout for a run over total feeds
+func (p *Progress) Start(total int) {
+	if p == nil {
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	p.term, os.Stdout = os.Stdout, w
+	p.total, p.done, p.start = total, 0, time.Now()
+	p.stop = make(chan struct{})
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		p.pump(r)
+	}()
+	go func() {
+		defer p.wg.Done()
+		tick := time.NewTicker(500 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				p.mu.Lock()
+				p.redraw()
+				p.mu.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Feed reports that the previous feed is finished and feedURL is next
+func (p *Progress) Feed(feedURL string, metrics *RunMetrics) {
+	if p == nil || p.term == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finishFeed(*metrics)
+	p.feed, p.item = feedURL, ""
+	p.feedStart, p.now = *metrics, *metrics
+	p.redraw()
+}
+
+// Item reports the item being processed, "" once it is done
+func (p *Progress) Item(title string, metrics *RunMetrics) {
+	if p == nil || p.term == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.item, p.now = title, *metrics
+	p.redraw()
+}
+
+// Finish prints the last feed's line, clears the footer and restores stdout
+func (p *Progress) Finish(metrics *RunMetrics) {
+	if p == nil || p.term == nil {
+		return
+	}
+	p.mu.Lock()
+	p.finishFeed(*metrics)
+	p.feed = ""
+	p.mu.Unlock()
+
+	w := os.Stdout
+	os.Stdout = p.term
+	w.Close()
+	close(p.stop)
+	p.wg.Wait()
+
+	p.mu.Lock()
+	p.clear()
+	p.term = nil
+	p.mu.Unlock()
+}
+
+// pump passes captured log lines through: warnings are kept, the rest is
+// summarized by the feed lines
+func (p *Progress) pump(r io.ReadCloser) {
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, "⚠️") {
+			continue
+		}
+		p.mu.Lock()
+		p.println(strings.TrimSpace(line))
+		p.mu.Unlock()
+	}
+}
+
+// finishFeed prints the result line for the current feed; mu must be held
+func (p *Progress) finishFeed(m RunMetrics) {
+	if p.feed == "" {
+		return
+	}
+	p.done++
+	s := p.feedStart
+	var line string
+	switch {
+	case m.FeedsSkipped > s.FeedsSkipped:
+		line = "⏸️  " + shortURL(p.feed) + " — backed off"
+	case m.FeedsDeferred > s.FeedsDeferred:
+		line = "⏳ " + shortURL(p.feed) + " — throttled, retrying later"
+		p.total++
+	case m.FeedErrors > s.FeedErrors:
+		line = "❌ " + shortURL(p.feed) + " — failed"
+	case m.FeedsUnchanged > s.FeedsUnchanged:
+		line = "💤 " + shortURL(p.feed) + " — unchanged"
+	case m.FeedsFetched == s.FeedsFetched:
+		line = "⏭️  " + shortURL(p.feed) + " — not fetched"
+	default:
+		line = fmt.Sprintf("✅ %s — %d new, %d sent", shortURL(p.feed), m.ItemsNew-s.ItemsNew, m.PostsSent-s.PostsSent)
+		if failed := m.SendFailures - s.SendFailures; failed > 0 {
+			line += fmt.Sprintf(", %d failed", failed)
+		}
+	}
+	p.now = m
+	p.println(line)
+}
+
+// println writes a permanent line above the footer; mu must be held
+func (p *Progress) println(line string) {
+	p.clear()
+	fmt.Fprintln(p.term, line)
+	p.draw()
+}
+
+func (p *Progress) redraw() {
+	if p.term == nil {
+		return
+	}
+	p.clear()
+	p.draw()
+}
+
+// clear erases the footer, leaving the cursor where it started
+func (p *Progress) clear() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.term, "\x1b[%dF\x1b[J", p.drawn)
+		p.drawn = 0
+	}
+}
+
+func (p *Progress) draw() {
+	if p.feed == "" {
+		return
+	}
+	m := p.now
+	elapsed := time.Since(p.start)
+	eta := "…"
+	if p.done > 0 {
+		eta = (elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)).Round(time.Second).String()
+	}
+
+	lines := []string{
+		fmt.Sprintf("📡 Feeds %d/%d · ✉️  Sent %d · 🆕 New %d · ⚠️  Errors %d · ⏱  %s, ETA %s",
+			p.done, p.total, m.PostsSent, m.ItemsNew, m.FeedErrors+m.SendFailures,
+			elapsed.Round(time.Second), eta),
+		"   " + shortURL(p.feed),
+	}
+	if p.item != "" {
+		lines = append(lines, "   📄 "+truncate(p.item, 70, "…"))
+	}
+	for _, l := range lines {
+		fmt.Fprintf(p.term, "\x1b[2K%s\n", l)
+	}
+	p.drawn = len(lines)
+}
+
+// shortURL is host and path, enough to tell feeds apart
+func shortURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	return strings.TrimPrefix(u.Host, "www.") + strings.TrimRight(u.Path, "/")
+}
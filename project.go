@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ProjectConfig turns the bot into one project's changelog channel: its blog,
+// GitHub releases and docs changes feed a single channel with release-centric
+// posts instead of generic article summaries
+type ProjectConfig struct {
+	Name    string   `yaml:"name"`
+	Blog    string   `yaml:"blog"`    // blog feed URL
+	GitHub  string   `yaml:"github"`  // owner/repo; its releases feed is watched
+	Docs    []string `yaml:"docs"`    // docs change feeds, e.g. https://github.com/owner/repo/commits/main/docs.atom
+	Channel string   `yaml:"channel"` // key into channels; default telegram.channel_id
+}
+
+// Feed kinds: how items of a feed are summarized and posted
+const (
+	FEED_ARTICLE = ""        // one summarized post per item
+	FEED_RELEASE = "release" // one post per release, release-notes template
+	FEED_DOCS    = "docs"    // all new changes of a run grouped into one post
+)
+
+const RELEASE_PROMPT = `Summarize these release notes for users of the project, in plain text with simple formatting.
+
+Format rules:
+- Use **bold** for section headers
+- Use bullet points (•) for lists
+- Leave out empty sections
+- NO HTML tags
+
+Structure:
+**Highlights:** 1-2 sentences
+
+**New:**
+- Feature 1
+- Feature 2
+
+**Breaking Changes:**
+- Change and what users must do
+
+**Fixes:** the notable ones, briefly
+
+If you can't summarize, output: AI FAILED
+
+Release: %s
+
+Notes:
+%s`
+
+// feeds lists the feeds a project is watched through
+func (p ProjectConfig) feeds() []FeedConfig {
+	var feeds []FeedConfig
+	if p.Blog != "" {
+		feeds = append(feeds, FeedConfig{URL: p.Blog, Category: "blog", Channel: p.Channel})
+	}
+	if p.GitHub != "" {
+		feeds = append(feeds, FeedConfig{
+			URL:      "https://github.com/" + strings.Trim(p.GitHub, "/") + "/releases.atom",
+			Category: "release",
+			Channel:  p.Channel,
+			Kind:     FEED_RELEASE,
+		})
+	}
+	for _, docs := range p.Docs {
+		feeds = append(feeds, FeedConfig{URL: docs, Category: "docs", Channel: p.Channel, Kind: FEED_DOCS})
+	}
+	return feeds
+}
+
+// releaseNotes is the text of release notes embedded in the feed, if any
+func releaseNotes(item Item) string {
+	return truncate(htmlText(item.Description), 6000, "...")
+}
+
+// postHeading is the first line of a post, with the version up front for releases
+func (b *Bot) postHeading(feed FeedConfig, link, title string) string {
+	if feed.Kind == FEED_RELEASE {
+		name := b.cfg.Project.Name
+		if name != "" {
+			name += " "
+		}
+		return fmt.Sprintf("🚀 <b><a href=\"%s\">%s%s</a></b>", link, html.EscapeString(name), title)
+	}
+	return fmt.Sprintf("<b><a href=\"%s\">%s</a></b>", link, title)
+}
+
+// docsDigest groups a run's docs changes into one message, listing as many as fit
+func (b *Bot) docsDigest(items []Item) string {
+	name := b.cfg.Project.Name
+	if name != "" {
+		name += " "
+	}
+	build := func(n int) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📚 <b>%sdocs updated</b>\n", html.EscapeString(name))
+		for _, item := range items[:n] {
+			fmt.Fprintf(&sb, "\n• <a href=\"%s\">%s</a>", html.EscapeString(item.Link), html.EscapeString(item.Title))
+		}
+		if n < len(items) {
+			fmt.Fprintf(&sb, "\n…and %d more", len(items)-n)
+		}
+		return sb.String()
+	}
+
+	n := len(items)
+	msg := build(n)
+	for n > 0 && telegramTextLen(msg) > TELEGRAM_MESSAGE_LIMIT {
+		n--
+		msg = build(n)
+	}
+	return msg
+}
+
+// postDocs sends one digest of a docs feed's unseen changes, reporting whether it was sent
+func (b *Bot) postDocs(feed FeedConfig, chatID string, items []Item, state StateStore, metrics *RunMetrics) bool {
+	var fresh []Item
+	for i := len(items) - 1; i >= 0; i-- {
+		seen, err := seenLink(state, items[i].Link)
+		if err != nil {
+			fmt.Printf("⚠️  State lookup failed, skipping item: %v\n", err)
+			continue
+		}
+		if seen {
+			continue
+		}
+		// The digest goes out right after, so claiming here doesn't outlive it
+		claimed, err := claimItem(state, itemID(items[i].Link))
+		if err != nil {
+			fmt.Printf("⚠️  Claiming item failed, skipping it: %v\n", err)
+			continue
+		}
+		if claimed {
+			fresh = append(fresh, items[i])
+		}
+	}
+	if len(fresh) == 0 {
+		return false
+	}
+	metrics.ItemsNew += len(fresh)
+
+	ctx := context.Background()
+	for _, item := range fresh {
+		ctx = withAuditItem(ctx, itemID(item.Link), item.Link)
+	}
+	messageID, err := sendToTelegram(ctx, b.cfg.Telegram.Token, chatID, b.docsDigest(fresh))
+	if err != nil {
+		for _, item := range fresh {
+			releaseItem(state, itemID(item.Link))
+		}
+		metrics.SendFailures++
+		metrics.PerFeedFailure[feed.URL]++
+		fmt.Printf("   ⚠️  Docs digest failed: %v\n", err)
+		return false
+	}
+
+	for _, item := range fresh {
+		id := itemID(item.Link)
+		entry := StateEntry{Title: item.Title, Link: item.Link, Feed: feed.URL, ChatID: chatID, MessageID: messageID}
+		if err := state.Mark(id, entry); err != nil {
+			fmt.Printf("   ⚠️  Could not record item as sent: %v\n", err)
+		}
+	}
+	metrics.PostsSent++
+	metrics.PerFeedSent[feed.URL]++
+	fmt.Printf("   ✉️  Sent docs digest: %d change(s)\n", len(fresh))
+
+	pacing.pause(b.cfg.postDelayFor(feed))
+	return true
+}
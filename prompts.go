@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// PromptsConfig lets feed categories reshape the summary prompt: sections are
+// reusable blocks, and each category adds some of them and removes sections
+// of the tone's own structure
+type PromptsConfig struct {
+	Sections   map[string]string           `yaml:"sections"`   // name -> block, added at the end of the structure
+	Categories map[string]CategorySections `yaml:"categories"` // feed category -> changes; replaces the built-in entry
+
+	// A/B test: items of feeds without a prompt file of their own are split
+	// evenly between these prompts, and their posts record the variant used
+	Variants []PromptVariant `yaml:"variants"`
+}
+
+// PromptVariant is one arm of a prompt A/B test
+type PromptVariant struct {
+	Name string `yaml:"name"`
+	File string `yaml:"file"` // prompt template file, see PromptData; empty keeps the usual prompt
+}
+
+type CategorySections struct {
+	Add    []string `yaml:"add"`    // names from prompts.sections or the built-in sections
+	Remove []string `yaml:"remove"` // headers of the tone's sections, e.g. "Rating" or "My Thoughts"
+
+	// Prompt template file for the category's feeds, replacing the tone's
+	// prompt and the changes above; feeds may set their own with prompt_file
+	PromptFile string `yaml:"prompt_file"`
+}
+
+// Built-in sections, usable from any category
+var PROMPT_SECTIONS = map[string]string{
+	"mitigation": `**Mitigation Steps:**
+- What affected users or admins should do now (patch, config change, workaround)`,
+	"upgrade":     `**Upgrade Notes:** what to check or change before upgrading, if anything`,
+	"methodology": `**Methodology:** 1-2 sentences on how the results were obtained (data, setup, sample size) and their limits`,
+}
+
+// Built-in category changes, used unless prompts.categories has the category
+var CATEGORY_SECTIONS = map[string]CategorySections{
+	"security": {Add: []string{"mitigation"}},
+	"release":  {Add: []string{"upgrade"}},
+	"research": {Add: []string{"methodology"}},
+}
+
+// section looks a section up in the config, then the built-ins
+func (p PromptsConfig) section(name string) (string, bool) {
+	if text, ok := p.Sections[name]; ok {
+		return text, true
+	}
+	text, ok := PROMPT_SECTIONS[name]
+	return text, ok
+}
+
+func (p PromptsConfig) category(name string) (CategorySections, bool) {
+	if cat, ok := p.Categories[name]; ok {
+		return cat, true
+	}
+	cat, ok := CATEGORY_SECTIONS[name]
+	return cat, ok
+}
+
+// withSections applies a category's section changes to a prompt. Sections in
+// the Structure part are paragraphs starting with "**Header:**"; added ones go
+// before the "If you can't summarize" line.
+func (p PromptsConfig) withSections(prompt, category string) string {
+	cat, ok := p.category(category)
+	if !ok || category == "" {
+		return prompt
+	}
+
+	paragraphs := strings.Split(prompt, "\n\n")
+	kept := paragraphs[:0]
+	for _, para := range paragraphs {
+		if !removedSection(para, cat.Remove) {
+			kept = append(kept, para)
+		}
+	}
+
+	var added []string
+	for _, name := range cat.Add {
+		if text, ok := p.section(name); ok {
+			// The prompt is a format string for the title and content
+			added = append(added, strings.ReplaceAll(strings.TrimSpace(text), "%", "%%"))
+		}
+	}
+	at := len(kept)
+	for i, para := range kept {
+		if strings.HasPrefix(para, "If you can't summarize") {
+			at = i
+			break
+		}
+	}
+	kept = append(kept[:at], append(added, kept[at:]...)...)
+	return strings.Join(kept, "\n\n")
+}
+
+func removedSection(para string, headers []string) bool {
+	for _, h := range headers {
+		if strings.HasPrefix(para, "**"+strings.TrimSuffix(h, ":")+":**") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPrompts reports sections that categories refer to but nobody defines,
+// and prompt files that don't load
+func checkPrompts(add func(path, format string, args ...any), p PromptsConfig) {
+	for name, cat := range p.Categories {
+		for i, section := range cat.Add {
+			if _, ok := p.section(section); !ok {
+				add(fmt.Sprintf("prompts.categories.%s.add[%d]", name, i), "unknown section %q; define it under prompts.sections", section)
+			}
+		}
+		if cat.PromptFile != "" {
+			if _, err := loadPromptFile(cat.PromptFile); err != nil {
+				add("prompts.categories."+name+".prompt_file", "%v", err)
+			}
+		}
+	}
+	if len(p.Variants) == 1 {
+		add("prompts.variants", "an A/B test needs at least two variants")
+	}
+	names := map[string]bool{}
+	for i, v := range p.Variants {
+		path := fmt.Sprintf("prompts.variants[%d]", i)
+		switch {
+		case v.Name == "":
+			add(path+".name", "required")
+		case names[v.Name]:
+			add(path+".name", "duplicate variant %q", v.Name)
+		}
+		names[v.Name] = true
+		if v.File != "" {
+			if _, err := loadPromptFile(v.File); err != nil {
+				add(path+".file", "%v", err)
+			}
+		}
+	}
+}
+
+// variantFor picks the A/B variant for an item, nil when no test applies to
+// its feed. The pick follows the item id, so a resend keeps its variant.
+func (p PromptsConfig) variantFor(feed FeedConfig, id string) *PromptVariant {
+	if len(p.Variants) == 0 || feed.Kind == FEED_RELEASE || p.promptFile(feed) != "" {
+		return nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &p.Variants[h.Sum32()%uint32(len(p.Variants))]
+}
+
+// PromptData fills a prompt file, a Go text/template such as
+//
+//	Summarize this {{.Category}} story from {{.Site}} for a technical audience.
+//	Title: {{.Title}}
+//
+//	{{.Content}}
+type PromptData struct {
+	Title    string
+	Content  string // the article text, or the feed's when the page couldn't be read
+	Feed     string // feed URL
+	Site     string // the feed's host, e.g. go.dev
+	Category string
+}
+
+// promptFile is the prompt file for a feed: its own, or its category's
+func (p PromptsConfig) promptFile(feed FeedConfig) string {
+	if feed.PromptFile != "" {
+		return feed.PromptFile
+	}
+	if feed.Category == "" {
+		return ""
+	}
+	cat, _ := p.category(feed.Category)
+	return cat.PromptFile
+}
+
+// loadPromptFile reads and compiles a prompt file, and tries it on empty
+// data so that misspelled fields fail at startup rather than per item
+func loadPromptFile(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("prompt file: %w", err)
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("prompt file: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, PromptData{}); err != nil {
+		return nil, fmt.Errorf("prompt file: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderPrompt fills a prompt file for an item
+func renderPrompt(tmpl *template.Template, feed FeedConfig, title, content string) (string, error) {
+	data := PromptData{Title: title, Content: content, Feed: feed.URL, Category: feed.Category}
+	if u, err := url.Parse(feed.URL); err == nil {
+		data.Site = u.Hostname()
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("prompt file: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/plugins/googlegenai"
+)
+
+// Models are named provider/model, and the prefix picks the service that
+// answers: googleai and vertexai are Gemini through genkit's own plugin (see
+// vertex.go), the others are
+// plugins of ours that speak the provider's HTTP API. Everything that asks a
+// model (summaries, events, jobs, translation, quiz, ask, eval) goes through
+// genkit.Generate, so it works with any provider.
+const (
+	PROVIDER_GOOGLEAI  = "googleai"
+	PROVIDER_OPENAI    = "openai"
+	PROVIDER_ANTHROPIC = "anthropic"
+	PROVIDER_OLLAMA    = "ollama"
+)
+
+// modelProvider is the provider prefix of a model name
+func modelProvider(model string) string {
+	provider, _, _ := strings.Cut(model, "/")
+	return provider
+}
+
+// plugins are the genkit plugins for every configured provider. Gemini's
+// refuses to start without a key, so it is left out when there isn't one,
+// and so is Vertex AI's without a project, location and credentials.
+func (c AIConfig) plugins() []api.Plugin {
+	var plugins []api.Plugin
+	if c.APIKey != "" {
+		plugins = append(plugins, &googlegenai.GoogleAI{APIKey: c.APIKey})
+	}
+	if c.Vertex.enabled() {
+		if err := c.Vertex.checkCredentials(); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		} else {
+			plugins = append(plugins, &googlegenai.VertexAI{ProjectID: c.Vertex.Project, Location: c.Vertex.Location})
+		}
+	}
+	plugins = append(plugins, &openAIPlugin{cfg: c.OpenAI}, &azurePlugin{cfg: c.Azure}, &anthropicPlugin{cfg: c.Anthropic}, &ollamaPlugin{cfg: c.Ollama})
+	return plugins
+}
+
+// checkModel reports a model whose provider is unknown or has no credentials
+func (c AIConfig) checkModel(model string) error {
+	switch modelProvider(model) {
+	case PROVIDER_GOOGLEAI:
+		if c.APIKey == "" {
+			return fmt.Errorf("%s needs ai.api_key or GEMINI_API_TOKEN", model)
+		}
+	case PROVIDER_VERTEXAI:
+		if !c.Vertex.enabled() {
+			return fmt.Errorf("%s needs ai.vertex.project and ai.vertex.location", model)
+		}
+		return c.Vertex.checkCredentials()
+	case PROVIDER_OPENAI:
+		if c.OpenAI.APIKey == "" && c.OpenAI.BaseURL == "" {
+			return fmt.Errorf("%s needs ai.openai.api_key or OPENAI_API_KEY", model)
+		}
+	case PROVIDER_AZURE:
+		if c.Azure.Endpoint == "" || c.Azure.APIKey == "" {
+			return fmt.Errorf("%s needs ai.azure.endpoint and ai.azure.api_key or AZURE_OPENAI_API_KEY", model)
+		}
+	case PROVIDER_ANTHROPIC:
+		if c.Anthropic.APIKey == "" {
+			return fmt.Errorf("%s needs ai.anthropic.api_key or ANTHROPIC_API_KEY", model)
+		}
+	case PROVIDER_OLLAMA:
+		// a local server, no credentials
+	default:
+		return fmt.Errorf("unknown provider in model %q (want googleai/..., vertexai/..., openai/..., azure/..., anthropic/... or ollama/...)", model)
+	}
+	return nil
+}
+
+// providerModel wraps a provider's generate function as a genkit model.
+// Providers answer in one piece, so a streaming caller gets a single chunk.
+func providerModel(provider, name string, generate func(context.Context, *ai.ModelRequest) (*ai.ModelResponse, error)) api.Action {
+	model := ai.NewModel(provider+"/"+name, &ai.ModelOptions{
+		Label: provider + " - " + name,
+		Supports: &ai.ModelSupports{
+			Multiturn:   true,
+			SystemRole:  true,
+			Constrained: ai.ConstrainedSupportNone, // genkit puts the schema in the prompt instead
+		},
+	}, func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		resp, err := generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Request = req
+		if cb != nil {
+			if err := cb(ctx, &ai.ModelResponseChunk{Role: ai.RoleModel, Content: resp.Message.Content}); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	})
+	return model.(api.Action)
+}
+
+// providerEmbedder wraps a provider's embed function, which gets the texts of
+// the documents, as a genkit embedder
+func providerEmbedder(provider, name string, embed func(context.Context, []string) ([][]float32, error)) api.Action {
+	embedder := ai.NewEmbedder(provider+"/"+name, &ai.EmbedderOptions{
+		Label:    provider + " - " + name,
+		Supports: &ai.EmbedderSupports{Input: []string{"text"}},
+	}, func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		texts := make([]string, len(req.Input))
+		for i, doc := range req.Input {
+			var parts []string
+			for _, part := range doc.Content {
+				if part.IsText() {
+					parts = append(parts, part.Text)
+				}
+			}
+			texts[i] = strings.Join(parts, "\n\n")
+		}
+		vectors, err := embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("%s: %d embeddings for %d texts", provider, len(vectors), len(texts))
+		}
+		resp := &ai.EmbedResponse{}
+		for _, v := range vectors {
+			resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: v})
+		}
+		return resp, nil
+	})
+	return embedder.(api.Action)
+}
+
+// textParts are a message's text parts; other parts (media, tool calls)
+// aren't sent to our providers
+func textParts(m *ai.Message) []string {
+	var texts []string
+	for _, part := range m.Content {
+		if part.IsText() {
+			texts = append(texts, part.Text)
+		}
+	}
+	return texts
+}
+
+// messageText joins a message's text parts
+func messageText(m *ai.Message) string {
+	return strings.Join(textParts(m), "\n\n")
+}
+
+// commonConfig is the request's generation config, when one was given
+func commonConfig(req *ai.ModelRequest) ai.GenerationCommonConfig {
+	switch c := req.Config.(type) {
+	case *ai.GenerationCommonConfig:
+		if c != nil {
+			return *c
+		}
+	case ai.GenerationCommonConfig:
+		return c
+	}
+	return ai.GenerationCommonConfig{}
+}
+
+// postProvider sends a JSON request to a provider's API and decodes the
+// reply into out. Failures come as {"error": {"message": ...}}, or from
+// Ollama as {"error": "..."}.
+func postProvider(ctx context.Context, provider, endpoint string, header http.Header, body, out any) error {
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(withPurpose(ctx, "ai"), http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpDo(req, timeouts.AI)
+	if err != nil {
+		return fmt.Errorf("%s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: read failed: %w", provider, err)
+	}
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal(rb, &failure) == nil && failure.Error != nil {
+			var message string
+			var detail struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(failure.Error, &message) != nil && json.Unmarshal(failure.Error, &detail) == nil {
+				message = detail.Message
+			}
+			if message != "" {
+				return newProviderError(provider, resp, message)
+			}
+		}
+		return newProviderError(provider, resp, string(rb))
+	}
+	if err := json.Unmarshal(rb, out); err != nil {
+		return fmt.Errorf("%s: parse failed: %w", provider, err)
+	}
+	return nil
+}
+
+// providerError is an error status from a provider's API, kept apart so
+// transient ones can be retried, see airetry.go
+type providerError struct {
+	Provider   string
+	Status     int
+	Message    string
+	RetryAfter time.Duration // from the Retry-After header, 0 if none
+}
+
+func newProviderError(provider string, resp *http.Response, message string) error {
+	e := &providerError{Provider: provider, Status: resp.StatusCode, Message: message}
+	e.RetryAfter, _ = retryAfter(resp)
+	return e
+}
+
+func (e *providerError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.Provider, e.Status, e.Message)
+}
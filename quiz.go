@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+type QuizConfig struct {
+	Schedule  string `yaml:"schedule"`  // cron expression for quizzes under `rss serve`, e.g. "0 18 * * 5"
+	Questions int    `yaml:"questions"` // default 5
+	Days      int    `yaml:"days"`      // how far back to look for articles, default 7
+	Channel   string `yaml:"channel"`   // key into channels; default telegram.channel_id
+}
+
+const QUIZ_PROMPT = `Write a short quiz for readers of a tech news channel, based on the articles below.
+
+Rules:
+- Exactly %d questions, each about one article, preferably all different articles
+- 4 answer options per question, exactly one correct, all plausible
+- Question up to 250 characters, each option up to 80 characters
+- The explanation (up to 120 characters) says why the answer is right
+- Ask about the substance of the article, not trivia such as dates or author names
+- Use the id of the article each question is about
+
+Articles:
+%s`
+
+type quizQuestion struct {
+	ArticleID   string   `json:"article_id"`
+	Question    string   `json:"question"`
+	Options     []string `json:"options"`
+	Correct     int      `json:"correct_index"`
+	Explanation string   `json:"explanation"`
+}
+
+type quizOutput struct {
+	Questions []quizQuestion `json:"questions"`
+}
+
+// postLink points at the Telegram message for an item, or the article when the chat has no public link
+func postLink(item *ArchivedItem) string {
+	if item.MessageID == 0 {
+		return item.Link
+	}
+	if name, ok := strings.CutPrefix(item.ChatID, "@"); ok {
+		return fmt.Sprintf("https://t.me/%s/%d", name, item.MessageID)
+	}
+	if id, ok := strings.CutPrefix(item.ChatID, "-100"); ok {
+		return fmt.Sprintf("https://t.me/c/%s/%d", id, item.MessageID)
+	}
+	return item.Link
+}
+
+// validQuizQuestion checks the limits Telegram puts on quiz polls
+func validQuizQuestion(q quizQuestion) bool {
+	// 300 minus room for the "1/5. " prefix
+	if q.Question == "" || graphemeCount(q.Question) > 290 {
+		return false
+	}
+	if len(q.Options) < 2 || len(q.Options) > 10 || q.Correct < 0 || q.Correct >= len(q.Options) {
+		return false
+	}
+	for _, o := range q.Options {
+		if o == "" || graphemeCount(o) > 100 {
+			return false
+		}
+	}
+	return true
+}
+
+// PostQuiz generates a quiz from recently posted items and sends it as quiz polls
+func (b *Bot) PostQuiz(ctx context.Context) error {
+	cfg := b.cfg.Quiz
+	if cfg.Questions <= 0 {
+		cfg.Questions = 5
+	}
+	if cfg.Days <= 0 {
+		cfg.Days = 7
+	}
+	chatID := b.cfg.chatFor(FeedConfig{Channel: cfg.Channel})
+
+	all, err := b.archive.Recent(0, "")
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().AddDate(0, 0, -cfg.Days)
+	items := map[string]*ArchivedItem{}
+	var articles strings.Builder
+	for i := range all {
+		item := &all[i]
+		if item.SentAt.Before(cutoff) || item.Summary == "" {
+			continue
+		}
+		items[item.ID] = item
+		fmt.Fprintf(&articles, "\nid: %s\ntitle: %s\nsummary: %s\n", item.ID, item.Title, truncate(item.Summary, 1500, "…"))
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no summarized posts in the last %d days", cfg.Days)
+	}
+
+	fmt.Printf("🧠 Generating a %d-question quiz from %d post(s)\n", cfg.Questions, len(items))
+	aiCtx, cancel := aiContext(ctx)
+	defer cancel()
+	out, _, err := genkit.GenerateData[quizOutput](aiCtx, b.g,
+		ai.WithPrompt(fmt.Sprintf(QUIZ_PROMPT, cfg.Questions, articles.String())),
+		ai.WithModelName(b.cfg.AI.Model),
+		withFallback(b.g, b.cfg.AI),
+	)
+	if err != nil {
+		return fmt.Errorf("quiz generation failed: %w", err)
+	}
+
+	var questions []quizQuestion
+	for _, q := range out.Questions {
+		if _, ok := items[q.ArticleID]; ok && validQuizQuestion(q) && len(questions) < cfg.Questions {
+			questions = append(questions, q)
+		}
+	}
+	if len(questions) == 0 {
+		return fmt.Errorf("quiz generation returned no usable questions")
+	}
+
+	token := b.cfg.Telegram.Token
+	intro := fmt.Sprintf("🧠 <b>Weekly quiz</b>: %d questions about what we posted this week. Each answer links back to its post.", len(questions))
+	if _, err := sendToTelegram(ctx, token, chatID, intro); err != nil {
+		return fmt.Errorf("quiz intro failed: %w", err)
+	}
+
+	for i, q := range questions {
+		item := items[q.ArticleID]
+		// Explanations are capped at 200 characters, entities included
+		explanation := html.EscapeString(truncate(q.Explanation, 150, "…")) +
+			fmt.Sprintf(` <a href="%s">Post</a>`, html.EscapeString(postLink(item)))
+
+		err := telegramCall(ctx, token, "sendPoll", map[string]any{
+			"chat_id":                chatID,
+			"question":               fmt.Sprintf("%d/%d. %s", i+1, len(questions), q.Question),
+			"options":                pollOptions(q.Options),
+			"type":                   "quiz",
+			"correct_option_id":      q.Correct,
+			"explanation":            explanation,
+			"explanation_parse_mode": "HTML",
+			"is_anonymous":           true,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("quiz question %d failed: %w", i+1, err)
+		}
+		pacing.pause(b.cfg.PostDelay)
+	}
+
+	fmt.Printf("🧠 Quiz posted: %d question(s)\n", len(questions))
+	return nil
+}
+
+func pollOptions(options []string) []map[string]string {
+	out := make([]map[string]string, len(options))
+	for i, o := range options {
+		out[i] = map[string]string{"text": o}
+	}
+	return out
+}
+
+// runQuizScheduled posts a quiz at every tick of the quiz schedule
+func runQuizScheduled(ctx context.Context, bot *Bot, schedule *cronSchedule) error {
+	return runEvery(ctx, schedule, "quiz", func() {
+		if err := bot.PostQuiz(ctx); err != nil {
+			fmt.Printf("⚠️  Quiz failed: %v\n", err)
+		}
+	})
+}
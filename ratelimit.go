@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows bursts of up to capacity events, refilled at rate per second
+type tokenBucket struct {
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(capacity, ratePerSecond float64) *tokenBucket {
+	return &tokenBucket{capacity: capacity, rate: ratePerSecond, tokens: capacity, last: time.Now()}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve takes a token, going into debt if needed, and returns how long to
+// wait before acting on it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// keyedLimiter keeps one token bucket per key (client IP, host, ...)
+type keyedLimiter struct {
+	capacity float64
+	rate     float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newKeyedLimiter(capacity, ratePerSecond float64) *keyedLimiter {
+	return &keyedLimiter{capacity: capacity, rate: ratePerSecond, buckets: map[string]*tokenBucket{}}
+}
+
+func (l *keyedLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	return l.bucket(key, now).allow(now)
+}
+
+// Wait blocks until key may act again
+func (l *keyedLimiter) Wait(key string) {
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.bucket(key, now).reserve(now)
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+// bucket returns key's bucket, creating it if needed; mu must be held
+func (l *keyedLimiter) bucket(key string, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		// Drop idle buckets so the map doesn't grow with every client ever seen
+		if len(l.buckets) > 10000 {
+			for k, old := range l.buckets {
+				if old.refill(now); old.tokens >= old.capacity {
+					delete(l.buckets, k)
+				}
+			}
+		}
+		b = newTokenBucket(l.capacity, l.rate)
+		l.buckets[key] = b
+	}
+	return b
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// RelevanceConfig has the model score each item against what the channel is
+// about before summarizing it, so press releases and other filler from broad
+// feeds can be skipped without keyword lists
+type RelevanceConfig struct {
+	Profile   string `yaml:"profile"`   // the readers' interests in plain words; empty turns scoring off
+	Threshold int    `yaml:"threshold"` // 0-10; items scoring lower are skipped, 0 only records scores
+	Model     string `yaml:"model"`     // e.g. a cheaper model than ai.model, which is the default
+}
+
+const RELEVANCE_PROMPT = `Score how relevant this article is to readers with these interests, from 0 (unrelated, or marketing, press releases and filler) to 10 (exactly what they want to read):
+
+%s
+
+Give the score and a one-sentence reason.
+
+Title: %s
+
+Content:
+%s`
+
+type RelevanceOutput struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// checkRelevance scores an item and reports why it should be skipped, or ""
+// to go on. A failed check lets the item through.
+func (b *Bot) checkRelevance(ctx context.Context, p *pendingItem) string {
+	cfg := b.cfg.Relevance
+	if cfg.Profile == "" || p.feed.Kind == FEED_RELEASE {
+		return ""
+	}
+	aiCfg := b.cfg.AI
+	aiCfg.Model = cmp.Or(cfg.Model, aiCfg.Model)
+
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	out, resp, err := genkit.GenerateData[RelevanceOutput](ctx, b.g,
+		ai.WithPrompt(fmt.Sprintf(RELEVANCE_PROMPT, cfg.Profile, p.item.Title, truncate(p.content, 3000, "..."))),
+		ai.WithModelName(aiCfg.Model),
+		withFallback(b.g, aiCfg),
+	)
+	p.addUsage(resp)
+	if err != nil {
+		fmt.Printf("⚠️  Relevance check failed (%s): %v\n", p.item.Title, err)
+		return ""
+	}
+	score := min(max(out.Score, 0), 10)
+	p.decision.score("relevance", float64(score))
+	if score >= cfg.Threshold {
+		return ""
+	}
+	return fmt.Sprintf("relevance %d/10 below %d: %s", score, cfg.Threshold, out.Reason)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errNotStored is returned by Resend for an id or URL the bot has no record of
+var errNotStored = errors.New("no stored item with that id or link")
+
+// Resend fetches, summarizes and posts a stored item again, whether or not it
+// was sent before: for a deleted message or a bad summary. key is the item id
+// or its link.
+func (b *Bot) Resend(ctx context.Context, key string) error {
+	if !b.running.TryLock() {
+		return errRunInProgress
+	}
+	defer b.running.Unlock()
+
+	unlock, err := acquireRunLock(b.cfg.State)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := openStateStore(b.cfg.State)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := state.Close(); err != nil {
+			fmt.Printf("⚠️  Saving state failed: %v\n", err)
+		}
+	}()
+
+	id, item, feedURL, err := b.findStored(state, key)
+	if err != nil {
+		return err
+	}
+
+	feed := FeedConfig{URL: feedURL}
+	for _, f := range b.cfg.Feeds {
+		if feedKey(f.URL) == feedKey(feedURL) {
+			feed = f
+			break
+		}
+	}
+
+	fmt.Printf("🔁 Resending: %s\n", item.Title)
+	metrics := newRunMetrics()
+	decision := &Decision{Feed: feedURL, ItemID: id, Title: item.Title, Link: item.Link}
+	// Job posts go to the jobs destination, so check the decision rather than the result
+	b.postItem(ctx, feed, id, item, state, decision, metrics)
+	if decision.Action != ACTION_SENT {
+		return fmt.Errorf("not resent (%s): %s", decision.Action, decision.Reason)
+	}
+	return nil
+}
+
+// findStored looks key up in the archive first, which keeps the original
+// link, then in the state store
+func (b *Bot) findStored(state StateStore, key string) (string, Item, string, error) {
+	archived, err := b.archive.Get(key)
+	if err != nil {
+		return "", Item{}, "", err
+	}
+	if archived != nil {
+		return archived.ID, Item{Title: archived.Title, Link: archived.Link}, archived.Feed, nil
+	}
+
+	var found *StateEntry
+	var id string
+	err = state.Each(func(entryID string, entry StateEntry) error {
+		if entryID == key || entryID == itemID(key) || entryID == hash(key) || entry.Link == key {
+			found, id = &entry, entryID
+			return errStopEach
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopEach) {
+		return "", Item{}, "", err
+	}
+	if found == nil || found.Link == "" {
+		return "", Item{}, "", fmt.Errorf("%s: %w", key, errNotStored)
+	}
+	return id, Item{Title: found.Title, Link: found.Link}, found.Feed, nil
+}
+
+// errStopEach ends a StateStore.Each walk early
+var errStopEach = errors.New("stop")
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Longest wait honored from a Retry-After header by retrying on the spot;
+// longer ones pause the host, see throttledError
+const MAX_RETRY_AFTER = 30 * time.Second
+
+// Longest a run holds a throttled feed back to try it again before the run
+// ends; hosts that want a longer break get the feed on the next run
+const MAX_THROTTLE_WAIT = 10 * time.Minute
+
+// throttledError is a host that asked, with 429 or 503 and Retry-After, not
+// to be contacted again until Until
+type throttledError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("%s asked us to wait until %s", e.Host, e.Until.Local().Format(time.TimeOnly))
+}
+
+// retryAfter reads a Retry-After header, given in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// retryableStatus reports responses worth asking for again: throttling and gateway trouble
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableError tells transient network failures (timeouts, resets, refused
+// connections, DNS hiccups) from permanent ones such as an unknown host, a bad
+// certificate or a refused redirect
+func retryableError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryDelay is the wait before retry n (1-based): retry_delay doubled each
+// time with ±50% jitter, or the server's Retry-After when it sent one
+func (o FetchConfig) retryDelay(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp); ok {
+			return min(d, MAX_RETRY_AFTER)
+		}
+	}
+	base := o.RetryDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	d := base << (n - 1)
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// attempts is how many times a request is tried, default 3
+func (o FetchConfig) attempts() int {
+	if o.Attempts <= 0 {
+		return 3
+	}
+	return o.Attempts
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a host's robots.txt is trusted before it is fetched again; failed
+// fetches are retried sooner
+const (
+	ROBOTS_TTL       = 24 * time.Hour
+	ROBOTS_ERROR_TTL = time.Hour
+	ROBOTS_MAX_BYTES = 512 << 10 // RFC 9309 asks crawlers to read at least 500 KiB
+)
+
+var errRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsRule is one Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsGroup struct {
+	agents []string // lowercase user-agent values, "*" for everyone
+	rules  []robotsRule
+}
+
+// robotsTxt is a parsed robots.txt; nil allows everything
+type robotsTxt struct {
+	groups []robotsGroup
+}
+
+type robotsEntry struct {
+	robots  *robotsTxt
+	err     error // the host couldn't say, so everything is disallowed (RFC 9309)
+	expires time.Time
+}
+
+// robotsCache keeps each host's rules, keyed by scheme and host
+var robotsCache = struct {
+	sync.Mutex
+	hosts map[string]robotsEntry
+}{hosts: map[string]robotsEntry{}}
+
+// robotsAllowed reports whether robots.txt lets the bot fetch target. The
+// product token of the user agent ("rss-bot" by default) picks the group.
+func robotsAllowed(target string, opts FetchConfig) (bool, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return false, fmt.Errorf("bad url %q", target)
+	}
+	key := u.Scheme + "://" + u.Host
+
+	robotsCache.Lock()
+	entry, ok := robotsCache.hosts[key]
+	robotsCache.Unlock()
+	if !ok || time.Now().After(entry.expires) {
+		entry = fetchRobots(key, opts)
+		robotsCache.Lock()
+		robotsCache.hosts[key] = entry
+		robotsCache.Unlock()
+	}
+	if entry.err != nil {
+		return false, entry.err
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.robots.allowed(robotsAgent(opts.userAgent()), path), nil
+}
+
+// robotsAgent is the product token of a user agent, e.g. "rss-bot"
+func robotsAgent(ua string) string {
+	token, _, _ := strings.Cut(ua, "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// fetchRobots downloads and parses a host's robots.txt. A missing file (4xx)
+// allows everything; a server or network error disallows everything until the
+// next try.
+func fetchRobots(origin string, opts FetchConfig) robotsEntry {
+	opts.Attempts = 1
+	resp, err := opts.get("robots", origin+"/robots.txt")
+	if err != nil {
+		return robotsEntry{err: fmt.Errorf("robots.txt: %w", err), expires: time.Now().Add(ROBOTS_ERROR_TTL)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return robotsEntry{err: fmt.Errorf("robots.txt: bad status: %d", resp.StatusCode), expires: time.Now().Add(ROBOTS_ERROR_TTL)}
+	case resp.StatusCode >= 400:
+		return robotsEntry{expires: time.Now().Add(ROBOTS_TTL)}
+	case resp.StatusCode >= 300:
+		// Redirects were followed; anything left over isn't a robots.txt
+		return robotsEntry{expires: time.Now().Add(ROBOTS_TTL)}
+	}
+	return robotsEntry{robots: parseRobots(io.LimitReader(resp.Body, ROBOTS_MAX_BYTES)), expires: time.Now().Add(ROBOTS_TTL)}
+}
+
+// parseRobots reads user-agent groups with their allow and disallow rules;
+// other lines (sitemap, crawl-delay) are ignored
+func parseRobots(r io.Reader) *robotsTxt {
+	robots := &robotsTxt{}
+	var group *robotsGroup
+	inAgents := false // consecutive user-agent lines share one group
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				group = &robots.groups[len(robots.groups)-1]
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if group == nil || value == "" && key == "disallow" {
+				continue // "Disallow:" with no path allows everything
+			}
+			group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+		default:
+			inAgents = false
+		}
+	}
+	return robots
+}
+
+// allowed applies the rules of the group for agent, or of "*": the longest
+// matching pattern wins, and allow wins a tie
+func (r *robotsTxt) allowed(agent, path string) bool {
+	if r == nil {
+		return true
+	}
+	var rules []robotsRule
+	matched := false
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			if a == agent {
+				if !matched {
+					rules, matched = nil, true
+				}
+				rules = append(rules, g.rules...)
+			}
+		}
+	}
+	if !matched {
+		for _, g := range r.groups {
+			for _, a := range g.agents {
+				if a == "*" {
+					rules = append(rules, g.rules...)
+				}
+			}
+		}
+	}
+
+	best, allow := -1, true
+	for _, rule := range rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || n == best && rule.allow {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a path against a robots.txt pattern, where * is any
+// run of characters and a trailing $ anchors the end
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
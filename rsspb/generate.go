@@ -0,0 +1,4 @@
+// Package rsspb holds the protobuf definitions and generated gRPC code for the bot API.
+package rsspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rss.proto
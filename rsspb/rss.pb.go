@@ -0,0 +1,502 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: rss.proto
+
+package rsspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Item struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Feed          string                 `protobuf:"bytes,2,opt,name=feed,proto3" json:"feed,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Link          string                 `protobuf:"bytes,4,opt,name=link,proto3" json:"link,omitempty"`
+	Summary       string                 `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Item) Reset() {
+	*x = Item{}
+	mi := &file_rss_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Item) ProtoMessage() {}
+
+func (x *Item) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Item.ProtoReflect.Descriptor instead.
+func (*Item) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Item) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Item) GetFeed() string {
+	if x != nil {
+		return x.Feed
+	}
+	return ""
+}
+
+func (x *Item) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Item) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *Item) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Item) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+type ListItemsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of items to return; 0 means the server default.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Only return items from this feed URL when set.
+	Feed          string `protobuf:"bytes,2,opt,name=feed,proto3" json:"feed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListItemsRequest) Reset() {
+	*x = ListItemsRequest{}
+	mi := &file_rss_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListItemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListItemsRequest) ProtoMessage() {}
+
+func (x *ListItemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListItemsRequest.ProtoReflect.Descriptor instead.
+func (*ListItemsRequest) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListItemsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListItemsRequest) GetFeed() string {
+	if x != nil {
+		return x.Feed
+	}
+	return ""
+}
+
+type ListItemsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Item                `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListItemsResponse) Reset() {
+	*x = ListItemsResponse{}
+	mi := &file_rss_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListItemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListItemsResponse) ProtoMessage() {}
+
+func (x *ListItemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListItemsResponse.ProtoReflect.Descriptor instead.
+func (*ListItemsResponse) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListItemsResponse) GetItems() []*Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type GetItemRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Item id (hash) or article link.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetItemRequest) Reset() {
+	*x = GetItemRequest{}
+	mi := &file_rss_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetItemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetItemRequest) ProtoMessage() {}
+
+func (x *GetItemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetItemRequest.ProtoReflect.Descriptor instead.
+func (*GetItemRequest) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetItemRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TriggerRunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Block until the run finishes and report its results.
+	Wait          bool `protobuf:"varint,1,opt,name=wait,proto3" json:"wait,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerRunRequest) Reset() {
+	*x = TriggerRunRequest{}
+	mi := &file_rss_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerRunRequest) ProtoMessage() {}
+
+func (x *TriggerRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerRunRequest.ProtoReflect.Descriptor instead.
+func (*TriggerRunRequest) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TriggerRunRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type TriggerRunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Started       bool                   `protobuf:"varint,1,opt,name=started,proto3" json:"started,omitempty"`
+	PostsSent     int32                  `protobuf:"varint,2,opt,name=posts_sent,json=postsSent,proto3" json:"posts_sent,omitempty"`
+	FeedErrors    int32                  `protobuf:"varint,3,opt,name=feed_errors,json=feedErrors,proto3" json:"feed_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerRunResponse) Reset() {
+	*x = TriggerRunResponse{}
+	mi := &file_rss_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerRunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerRunResponse) ProtoMessage() {}
+
+func (x *TriggerRunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerRunResponse.ProtoReflect.Descriptor instead.
+func (*TriggerRunResponse) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TriggerRunResponse) GetStarted() bool {
+	if x != nil {
+		return x.Started
+	}
+	return false
+}
+
+func (x *TriggerRunResponse) GetPostsSent() int32 {
+	if x != nil {
+		return x.PostsSent
+	}
+	return 0
+}
+
+func (x *TriggerRunResponse) GetFeedErrors() int32 {
+	if x != nil {
+		return x.FeedErrors
+	}
+	return 0
+}
+
+type StreamItemsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Replay this many recent items before streaming new ones.
+	Replay        int32 `protobuf:"varint,1,opt,name=replay,proto3" json:"replay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamItemsRequest) Reset() {
+	*x = StreamItemsRequest{}
+	mi := &file_rss_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamItemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamItemsRequest) ProtoMessage() {}
+
+func (x *StreamItemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rss_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamItemsRequest.ProtoReflect.Descriptor instead.
+func (*StreamItemsRequest) Descriptor() ([]byte, []int) {
+	return file_rss_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamItemsRequest) GetReplay() int32 {
+	if x != nil {
+		return x.Replay
+	}
+	return 0
+}
+
+var File_rss_proto protoreflect.FileDescriptor
+
+const file_rss_proto_rawDesc = "" +
+	"\n" +
+	"\trss.proto\x12\x06rss.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa3\x01\n" +
+	"\x04Item\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04feed\x18\x02 \x01(\tR\x04feed\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x12\n" +
+	"\x04link\x18\x04 \x01(\tR\x04link\x12\x18\n" +
+	"\asummary\x18\x05 \x01(\tR\asummary\x123\n" +
+	"\asent_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\"<\n" +
+	"\x10ListItemsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x12\n" +
+	"\x04feed\x18\x02 \x01(\tR\x04feed\"7\n" +
+	"\x11ListItemsResponse\x12\"\n" +
+	"\x05items\x18\x01 \x03(\v2\f.rss.v1.ItemR\x05items\" \n" +
+	"\x0eGetItemRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"'\n" +
+	"\x11TriggerRunRequest\x12\x12\n" +
+	"\x04wait\x18\x01 \x01(\bR\x04wait\"n\n" +
+	"\x12TriggerRunResponse\x12\x18\n" +
+	"\astarted\x18\x01 \x01(\bR\astarted\x12\x1d\n" +
+	"\n" +
+	"posts_sent\x18\x02 \x01(\x05R\tpostsSent\x12\x1f\n" +
+	"\vfeed_errors\x18\x03 \x01(\x05R\n" +
+	"feedErrors\",\n" +
+	"\x12StreamItemsRequest\x12\x16\n" +
+	"\x06replay\x18\x01 \x01(\x05R\x06replay2\xff\x01\n" +
+	"\n" +
+	"RSSService\x12@\n" +
+	"\tListItems\x12\x18.rss.v1.ListItemsRequest\x1a\x19.rss.v1.ListItemsResponse\x12/\n" +
+	"\aGetItem\x12\x16.rss.v1.GetItemRequest\x1a\f.rss.v1.Item\x12C\n" +
+	"\n" +
+	"TriggerRun\x12\x19.rss.v1.TriggerRunRequest\x1a\x1a.rss.v1.TriggerRunResponse\x129\n" +
+	"\vStreamItems\x12\x1a.rss.v1.StreamItemsRequest\x1a\f.rss.v1.Item0\x01B\vZ\trss/rsspbb\x06proto3"
+
+var (
+	file_rss_proto_rawDescOnce sync.Once
+	file_rss_proto_rawDescData []byte
+)
+
+func file_rss_proto_rawDescGZIP() []byte {
+	file_rss_proto_rawDescOnce.Do(func() {
+		file_rss_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rss_proto_rawDesc), len(file_rss_proto_rawDesc)))
+	})
+	return file_rss_proto_rawDescData
+}
+
+var file_rss_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_rss_proto_goTypes = []any{
+	(*Item)(nil),                  // 0: rss.v1.Item
+	(*ListItemsRequest)(nil),      // 1: rss.v1.ListItemsRequest
+	(*ListItemsResponse)(nil),     // 2: rss.v1.ListItemsResponse
+	(*GetItemRequest)(nil),        // 3: rss.v1.GetItemRequest
+	(*TriggerRunRequest)(nil),     // 4: rss.v1.TriggerRunRequest
+	(*TriggerRunResponse)(nil),    // 5: rss.v1.TriggerRunResponse
+	(*StreamItemsRequest)(nil),    // 6: rss.v1.StreamItemsRequest
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_rss_proto_depIdxs = []int32{
+	7, // 0: rss.v1.Item.sent_at:type_name -> google.protobuf.Timestamp
+	0, // 1: rss.v1.ListItemsResponse.items:type_name -> rss.v1.Item
+	1, // 2: rss.v1.RSSService.ListItems:input_type -> rss.v1.ListItemsRequest
+	3, // 3: rss.v1.RSSService.GetItem:input_type -> rss.v1.GetItemRequest
+	4, // 4: rss.v1.RSSService.TriggerRun:input_type -> rss.v1.TriggerRunRequest
+	6, // 5: rss.v1.RSSService.StreamItems:input_type -> rss.v1.StreamItemsRequest
+	2, // 6: rss.v1.RSSService.ListItems:output_type -> rss.v1.ListItemsResponse
+	0, // 7: rss.v1.RSSService.GetItem:output_type -> rss.v1.Item
+	5, // 8: rss.v1.RSSService.TriggerRun:output_type -> rss.v1.TriggerRunResponse
+	0, // 9: rss.v1.RSSService.StreamItems:output_type -> rss.v1.Item
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_rss_proto_init() }
+func file_rss_proto_init() {
+	if File_rss_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rss_proto_rawDesc), len(file_rss_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rss_proto_goTypes,
+		DependencyIndexes: file_rss_proto_depIdxs,
+		MessageInfos:      file_rss_proto_msgTypes,
+	}.Build()
+	File_rss_proto = out.File
+	file_rss_proto_goTypes = nil
+	file_rss_proto_depIdxs = nil
+}
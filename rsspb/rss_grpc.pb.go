@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: rss.proto
+
+package rsspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RSSService_ListItems_FullMethodName   = "/rss.v1.RSSService/ListItems"
+	RSSService_GetItem_FullMethodName     = "/rss.v1.RSSService/GetItem"
+	RSSService_TriggerRun_FullMethodName  = "/rss.v1.RSSService/TriggerRun"
+	RSSService_StreamItems_FullMethodName = "/rss.v1.RSSService/StreamItems"
+)
+
+// RSSServiceClient is the client API for RSSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RSSService exposes archived items and run control to internal services.
+type RSSServiceClient interface {
+	// ListItems returns recently posted items, newest first.
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error)
+	// GetItem looks up a single posted item by id or link.
+	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error)
+	// TriggerRun starts a fetch-and-post run.
+	TriggerRun(ctx context.Context, in *TriggerRunRequest, opts ...grpc.CallOption) (*TriggerRunResponse, error)
+	// StreamItems sends items as they are posted until the client disconnects.
+	StreamItems(ctx context.Context, in *StreamItemsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Item], error)
+}
+
+type rSSServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRSSServiceClient(cc grpc.ClientConnInterface) RSSServiceClient {
+	return &rSSServiceClient{cc}
+}
+
+func (c *rSSServiceClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListItemsResponse)
+	err := c.cc.Invoke(ctx, RSSService_ListItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rSSServiceClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, RSSService_GetItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rSSServiceClient) TriggerRun(ctx context.Context, in *TriggerRunRequest, opts ...grpc.CallOption) (*TriggerRunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerRunResponse)
+	err := c.cc.Invoke(ctx, RSSService_TriggerRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rSSServiceClient) StreamItems(ctx context.Context, in *StreamItemsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Item], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RSSService_ServiceDesc.Streams[0], RSSService_StreamItems_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamItemsRequest, Item]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RSSService_StreamItemsClient = grpc.ServerStreamingClient[Item]
+
+// RSSServiceServer is the server API for RSSService service.
+// All implementations must embed UnimplementedRSSServiceServer
+// for forward compatibility.
+//
+// RSSService exposes archived items and run control to internal services.
+type RSSServiceServer interface {
+	// ListItems returns recently posted items, newest first.
+	ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error)
+	// GetItem looks up a single posted item by id or link.
+	GetItem(context.Context, *GetItemRequest) (*Item, error)
+	// TriggerRun starts a fetch-and-post run.
+	TriggerRun(context.Context, *TriggerRunRequest) (*TriggerRunResponse, error)
+	// StreamItems sends items as they are posted until the client disconnects.
+	StreamItems(*StreamItemsRequest, grpc.ServerStreamingServer[Item]) error
+	mustEmbedUnimplementedRSSServiceServer()
+}
+
+// UnimplementedRSSServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRSSServiceServer struct{}
+
+func (UnimplementedRSSServiceServer) ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListItems not implemented")
+}
+func (UnimplementedRSSServiceServer) GetItem(context.Context, *GetItemRequest) (*Item, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetItem not implemented")
+}
+func (UnimplementedRSSServiceServer) TriggerRun(context.Context, *TriggerRunRequest) (*TriggerRunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerRun not implemented")
+}
+func (UnimplementedRSSServiceServer) StreamItems(*StreamItemsRequest, grpc.ServerStreamingServer[Item]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamItems not implemented")
+}
+func (UnimplementedRSSServiceServer) mustEmbedUnimplementedRSSServiceServer() {}
+func (UnimplementedRSSServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeRSSServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RSSServiceServer will
+// result in compilation errors.
+type UnsafeRSSServiceServer interface {
+	mustEmbedUnimplementedRSSServiceServer()
+}
+
+func RegisterRSSServiceServer(s grpc.ServiceRegistrar, srv RSSServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRSSServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RSSService_ServiceDesc, srv)
+}
+
+func _RSSService_ListItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RSSServiceServer).ListItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RSSService_ListItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RSSServiceServer).ListItems(ctx, req.(*ListItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RSSService_GetItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RSSServiceServer).GetItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RSSService_GetItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RSSServiceServer).GetItem(ctx, req.(*GetItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RSSService_TriggerRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RSSServiceServer).TriggerRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RSSService_TriggerRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RSSServiceServer).TriggerRun(ctx, req.(*TriggerRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RSSService_StreamItems_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamItemsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RSSServiceServer).StreamItems(m, &grpc.GenericServerStream[StreamItemsRequest, Item]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RSSService_StreamItemsServer = grpc.ServerStreamingServer[Item]
+
+// RSSService_ServiceDesc is the grpc.ServiceDesc for RSSService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RSSService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rss.v1.RSSService",
+	HandlerType: (*RSSServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListItems",
+			Handler:    _RSSService_ListItems_Handler,
+		},
+		{
+			MethodName: "GetItem",
+			Handler:    _RSSService_GetItem_Handler,
+		},
+		{
+			MethodName: "TriggerRun",
+			Handler:    _RSSService_TriggerRun_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamItems",
+			Handler:       _RSSService_StreamItems_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rss.proto",
+}
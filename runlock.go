@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// state.lock values
+const (
+	LOCK_SKIP = "skip" // exit when another run is active
+	LOCK_WAIT = "wait" // block until it finishes
+	LOCK_NONE = "none"
+)
+
+// Lock file for backends without a local state file
+const RUN_LOCK_FILE = "rss.lock"
+
+// A Redis run lock expires this long after its holder stops refreshing it
+const REDIS_LOCK_TTL = time.Minute
+
+var errRunLocked = errors.New("another run is still in progress")
+
+// acquireRunLock keeps overlapping invocations (a slow cron run and the next
+// one) from reading the same state and posting the same items twice. Local
+// backends lock a file next to the state; redis locks a key shared by all
+// instances.
+func acquireRunLock(cfg StateConfig) (release func(), err error) {
+	if cfg.Lock == LOCK_NONE {
+		return func() {}, nil
+	}
+	wait := cfg.Lock == LOCK_WAIT
+	if cfg.Backend == STATE_REDIS {
+		return redisRunLock(cfg, wait)
+	}
+	return fileRunLock(cfg.lockPath(), wait)
+}
+
+func (c StateConfig) lockPath() string {
+	switch {
+	case c.Backend == STATE_S3 || c.Backend == STATE_GCS:
+		return RUN_LOCK_FILE
+	case c.Path != "":
+		return c.Path + ".lock"
+	case c.Backend == STATE_BOLT:
+		return STATE_BOLT_FILE + ".lock"
+	default:
+		return STATE_FILE + ".lock"
+	}
+}
+
+func redisRunLock(cfg StateConfig, wait bool) (func(), error) {
+	client, err := redisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key := "rss:runlock:" + cfg.redisPrefix()
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	for announced := false; ; {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ok, err := client.SetNX(ctx, key, token, REDIS_LOCK_TTL).Result()
+		cancel()
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("run lock: %w", err)
+		}
+		if ok {
+			break
+		}
+		if !wait {
+			client.Close()
+			return nil, errRunLocked
+		}
+		if !announced {
+			fmt.Println("⏳ Waiting for the run in progress to finish")
+			announced = true
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	// Keep the lock alive for as long as the run takes
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(REDIS_LOCK_TTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				client.Eval(ctx, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`,
+					[]string{key}, token, REDIS_LOCK_TTL.Milliseconds())
+				cancel()
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Eval(ctx, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`,
+			[]string{key}, token).Err(); err != nil && err != redis.Nil {
+			fmt.Printf("⚠️  Releasing run lock failed: %v\n", err)
+		}
+		client.Close()
+	}, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "fmt"
+
+// fileRunLock is a no-op where flock isn't available
+func fileRunLock(path string, wait bool) (func(), error) {
+	fmt.Printf("⚠️  Run lock %s is not supported on this platform, overlapping runs are not prevented\n", path)
+	return func() {}, nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileRunLock takes an flock on path; the kernel drops it if the process dies
+func fileRunLock(path string, wait bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("run lock: %w", err)
+	}
+	fd := int(f.Fd())
+
+	err = syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		if !wait {
+			f.Close()
+			return nil, errRunLocked
+		}
+		fmt.Println("⏳ Waiting for the run in progress to finish")
+		err = syscall.Flock(fd, syscall.LOCK_EX)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("run lock: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(fd, syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
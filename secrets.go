@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+// Secret references, set under `secrets:` as ENV_VAR: reference
+//
+//	vault://secret/data/rss#tg_bot_token         HashiCorp Vault (KV v1 or v2), VAULT_ADDR + VAULT_TOKEN
+//	awssm://rss/prod#gemini_api_token            AWS Secrets Manager, standard AWS_* credentials
+//	gcpsm://projects/p/secrets/tg-token          GCP Secret Manager, application default credentials
+//
+// The part after # picks a field from a JSON (or KV) secret; without it the
+// whole secret string is used.
+
+// loadSecrets resolves the config's secrets section into environment variables
+// so the rest of the config can keep referring to them as ${VAR}. Variables
+// already set in the environment are left alone.
+func loadSecrets(root *yaml.Node) error {
+	top := root.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var secrets map[string]string
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value == "secrets" {
+			if err := top.Content[i+1].Decode(&secrets); err != nil {
+				return fmt.Errorf("secrets: %w", err)
+			}
+		}
+	}
+
+	for env, ref := range secrets {
+		if v, ok := os.LookupEnv(env); ok && v != "" {
+			continue
+		}
+		value, err := fetchSecret(expandEnv(ref))
+		if err != nil {
+			return fmt.Errorf("secret %s: %w", env, err)
+		}
+		os.Setenv(env, value)
+	}
+	return nil
+}
+
+func fetchSecret(ref string) (string, error) {
+	scheme, rest, ok := strings.Cut(ref, "://")
+	if !ok {
+		return "", fmt.Errorf("bad reference %q (want vault://, awssm:// or gcpsm://)", ref)
+	}
+	path, field, _ := strings.Cut(rest, "#")
+
+	ctx, cancel := context.WithTimeout(withPurpose(context.Background(), "secrets"), 30*time.Second)
+	defer cancel()
+
+	switch scheme {
+	case "vault":
+		return vaultSecret(ctx, path, field)
+	case "awssm":
+		value, err := awsSecret(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		return secretField(value, field)
+	case "gcpsm":
+		value, err := gcpSecret(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		return secretField(value, field)
+	default:
+		return "", fmt.Errorf("unknown secret backend %q", scheme)
+	}
+}
+
+// secretField picks one key out of a JSON object secret
+func secretField(value, field string) (string, error) {
+	if field == "" {
+		return value, nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, can't select %q", field)
+	}
+	v, ok := obj[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	return fmt.Sprint(v), nil
+}
+
+func doSecretRequest(req *http.Request) ([]byte, error) {
+	resp, err := httpDo(req, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status: %d %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+func vaultSecret(ctx context.Context, path, field string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	if field == "" {
+		return "", fmt.Errorf("vault references need a #field")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parse failed: %w", err)
+	}
+	data := resp.Data
+	// KV v2 nests the payload one level deeper
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = inner
+		}
+	}
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %q", path, field)
+	}
+	return fmt.Sprint(v), nil
+}
+
+func awsSecret(ctx context.Context, secretID string) (string, error) {
+	creds := awsCredentialsFromEnv()
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", creds.Region)
+	}
+
+	payload, _ := json.Marshal(map[string]string{"SecretId": secretID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, payload, "secretsmanager", creds)
+
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parse failed: %w", err)
+	}
+	if resp.SecretString == "" && resp.SecretBinary != "" {
+		b, err := base64.StdEncoding.DecodeString(resp.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("parse failed: %w", err)
+		}
+		return string(b), nil
+	}
+	return resp.SecretString, nil
+}
+
+func gcpSecret(ctx context.Context, name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("gcp credentials: %w", err)
+	}
+	token, err := creds.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("gcp credentials: %w", err)
+	}
+
+	target := "https://secretmanager.googleapis.com/v1/" + (&url.URL{Path: name}).EscapedPath() + ":access"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Value)
+
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parse failed: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("parse failed: %w", err)
+	}
+	return string(data), nil
+}
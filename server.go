@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs the configured API servers and the run schedule until interrupted
+// or one of them fails
+func serve(ctx context.Context, bot *Bot) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var servers []func(context.Context) error
+	if bot.cfg.GRPC.Listen != "" {
+		servers = append(servers, func(ctx context.Context) error { return serveGRPC(ctx, bot, bot.cfg.GRPC) })
+	}
+	if bot.cfg.API.Listen != "" {
+		servers = append(servers, func(ctx context.Context) error { return serveAPI(ctx, bot.archive, bot.cfg.API) })
+	}
+	if bot.cfg.Ask.Enabled {
+		servers = append(servers, func(ctx context.Context) error { return runAsk(ctx, bot) })
+	}
+	if bot.cfg.Schedule != "" {
+		schedule, err := parseCron(bot.cfg.Schedule)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, func(ctx context.Context) error { return runScheduled(ctx, bot, schedule) })
+	}
+	if bot.cfg.Quiz.Schedule != "" {
+		schedule, err := parseCron(bot.cfg.Quiz.Schedule)
+		if err != nil {
+			return fmt.Errorf("quiz.schedule: %w", err)
+		}
+		servers = append(servers, func(ctx context.Context) error { return runQuizScheduled(ctx, bot, schedule) })
+	}
+	if bot.cfg.Newsletter.Schedule != "" {
+		schedule, err := parseCron(bot.cfg.Newsletter.Schedule)
+		if err != nil {
+			return fmt.Errorf("newsletter.schedule: %w", err)
+		}
+		servers = append(servers, func(ctx context.Context) error { return runNewsletterScheduled(ctx, bot, schedule) })
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("nothing to serve: set schedule, quiz.schedule, newsletter.schedule, grpc.listen, api.listen or ask.enabled in the config")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(servers))
+	for _, run := range servers {
+		go func() { errs <- run(ctx) }()
+	}
+
+	// The first server to stop takes the others down with it
+	err := <-errs
+	cancel()
+	for range len(servers) - 1 {
+		<-errs
+	}
+	return err
+}
+
+// runScheduled starts a run at every cron tick, skipping ticks while a run is still going
+func runScheduled(ctx context.Context, bot *Bot, schedule *cronSchedule) error {
+	return runEvery(ctx, schedule, "run", func() {
+		if err := bot.Start(ctx); errors.Is(err, errRunInProgress) {
+			fmt.Println("⏭️  Previous run still in progress, skipping this tick")
+		}
+	})
+}
+
+// runEvery calls fire at every tick of schedule until ctx is done
+func runEvery(ctx context.Context, schedule *cronSchedule, name string, fire func()) error {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("%s schedule never fires", name)
+		}
+		fmt.Printf("⏰ Next %s at %s\n", name, next.Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+		fire()
+	}
+}
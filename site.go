@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type SiteConfig struct {
+	Dir     string `yaml:"dir"`      // output directory, e.g. docs/ for GitHub Pages; empty disables the sink
+	Title   string `yaml:"title"`    // site title, default "RSS digest"
+	BaseURL string `yaml:"base_url"` // public URL of the site; needed for sitemap.xml, feed.xml and og:url
+}
+
+// sitePage is one rendered article summary
+type sitePage struct {
+	ArchivedItem
+	Slug        string
+	Body        template.HTML
+	Description string // plain-text excerpt for meta tags and the site feed
+}
+
+type siteDay struct {
+	Date  string
+	Pages []*sitePage
+}
+
+type siteTag struct {
+	Name  string
+	Slug  string
+	Pages []*sitePage
+}
+
+const siteLayout = `{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.PageTitle}}</title>
+{{if .Description}}<meta name="description" content="{{.Description}}">
+<meta property="og:description" content="{{.Description}}">
+{{end}}<meta property="og:site_name" content="{{.SiteTitle}}">
+<meta property="og:title" content="{{.OGTitle}}">
+<meta property="og:type" content="{{.OGType}}">
+<meta name="twitter:card" content="summary">
+{{if .URL}}<meta property="og:url" content="{{.URL}}">
+<link rel="canonical" href="{{.URL}}">
+{{end}}<link rel="alternate" type="application/rss+xml" title="{{.SiteTitle}}" href="{{.Root}}feed.xml">
+<style>
+body{font-family:system-ui,sans-serif;max-width:46rem;margin:2rem auto;padding:0 1rem;line-height:1.5;color:#222}
+a{color:#0b63c5}h1 a{color:inherit;text-decoration:none}
+.meta{color:#777;font-size:.9em}.tag{margin-right:.5em}
+.summary{white-space:pre-wrap}li{margin:.3em 0}
+</style>
+</head>
+<body>
+<h1><a href="{{.Root}}index.html">{{.SiteTitle}}</a></h1>
+<p class="meta"><a href="{{.Root}}tags/index.html">Tags</a></p>
+{{end}}
+{{define "foot"}}</body>
+</html>
+{{end}}
+{{define "list"}}<ul>{{range .}}
+<li><a href="{{root}}items/{{.Slug}}.html">{{.Title}}</a> <span class="meta">{{host .Link}}</span></li>{{end}}
+</ul>{{end}}
+
+{{define "index"}}{{template "head" .}}
+{{range .Days}}<h2>{{.Date}}</h2>
+{{template "list" .Pages}}
+{{end}}{{template "foot"}}{{end}}
+
+{{define "item"}}{{template "head" .}}
+{{with .Page}}<h2><a href="{{.Link}}">{{.Title}}</a></h2>
+<p class="meta">{{.SentAt.Format "2006-01-02 15:04"}} UTC · {{host .Link}}</p>
+<p>{{range .Tags}}<a class="tag" href="../tags/{{tagSlug .}}.html">#{{.}}</a>{{end}}</p>
+<div class="summary">{{.Body}}</div>
+<p><a href="{{.Link}}">Read the original article →</a></p>{{end}}
+{{template "foot"}}{{end}}
+
+{{define "tag"}}{{template "head" .}}
+<h2>#{{.Tag.Name}}</h2>
+{{template "list" .Tag.Pages}}
+{{template "foot"}}{{end}}
+
+{{define "tagindex"}}{{template "head" .}}
+<h2>Tags</h2>
+<ul>{{range .Tags}}
+<li><a href="{{.Slug}}.html">#{{.Name}}</a> <span class="meta">({{len .Pages}})</span></li>{{end}}
+</ul>
+{{template "foot"}}{{end}}`
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+func hostOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Host, "www.")
+}
+
+func tagSlug(tag string) string {
+	s := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(tag), "-"), "-")
+	if s == "" {
+		s = hash(tag)[:8]
+	}
+	return s
+}
+
+// renderSite writes the whole archive as a static HTML site into cfg.Dir
+func renderSite(archive *Archive, cfg SiteConfig) error {
+	items, err := archive.All()
+	if err != nil {
+		return err
+	}
+
+	title := cfg.Title
+	if title == "" {
+		title = "RSS digest"
+	}
+
+	// Newest first; an item re-sent later replaces its older copy
+	pages := []*sitePage{}
+	bySlug := map[string]bool{}
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		slug := item.ID
+		if len(slug) > 16 {
+			slug = slug[:16]
+		}
+		if bySlug[slug] {
+			continue
+		}
+		bySlug[slug] = true
+		pages = append(pages, &sitePage{
+			ArchivedItem: item,
+			Slug:         slug,
+			Body:         template.HTML(convertToTelegramHTML(item.Summary)),
+			Description:  summaryExcerpt(item.Summary, 200),
+		})
+	}
+
+	var days []*siteDay
+	tags := map[string]*siteTag{}
+	for _, p := range pages {
+		date := p.SentAt.Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, &siteDay{Date: date})
+		}
+		days[len(days)-1].Pages = append(days[len(days)-1].Pages, p)
+
+		for _, tag := range p.Tags {
+			slug := tagSlug(tag)
+			if tags[slug] == nil {
+				tags[slug] = &siteTag{Name: tag, Slug: slug}
+			}
+			tags[slug].Pages = append(tags[slug].Pages, p)
+		}
+	}
+	tagList := make([]*siteTag, 0, len(tags))
+	for _, t := range tags {
+		tagList = append(tagList, t)
+	}
+	sort.Slice(tagList, func(i, j int) bool { return tagList[i].Name < tagList[j].Name })
+
+	// Start items/ and tags/ from scratch so purged items don't linger
+	for _, dir := range []string{filepath.Join(cfg.Dir, "items"), filepath.Join(cfg.Dir, "tags")} {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+	}
+
+	render := func(path, name, root string, data map[string]any) error {
+		funcs := template.FuncMap{
+			"tagSlug": tagSlug,
+			"host":    hostOf,
+			"root":    func() string { return root },
+		}
+		tmpl, err := template.New("site").Funcs(funcs).Parse(siteLayout)
+		if err != nil {
+			return err
+		}
+		data["SiteTitle"] = title
+		data["Root"] = root
+		if data["PageTitle"] == nil {
+			data["PageTitle"] = title
+		}
+		if data["OGTitle"] == nil {
+			data["OGTitle"] = data["PageTitle"]
+		}
+		if data["OGType"] == nil {
+			data["OGType"] = "website"
+		}
+		data["URL"] = siteURL(cfg.BaseURL, path)
+
+		f, err := os.Create(filepath.Join(cfg.Dir, path))
+		if err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+		defer f.Close()
+		if err := tmpl.ExecuteTemplate(f, name, data); err != nil {
+			return fmt.Errorf("site %s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := render("index.html", "index", "", map[string]any{"Days": days, "Description": "Summaries of " + title}); err != nil {
+		return err
+	}
+	for _, p := range pages {
+		err := render(filepath.Join("items", p.Slug+".html"), "item", "../", map[string]any{
+			"Page":        p,
+			"PageTitle":   p.Title + " · " + title,
+			"OGTitle":     p.Title,
+			"OGType":      "article",
+			"Description": p.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range tagList {
+		err := render(filepath.Join("tags", t.Slug+".html"), "tag", "../", map[string]any{
+			"Tag":       t,
+			"PageTitle": "#" + t.Name + " · " + title,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := render(filepath.Join("tags", "index.html"), "tagindex", "../", map[string]any{"Tags": tagList}); err != nil {
+		return err
+	}
+
+	if err := writeSiteFeed(cfg, title, pages); err != nil {
+		return err
+	}
+	if cfg.BaseURL == "" {
+		fmt.Println("⚠️  site.base_url is not set, skipping sitemap.xml")
+	} else {
+		if err := writeSitemap(cfg, pages, tagList); err != nil {
+			return err
+		}
+		robots := "User-agent: *\nAllow: /\nSitemap: " + siteURL(cfg.BaseURL, "sitemap.xml") + "\n"
+		if err := os.WriteFile(filepath.Join(cfg.Dir, "robots.txt"), []byte(robots), 0644); err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+	}
+
+	// Serve files as-is on GitHub Pages instead of running them through Jekyll
+	return os.WriteFile(filepath.Join(cfg.Dir, ".nojekyll"), nil, 0644)
+}
+
+// siteURL turns a site-relative path into an absolute URL, or "" without a base URL
+func siteURL(base, path string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/" + filepath.ToSlash(path)
+}
+
+var markdownMarks = regexp.MustCompile(`\*\*|__|[*_` + "`" + `]`)
+
+// summaryExcerpt flattens a markdown summary into at most n runes of plain text
+func summaryExcerpt(summary string, n int) string {
+	text := strings.Join(strings.Fields(markdownMarks.ReplaceAllString(summary, "")), " ")
+	text = strings.TrimPrefix(text, "Summary: ")
+	if cut := truncate(text, n-1, ""); cut != text {
+		text = strings.TrimSpace(cut) + "…"
+	}
+	return text
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+func writeSitemap(cfg SiteConfig, pages []*sitePage, tags []*siteTag) error {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	lastmod := ""
+	if len(pages) > 0 {
+		lastmod = pages[0].SentAt.Format("2006-01-02")
+	}
+	set.URLs = append(set.URLs,
+		sitemapURL{Loc: siteURL(cfg.BaseURL, "index.html"), LastMod: lastmod},
+		sitemapURL{Loc: siteURL(cfg.BaseURL, "tags/index.html"), LastMod: lastmod})
+	for _, t := range tags {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     siteURL(cfg.BaseURL, "tags/"+t.Slug+".html"),
+			LastMod: t.Pages[0].SentAt.Format("2006-01-02"),
+		})
+	}
+	for _, p := range pages {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     siteURL(cfg.BaseURL, "items/"+p.Slug+".html"),
+			LastMod: p.SentAt.Format("2006-01-02"),
+		})
+	}
+
+	return writeXMLFile(filepath.Join(cfg.Dir, "sitemap.xml"), set)
+}
+
+type siteFeedItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+type siteFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title       string         `xml:"title"`
+		Link        string         `xml:"link"`
+		Description string         `xml:"description"`
+		Items       []siteFeedItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// writeSiteFeed publishes the 50 newest summaries as the site's own RSS feed
+func writeSiteFeed(cfg SiteConfig, title string, pages []*sitePage) error {
+	feed := siteFeed{Version: "2.0"}
+	feed.Channel.Title = title
+	feed.Channel.Link = siteURL(cfg.BaseURL, "index.html")
+	feed.Channel.Description = "Summaries of " + title
+
+	for i, p := range pages {
+		if i == 50 {
+			break
+		}
+		link := siteURL(cfg.BaseURL, "items/"+p.Slug+".html")
+		if link == "" {
+			link = p.Link
+		}
+		feed.Channel.Items = append(feed.Channel.Items, siteFeedItem{
+			Title:       p.Title,
+			Link:        link,
+			GUID:        link,
+			PubDate:     p.SentAt.Format(time.RFC1123Z),
+			Description: p.Description,
+			Categories:  p.Tags,
+		})
+	}
+
+	return writeXMLFile(filepath.Join(cfg.Dir, "feed.xml"), feed)
+}
+
+func writeXMLFile(path string, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("site %s: %w", path, err)
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+// runSiteBuild implements `rss site build [-dir path]`
+func runSiteBuild(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("site build", flag.ExitOnError)
+	dir := fs.String("dir", cfg.Site.Dir, "output directory")
+	if len(args) == 0 || args[0] != "build" {
+		fmt.Println("Usage: rss site build [-dir path]")
+		return 2
+	}
+	fs.Parse(args[1:])
+
+	if *dir == "" {
+		fmt.Println("Missing output directory: set site.dir in the config or pass -dir")
+		return 2
+	}
+
+	site := cfg.Site
+	site.Dir = *dir
+	if err := renderSite(openArchive(cfg.Archive), site); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	fmt.Printf("🌍 Site written to %s\n", site.Dir)
+	return 0
+}
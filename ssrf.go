@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// Feeds carry links chosen by whoever writes them, so fetches (feeds,
+// articles, robots.txt) refuse addresses inside the network the bot runs in:
+// loopback, private, link-local (cloud metadata at 169.254.169.254) and
+// carrier-grade NAT ranges. fetch.allow_networks lets chosen ranges through,
+// e.g. for self-hosted feeds; it is global because connections are pooled.
+// Direct connections are checked when dialed, so a name that resolves
+// differently the second time can't slip through; requests through a proxy
+// are checked by resolving the host first.
+
+// Carrier-grade NAT, not covered by netip.Addr.IsPrivate
+var CGNAT_PREFIX = netip.MustParsePrefix("100.64.0.0/10")
+
+type dialGuardKey struct{}
+
+// internalAddr reports whether a is loopback, private, link-local or otherwise not on the internet
+func internalAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	return a.IsLoopback() || a.IsPrivate() || a.IsLinkLocalUnicast() || a.IsLinkLocalMulticast() ||
+		a.IsInterfaceLocalMulticast() || a.IsMulticast() || a.IsUnspecified() || CGNAT_PREFIX.Contains(a)
+}
+
+// parseNetworks reads CIDRs or bare addresses, skipping bad entries (validate reports them)
+func parseNetworks(entries []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, e := range entries {
+		if p, err := parseNetwork(e); err == nil {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+func parseNetwork(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		p, err := netip.ParsePrefix(entry)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// checkAddr refuses internal addresses outside allow_networks
+func (o FetchConfig) checkAddr(host string, a netip.Addr) error {
+	a = a.Unmap()
+	if !internalAddr(a) {
+		return nil
+	}
+	for _, p := range parseNetworks(o.AllowNetworks) {
+		if p.Contains(a) {
+			return nil
+		}
+	}
+	if host != a.String() {
+		host += " (" + a.String() + ")"
+	}
+	return fmt.Errorf("refusing to fetch from internal address %s; add it to fetch.allow_networks to permit it", host)
+}
+
+// checkHost resolves host and checks every address it has; a host that
+// doesn't resolve here is left to the proxy
+func (o FetchConfig) checkHost(ctx context.Context, host string) error {
+	if a, err := netip.ParseAddr(host); err == nil {
+		return o.checkAddr(host, a)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, a := range addrs {
+		if err := o.checkAddr(host, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardRequest checks a fetch before it is sent: a proxied request by
+// resolving its host now, a direct one by marking the context for guardDial
+func guardRequest(req *http.Request, opts FetchConfig) (*http.Request, error) {
+	proxied := opts.Proxy != ""
+	if !proxied && baseTransport.Proxy != nil {
+		u, err := baseTransport.Proxy(req)
+		proxied = err == nil && u != nil
+	}
+	if proxied {
+		return req, opts.checkHost(req.Context(), req.URL.Hostname())
+	}
+	return req.WithContext(context.WithValue(req.Context(), dialGuardKey{}, req.URL.Hostname())), nil
+}
+
+// guardDial is the dialers' control hook: it runs once the address is
+// resolved and before connecting, for connections guardRequest marked
+func guardDial(ctx context.Context, network, address string, _ syscall.RawConn) error {
+	host, ok := ctx.Value(dialGuardKey{}).(string)
+	if !ok {
+		return nil
+	}
+	opts, _ := fetchOptsFrom(ctx)
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return nil
+	}
+	return opts.checkAddr(host, ap.Addr())
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	STATE_JSON  = "json"  // state.json rewritten as items are sent (default)
+	STATE_BOLT  = "bolt"  // bbolt file, one transaction per posted item
+	STATE_REDIS = "redis" // shared between instances, items claimed with SETNX
+	STATE_S3    = "s3"    // state.json in an S3 object, conditional put on save
+	STATE_GCS   = "gcs"   // state.json in a GCS object, conditional put on save
+)
+
+const STATE_BOLT_FILE = "state.db"
+
+type StateConfig struct {
+	Backend string `yaml:"backend"` // json, bolt or redis
+	Path    string `yaml:"path"`    // default state.json / state.db
+	URL     string `yaml:"url"`     // redis://[user:pass@]host:6379/0 (rediss:// for TLS), s3://bucket/key or gs://bucket/object
+	Prefix  string `yaml:"prefix"`  // redis key prefix, default "rss:seen:"
+	// json, s3 and gcs rewrite the whole state; do it every N sent items so a
+	// killed run doesn't lose its progress. Default 1, after each item.
+	SaveEvery int `yaml:"save_every"`
+	// What a run does when another one still holds the run lock: skip (default),
+	// wait, or none to run without a lock
+	Lock string `yaml:"lock"`
+}
+
+func (c StateConfig) saveEvery() int {
+	if c.SaveEvery > 0 {
+		return c.SaveEvery
+	}
+	return 1
+}
+
+// StateStore remembers which items have already been posted
+type StateStore interface {
+	Seen(id string) (bool, error)
+	Mark(id string, entry StateEntry) error
+	// Prune forgets items sent before cutoff and reports how many were dropped
+	Prune(cutoff time.Time) (int, error)
+	// Each calls fn for every recorded item
+	Each(fn func(id string, entry StateEntry) error) error
+	// FeedStatus is the fetch history of a feed, zero if it was never fetched
+	FeedStatus(feedURL string) (FeedStatus, error)
+	SetFeedStatus(feedURL string, status FeedStatus) error
+	Close() error
+}
+
+// StateEntry is what the state keeps about a sent item, so a hash can be traced
+// back to its article and post
+type StateEntry struct {
+	Title     string             `json:"title,omitempty"`
+	Link      string             `json:"link,omitempty"`
+	Feed      string             `json:"feed,omitempty"`
+	Summary   string             `json:"summary,omitempty"`
+	Scores    map[string]float64 `json:"scores,omitempty"`
+	ChatID    string             `json:"chat_id,omitempty"`
+	MessageID int64              `json:"message_id,omitempty"`
+	Skipped   string             `json:"skipped,omitempty"` // why the item was marked seen without a post
+	SentAt    time.Time          `json:"sent_at"`
+}
+
+// stamped fills in the time an entry is recorded
+func (e StateEntry) stamped() StateEntry {
+	if e.SentAt.IsZero() {
+		e.SentAt = time.Now().UTC()
+	}
+	return e
+}
+
+// stateClaimer is implemented by stores shared between instances. An item is
+// claimed before it is processed so only one instance posts it; a claim that
+// never becomes a Mark expires or is released.
+type stateClaimer interface {
+	Claim(id string) (bool, error)
+	Release(id string) error
+}
+
+// claimItem claims id for this run on shared stores, once the item is about
+// to be worked on so the claim outlives the work; other stores have nothing
+// to claim, their items having been checked against state already
+func claimItem(store StateStore, id string) (bool, error) {
+	if c, ok := store.(stateClaimer); ok {
+		return c.Claim(id)
+	}
+	return true, nil
+}
+
+// releaseItem hands an item that wasn't sent back to other instances
+func releaseItem(store StateStore, id string) {
+	if c, ok := store.(stateClaimer); ok {
+		if err := c.Release(id); err != nil {
+			fmt.Printf("⚠️  Releasing claim failed: %v\n", err)
+		}
+	}
+}
+
+// pruneState applies retention.state_days, if set
+func pruneState(store StateStore, days int) {
+	if days <= 0 {
+		return
+	}
+	removed, err := store.Prune(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		fmt.Printf("⚠️  Pruning state failed: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		fmt.Printf("🧹 Pruned %d state entries older than %d days\n", removed, days)
+	}
+}
+
+func openStateStore(cfg StateConfig) (StateStore, error) {
+	switch cfg.Backend {
+	case "", STATE_JSON:
+		path := cfg.Path
+		if path == "" {
+			path = STATE_FILE
+		}
+		return &jsonState{path: path, file: loadState(path), every: cfg.saveEvery()}, nil
+	case STATE_BOLT:
+		path := cfg.Path
+		if path == "" {
+			path = STATE_BOLT_FILE
+		}
+		return openBoltState(path)
+	case STATE_REDIS:
+		return openRedisState(cfg)
+	case STATE_S3, STATE_GCS:
+		return openRemoteState(cfg)
+	default:
+		return nil, fmt.Errorf("unknown state backend %q (expected json, bolt, redis, s3 or gcs)", cfg.Backend)
+	}
+}
+
+// jsonState keeps the whole file in memory and writes it back every few marks and on Close
+type jsonState struct {
+	path    string
+	file    *stateFile
+	every   int
+	pending int // marks not yet written
+}
+
+func (s *jsonState) Seen(id string) (bool, error) {
+	_, ok := s.file.Items[id]
+	return ok, nil
+}
+
+func (s *jsonState) Mark(id string, entry StateEntry) error {
+	s.file.Items[id] = entry.stamped()
+	s.pending++
+	if s.pending < s.every {
+		return nil
+	}
+	if err := saveState(s.path, s.file); err != nil {
+		return err
+	}
+	s.pending = 0
+	return nil
+}
+
+func (s *jsonState) Prune(cutoff time.Time) (int, error) {
+	removed := pruneEntries(s.file.Items, cutoff)
+	s.pending += removed
+	return removed, nil
+}
+
+func (s *jsonState) Each(fn func(id string, entry StateEntry) error) error {
+	return eachEntry(s.file.Items, fn)
+}
+
+func (s *jsonState) FeedStatus(feedURL string) (FeedStatus, error) {
+	return s.file.Feeds[feedURL], nil
+}
+
+// SetFeedStatus is saved with the next batch of marks or on Close
+func (s *jsonState) SetFeedStatus(feedURL string, status FeedStatus) error {
+	s.file.Feeds[feedURL] = status
+	return nil
+}
+
+func (s *jsonState) Close() error {
+	return saveState(s.path, s.file)
+}
+
+func eachEntry(seen map[string]StateEntry, fn func(id string, entry StateEntry) error) error {
+	for id, entry := range seen {
+		if err := fn(id, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pruneEntries(seen map[string]StateEntry, cutoff time.Time) int {
+	removed := 0
+	for id, entry := range seen {
+		if entry.SentAt.Before(cutoff) {
+			delete(seen, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+var (
+	boltSeenBucket  = []byte("seen")
+	boltFeedsBucket = []byte("feeds")
+)
+
+// boltState commits every Mark immediately, so a crash mid-run loses nothing
+type boltState struct {
+	db *bolt.DB
+}
+
+func openBoltState(path string) (*boltState, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open state %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltFeedsBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(boltSummariesBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists(boltSeenBucket)
+		if err != nil {
+			return err
+		}
+		if b.Stats().KeyN > 0 {
+			return nil
+		}
+		// First start: carry over what the JSON state already knows
+		imported := loadState(STATE_FILE).Items
+		for id, entry := range imported {
+			data, _ := json.Marshal(entry)
+			if err := b.Put([]byte(id), data); err != nil {
+				return err
+			}
+		}
+		if len(imported) > 0 {
+			fmt.Printf("📦 Imported %d item(s) from %s into %s\n", len(imported), STATE_FILE, path)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open state %s: %w", path, err)
+	}
+	return &boltState{db: db}, nil
+}
+
+func (s *boltState) Seen(id string) (bool, error) {
+	seen := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		seen = tx.Bucket(boltSeenBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	return seen, err
+}
+
+func (s *boltState) Mark(id string, entry StateEntry) error {
+	data, _ := json.Marshal(entry.stamped())
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSeenBucket).Put([]byte(id), data)
+	})
+}
+
+// boltEntry decodes a stored value; older databases kept only the RFC 3339 send time
+func boltEntry(v []byte) (StateEntry, error) {
+	var entry StateEntry
+	if sentAt, err := time.Parse(time.RFC3339, string(v)); err == nil {
+		entry.SentAt = sentAt
+		return entry, nil
+	}
+	err := json.Unmarshal(v, &entry)
+	return entry, err
+}
+
+func (s *boltState) Prune(cutoff time.Time) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSeenBucket)
+		// Deleting while iterating makes the cursor skip keys, so collect first
+		var expired [][]byte
+		bucket.ForEach(func(k, v []byte) error {
+			if entry, err := boltEntry(v); err == nil && entry.SentAt.Before(cutoff) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	return removed, err
+}
+
+func (s *boltState) Each(fn func(id string, entry StateEntry) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSeenBucket).ForEach(func(k, v []byte) error {
+			entry, err := boltEntry(v)
+			if err != nil {
+				return fmt.Errorf("entry %s: %w", k, err)
+			}
+			return fn(string(k), entry)
+		})
+	})
+}
+
+func (s *boltState) FeedStatus(feedURL string) (FeedStatus, error) {
+	var status FeedStatus
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltFeedsBucket).Get([]byte(feedURL)); v != nil {
+			return json.Unmarshal(v, &status)
+		}
+		return nil
+	})
+	return status, err
+}
+
+func (s *boltState) SetFeedStatus(feedURL string, status FeedStatus) error {
+	data, _ := json.Marshal(status)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltFeedsBucket).Put([]byte(feedURL), data)
+	})
+}
+
+func (s *boltState) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// How long a claim blocks other instances if its owner dies before posting
+const REDIS_CLAIM_TTL = 10 * time.Minute
+
+// Value of a key while an instance works on the item; once sent it holds the
+// StateEntry as JSON (or "sent", written by older versions)
+const redisClaimed = "claimed"
+
+// redisState lets several instances (e.g. one per region) dedup against one store
+type redisState struct {
+	client *redis.Client
+	prefix string
+}
+
+func openRedisState(cfg StateConfig) (*redisState, error) {
+	client, err := redisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &redisState{client: client, prefix: cfg.redisPrefix()}, nil
+}
+
+func (c StateConfig) redisPrefix() string {
+	if c.Prefix == "" {
+		return "rss:seen:"
+	}
+	return c.Prefix
+}
+
+// redisClient connects to state.url and checks the server is reachable
+func redisClient(cfg StateConfig) (*redis.Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("state.url is required for the redis backend")
+	}
+	opts, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("state.url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis state: %w", err)
+	}
+	return client, nil
+}
+
+func (s *redisState) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 10*time.Second)
+}
+
+func (s *redisState) Seen(id string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	n, err := s.client.Exists(ctx, s.prefix+id).Result()
+	return n > 0, err
+}
+
+// Claim takes the item for this instance; false means it was sent or is being sent elsewhere
+func (s *redisState) Claim(id string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	return s.client.SetNX(ctx, s.prefix+id, redisClaimed, REDIS_CLAIM_TTL).Result()
+}
+
+func (s *redisState) Release(id string) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	// Only drop our own claim, never a finished item
+	return s.client.Eval(ctx, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`,
+		[]string{s.prefix + id}, redisClaimed).Err()
+}
+
+func (s *redisState) Mark(id string, entry StateEntry) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	data, _ := json.Marshal(entry.stamped())
+	return s.client.Set(ctx, s.prefix+id, data, 0).Err()
+}
+
+// Prune can't tell how old a key is, so it gives every sent key without an
+// expiry one that ends when the retention window would; Redis drops them then
+func (s *redisState) Prune(cutoff time.Time) (int, error) {
+	ttl := time.Since(cutoff)
+	if ttl <= 0 {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	scheduled := 0
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if s.client.TTL(ctx, key).Val() != -1 || s.client.Get(ctx, key).Val() == redisClaimed {
+			continue
+		}
+		if err := s.client.Expire(ctx, key, ttl).Err(); err != nil {
+			return scheduled, err
+		}
+		scheduled++
+	}
+	return scheduled, iter.Err()
+}
+
+// Each skips items that are only claimed; entries from older versions carry no details
+func (s *redisState) Each(fn func(id string, entry StateEntry) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := s.client.Get(ctx, key).Result()
+		if err == redis.Nil || value == redisClaimed {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var entry StateEntry
+		if value != "sent" {
+			if err := json.Unmarshal([]byte(value), &entry); err != nil {
+				return fmt.Errorf("entry %s: %w", key, err)
+			}
+		}
+		if err := fn(strings.TrimPrefix(key, s.prefix), entry); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// feedsKey is a hash of feed URL to FeedStatus, outside the item prefix
+func (s *redisState) feedsKey() string {
+	return "rss:feeds:" + s.prefix
+}
+
+func (s *redisState) FeedStatus(feedURL string) (FeedStatus, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	var status FeedStatus
+	data, err := s.client.HGet(ctx, s.feedsKey(), feedURL).Bytes()
+	if err == redis.Nil {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	return status, json.Unmarshal(data, &status)
+}
+
+func (s *redisState) SetFeedStatus(feedURL string, status FeedStatus) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	data, _ := json.Marshal(status)
+	return s.client.HSet(ctx, s.feedsKey(), feedURL, data).Err()
+}
+
+func (s *redisState) Close() error {
+	return s.client.Close()
+}
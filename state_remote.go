@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth/credentials"
+)
+
+// remoteState keeps state.json in an S3 or GCS object, for runners with an
+// ephemeral filesystem such as GitHub Actions. The object is read at startup and
+// written back every save_every marks and on Close, each time only if nobody
+// else changed it in between; on a race the other writer's entries are merged
+// in and the write is retried.
+type remoteState struct {
+	url     string
+	get     func(url string) ([]byte, string, error)
+	put     func(url string, data []byte, version string) (string, error)
+	version string // ETag or generation of our last read or write, empty if the object didn't exist
+	file    *stateFile
+	every   int
+	pending int // marks not yet written
+
+	dirtyFeeds bool // feed status changed since the last write
+}
+
+func openRemoteState(cfg StateConfig) (*remoteState, error) {
+	s := &remoteState{url: cfg.URL, every: cfg.saveEvery()}
+	switch {
+	case strings.HasPrefix(cfg.URL, "s3://"):
+		s.get, s.put = s3Get, s3Put
+	case strings.HasPrefix(cfg.URL, "gs://"):
+		s.get, s.put = gcsGet, gcsPut
+	default:
+		return nil, fmt.Errorf("state.url must be s3://bucket/key or gs://bucket/object, got %q", cfg.URL)
+	}
+
+	file, version, err := s.load()
+	if err != nil {
+		return nil, fmt.Errorf("remote state %s: %w", cfg.URL, err)
+	}
+	s.file, s.version = file, version
+	fmt.Printf("☁️  Loaded %d state entries from %s\n", len(file.Items), cfg.URL)
+	return s, nil
+}
+
+func (s *remoteState) load() (*stateFile, string, error) {
+	data, version, err := s.get(s.url)
+	if errors.Is(err, errObjectNotFound) {
+		return newStateFile(), "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := parseState(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse failed: %w", err)
+	}
+	return file, version, nil
+}
+
+func (s *remoteState) Seen(id string) (bool, error) {
+	_, ok := s.file.Items[id]
+	return ok, nil
+}
+
+func (s *remoteState) Mark(id string, entry StateEntry) error {
+	s.file.Items[id] = entry.stamped()
+	s.pending++
+	if s.pending >= s.every {
+		return s.flush()
+	}
+	return nil
+}
+
+// Prune drops old entries locally; they are written back with the next save.
+// An entry the other writer still has comes back if a race forces a merge.
+func (s *remoteState) Prune(cutoff time.Time) (int, error) {
+	removed := pruneEntries(s.file.Items, cutoff)
+	s.pending += removed
+	return removed, nil
+}
+
+func (s *remoteState) Each(fn func(id string, entry StateEntry) error) error {
+	return eachEntry(s.file.Items, fn)
+}
+
+func (s *remoteState) FeedStatus(feedURL string) (FeedStatus, error) {
+	return s.file.Feeds[feedURL], nil
+}
+
+// SetFeedStatus is saved with the next batch of marks or on Close
+func (s *remoteState) SetFeedStatus(feedURL string, status FeedStatus) error {
+	s.file.Feeds[feedURL] = status
+	s.dirtyFeeds = true
+	return nil
+}
+
+func (s *remoteState) Close() error {
+	return s.flush()
+}
+
+func (s *remoteState) flush() error {
+	if s.pending == 0 && !s.dirtyFeeds {
+		return nil
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		data, _ := json.MarshalIndent(s.file, "", "  ")
+		version, err := s.put(s.url, data, s.version)
+		if err == nil {
+			s.version = version
+			s.pending = 0
+			s.dirtyFeeds = false
+			return nil
+		}
+		if !errors.Is(err, errPreconditionFailed) {
+			return err
+		}
+
+		// Another run saved in the meantime: keep its entries too and try again
+		theirs, version, err := s.load()
+		if err != nil {
+			return err
+		}
+		for id, entry := range theirs.Items {
+			if _, ok := s.file.Items[id]; !ok {
+				s.file.Items[id] = entry
+			}
+		}
+		for feedURL, status := range theirs.Feeds {
+			if _, ok := s.file.Feeds[feedURL]; !ok {
+				s.file.Feeds[feedURL] = status
+			}
+		}
+		s.version = version
+		fmt.Printf("🔁 State at %s changed during the run, merged %d entries and retrying\n", s.url, len(theirs.Items))
+	}
+	return fmt.Errorf("remote state %s: gave up after repeated concurrent updates", s.url)
+}
+
+// gcsObject splits gs://bucket/object
+func gcsObject(gsurl string) (string, string, error) {
+	u, err := url.Parse(gsurl)
+	if err != nil || u.Scheme != "gs" || u.Host == "" || len(u.Path) < 2 {
+		return "", "", fmt.Errorf("bad gcs url: %s", gsurl)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+func gcsToken(ctx context.Context) (string, error) {
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/devstorage.read_write"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("gcp credentials: %w", err)
+	}
+	token, err := creds.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("gcp credentials: %w", err)
+	}
+	return token.Value, nil
+}
+
+// gcsGet downloads an object, returning its body and generation
+func gcsGet(gsurl string) ([]byte, string, error) {
+	bucket, object, err := gcsObject(gsurl)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(withPurpose(context.Background(), "gcs"), 30*time.Second)
+	defer cancel()
+
+	token, err := gcsToken(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	target := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media", url.PathEscape(bucket), url.PathEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errObjectNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("bad status: %d %s", resp.StatusCode, string(body))
+	}
+	return body, resp.Header.Get("X-Goog-Generation"), nil
+}
+
+// gcsPut uploads an object only if it is still at generation, or doesn't exist
+// when generation is empty, and returns the new generation
+func gcsPut(gsurl string, data []byte, generation string) (string, error) {
+	bucket, object, err := gcsObject(gsurl)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(withPurpose(context.Background(), "gcs"), 30*time.Second)
+	defer cancel()
+
+	token, err := gcsToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if generation == "" {
+		generation = "0" // only create
+	}
+	target := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s&ifGenerationMatch=%s",
+		url.PathEscape(bucket), url.QueryEscape(object), url.QueryEscape(generation))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return "", errPreconditionFailed
+	case resp.StatusCode >= 300:
+		rb, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload failed: %d %s", resp.StatusCode, string(rb))
+	}
+	var uploaded struct {
+		Generation string `json:"generation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil {
+		return "", fmt.Errorf("upload response: %w", err)
+	}
+	return uploaded.Generation, nil
+}
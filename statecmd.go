@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseStateSpec reads a "backend[:location]" flag value; the location is a
+// path for json and bolt and a URL for redis, s3 and gcs
+func parseStateSpec(spec string) (StateConfig, error) {
+	backend, location, _ := strings.Cut(spec, ":")
+	cfg := StateConfig{Backend: backend}
+	switch backend {
+	case STATE_JSON, STATE_BOLT:
+		cfg.Path = location
+	case STATE_REDIS, STATE_S3, STATE_GCS:
+		if location == "" {
+			return cfg, fmt.Errorf("%s needs a url, e.g. %s:%s", backend, backend, map[string]string{
+				STATE_REDIS: "redis://localhost:6379/0",
+				STATE_S3:    "s3://bucket/state.json",
+				STATE_GCS:   "gs://bucket/state.json",
+			}[backend])
+		}
+		cfg.URL = location
+	default:
+		return cfg, fmt.Errorf("unknown state backend %q (expected json, bolt, redis, s3 or gcs)", backend)
+	}
+	return cfg, nil
+}
+
+// migrateState copies every entry of from into to, keeping send times
+func migrateState(from, to StateStore) (int, error) {
+	copied := 0
+	err := from.Each(func(id string, entry StateEntry) error {
+		if err := to.Mark(id, entry); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
+
+func statePrune(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("state prune", flag.ExitOnError)
+	days := fs.Int("days", cfg.Retention.StateDays, "forget items sent more than this many days ago")
+	fs.Parse(args)
+	if *days <= 0 {
+		fmt.Println("Set retention.state_days or pass -days N")
+		return 2
+	}
+
+	store, err := openStateStore(cfg.State)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	removed, err := store.Prune(time.Now().AddDate(0, 0, -*days))
+	if cerr := store.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	fmt.Printf("🧹 Pruned %d state entries older than %d days\n", removed, *days)
+	return 0
+}
+
+func stateMigrate(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("state migrate", flag.ExitOnError)
+	fromSpec := fs.String("from", "", "source backend[:path or url] (default: the configured state)")
+	toSpec := fs.String("to", "", "target backend[:path or url], e.g. bolt:state.db or redis:redis://host:6379/0")
+	fs.Parse(args)
+	if *toSpec == "" {
+		fmt.Println("Usage: rss state migrate [-from backend[:location]] -to backend[:location]")
+		return 2
+	}
+
+	fromCfg := cfg.State
+	if *fromSpec != "" {
+		var err error
+		if fromCfg, err = parseStateSpec(*fromSpec); err != nil {
+			fmt.Printf("⚠️  -from: %v\n", err)
+			return 2
+		}
+	}
+	toCfg, err := parseStateSpec(*toSpec)
+	if err != nil {
+		fmt.Printf("⚠️  -to: %v\n", err)
+		return 2
+	}
+	// Batch writes to whole-file backends; they are saved once on Close anyway
+	toCfg.SaveEvery = 1 << 30
+
+	from, err := openStateStore(fromCfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	defer from.Close()
+	to, err := openStateStore(toCfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+
+	copied, err := migrateState(from, to)
+	if cerr := to.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Migration failed after %d item(s): %v\n", copied, err)
+		return 1
+	}
+	fmt.Printf("📦 Copied %d item(s) to %s; switch state.backend before the next run\n", copied, *toSpec)
+	return 0
+}
+
+// runStateCommand implements `rss state prune|migrate`
+func runStateCommand(cfg *Config, args []string) int {
+	usage := "Usage: rss state prune [-days N]\n       rss state migrate [-from backend[:location]] -to backend[:location]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "prune":
+		return statePrune(cfg, args[1:])
+	case "migrate":
+		return stateMigrate(cfg, args[1:])
+	default:
+		fmt.Println(usage)
+		return 2
+	}
+}
+
+// markSeen records the items not yet in state as seen, skipped for reason,
+// and returns how many it recorded
+func markSeen(store StateStore, feedURL string, items []Item, reason string) int {
+	n := 0
+	for _, item := range items {
+		seen, err := seenLink(store, item.Link)
+		if err != nil {
+			fmt.Printf("⚠️  State lookup failed: %v\n", err)
+			continue
+		}
+		if seen {
+			continue
+		}
+		if err := store.Mark(itemID(item.Link), StateEntry{Title: item.Title, Link: item.Link, Feed: feedURL, Skipped: reason}); err != nil {
+			fmt.Printf("⚠️  Could not record item as seen: %v\n", err)
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// noneSeen reports whether none of items is recorded in state yet. A failed
+// lookup counts as seen, so an unreachable store never hides new items.
+func noneSeen(store StateStore, items []Item) bool {
+	for _, item := range items {
+		if seen, err := seenLink(store, item.Link); err != nil || seen {
+			return false
+		}
+	}
+	return true
+}
+
+// runMarkSeen implements `rss mark-seen [-feed url]`: every item currently in
+// the feeds is recorded as seen without posting, so a new channel or feed
+// starts with what is published from now on
+func runMarkSeen(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("mark-seen", flag.ExitOnError)
+	only := fs.String("feed", "", "only this feed URL (default: all configured feeds)")
+	fs.Parse(args)
+
+	unlock, err := acquireRunLock(cfg.State)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+	defer unlock()
+
+	store, err := openStateStore(cfg.State)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+
+	marked, failed, matched := 0, 0, false
+	for _, feed := range cfg.Feeds {
+		if *only != "" && feedKey(feed.URL) != feedKey(*only) {
+			continue
+		}
+		matched = true
+
+		rss, err := fetchRSS(feed.URL, cfg.fetchFor(feed))
+		if err != nil {
+			fmt.Printf("⚠️  RSS feed failed (%s): %v\n", feed.URL, err)
+			failed++
+			continue
+		}
+		n := markSeen(store, feed.URL, rss.Channel.Items, "mark-seen")
+		fmt.Printf("📡 %s: %d of %d item(s) marked seen\n", feed.URL, n, len(rss.Channel.Items))
+		marked += n
+	}
+
+	if err := store.Close(); err != nil {
+		fmt.Printf("⚠️  Saving state failed: %v\n", err)
+		return 1
+	}
+	if *only != "" && !matched {
+		fmt.Printf("⚠️  %s is not a configured feed\n", *only)
+		return 1
+	}
+	fmt.Printf("✅ Marked %d item(s) as seen\n", marked)
+	if failed > 0 {
+		fmt.Printf("⚠️  %d feed(s) could not be fetched; their items will be posted on the next run\n", failed)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"google.golang.org/genai"
+)
+
+// StoriesConfig skips items that tell a story already posted in the last
+// hours under another link, e.g. the same launch on several news sites.
+// Items are compared by embeddings of their title and opening text, before
+// they are summarized, so duplicates cost no summary. With combine, the post
+// of the story, which has the first source's summary, also links the others.
+// Stories are told apart per chat: a story posted to one channel may still
+// go to another.
+type StoriesConfig struct {
+	Embedder  string  `yaml:"embedder"`  // e.g. googleai/gemini-embedding-001 or ollama/nomic-embed-text; empty turns it off
+	Threshold float64 `yaml:"threshold"` // cosine similarity from which items are the same story; default 0.88
+	Hours     int     `yaml:"hours"`     // how long posted items are compared against; default 48
+	File      string  `yaml:"file"`      // default embeddings.json
+	Combine   bool    `yaml:"combine"`   // add "Also covered by" links to the story's post
+}
+
+const (
+	EMBEDDINGS_FILE   = "embeddings.json"
+	STORIES_THRESHOLD = 0.88
+	STORIES_HOURS     = 48
+
+	// Gemini embedders are asked for this many dimensions, plenty to tell
+	// stories apart, instead of up to 3072
+	GEMINI_EMBEDDING_DIMENSIONS = 768
+)
+
+func (c StoriesConfig) threshold() float64 {
+	return cmp.Or(c.Threshold, STORIES_THRESHOLD)
+}
+
+// since is the oldest post still compared against
+func (c StoriesConfig) since() time.Time {
+	return time.Now().Add(-time.Duration(cmp.Or(c.Hours, STORIES_HOURS)) * time.Hour)
+}
+
+// embeddedItem is a posted item and the embedding it was compared by
+type embeddedItem struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	Feed      string    `json:"feed"`
+	ChatID    string    `json:"chat_id,omitempty"`
+	Home      string    `json:"home,omitempty"` // the feed's chat; a topic may have routed the post elsewhere
+	MessageID int64     `json:"message_id,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+	Vector    []float32 `json:"vector"`
+
+	Text string   `json:"text,omitempty"` // the post as sent, to edit links in with combine
+	Also []string `json:"also,omitempty"` // links of the other sources added to it
+}
+
+// embeddingStore holds the embeddings of recent posts, saved after each run
+type embeddingStore struct {
+	mu    sync.Mutex
+	path  string
+	items []embeddedItem
+}
+
+// openEmbeddingStore loads the store; a missing file is an empty store
+func openEmbeddingStore(path string) (*embeddingStore, error) {
+	s := &embeddingStore{path: cmp.Or(path, EMBEDDINGS_FILE)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("embeddings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return s, fmt.Errorf("embeddings: %s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+// nearest is the post for chatID, the chat of its feed, since then most
+// similar to v; nil if there is none
+func (s *embeddingStore) nearest(v []float32, chatID string, since time.Time) (*embeddedItem, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best *embeddedItem
+	bestSim := -1.0
+	for i := range s.items {
+		item := &s.items[i]
+		if item.SentAt.Before(since) || cmp.Or(item.Home, item.ChatID) != chatID {
+			continue
+		}
+		if sim := cosineSimilarity(v, item.Vector); sim > bestSim {
+			best, bestSim = item, sim
+		}
+	}
+	if best == nil {
+		return nil, 0
+	}
+	found := *best
+	return &found, bestSim
+}
+
+func (s *embeddingStore) add(item embeddedItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, item)
+}
+
+// addLink records another source of a post's story and returns the post,
+// nil if it is no longer stored
+func (s *embeddingStore) addLink(id, chatID, link string) *embeddedItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.items {
+		item := &s.items[i]
+		if item.ID != id || item.ChatID != chatID {
+			continue
+		}
+		if !slices.Contains(item.Also, link) {
+			item.Also = append(item.Also, link)
+		}
+		found := *item
+		return &found
+	}
+	return nil
+}
+
+// save writes the posts since then, dropping older ones
+func (s *embeddingStore) save(since time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.items[:0]
+	for _, item := range s.items {
+		if !item.SentAt.Before(since) {
+			kept = append(kept, item)
+		}
+	}
+	s.items = kept
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return fmt.Errorf("embeddings: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("embeddings: %w", err)
+	}
+	return nil
+}
+
+// cosineSimilarity is 1 for vectors pointing the same way, 0 for unrelated
+// ones; vectors of different lengths (another embedder) don't compare
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// embedItem embeds an item's title and opening text
+func (b *Bot) embedItem(ctx context.Context, p *pendingItem) ([]float32, error) {
+	embedder := b.cfg.Stories.Embedder
+	opts := []ai.EmbedderOption{
+		ai.WithEmbedderName(embedder),
+		ai.WithTextDocs(p.item.Title + "\n\n" + truncate(p.content, 1000, "...")),
+	}
+	if modelProvider(embedder) == PROVIDER_GOOGLEAI {
+		dims := int32(GEMINI_EMBEDDING_DIMENSIONS)
+		opts = append(opts, ai.WithConfig(&genai.EmbedContentConfig{TaskType: "SEMANTIC_SIMILARITY", OutputDimensionality: &dims}))
+	}
+
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	resp, err := genkit.Embed(ctx, b.g, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(resp.Embeddings) == 0 || len(resp.Embeddings[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embedding failed: empty response")
+	}
+	return resp.Embeddings[0].Embedding, nil
+}
+
+// checkDuplicate embeds an item, unless it has been already, and reports why
+// it should be skipped as a story posted recently, or "" to go on. A failed
+// embedding lets the item through.
+func (b *Bot) checkDuplicate(ctx context.Context, p *pendingItem) string {
+	if b.embeddings == nil || p.feed.Kind == FEED_RELEASE {
+		return ""
+	}
+	if p.embedding == nil {
+		v, err := b.embedItem(ctx, p)
+		if err != nil {
+			fmt.Printf("⚠️  Duplicate check failed (%s): %v\n", p.item.Title, err)
+			return ""
+		}
+		p.embedding = v
+	}
+	cfg := b.cfg.Stories
+	match, sim := b.embeddings.nearest(p.embedding, b.cfg.chatFor(p.feed), cfg.since())
+	if match == nil {
+		return ""
+	}
+	p.decision.score("similarity", math.Round(sim*1000)/1000)
+	if sim < cfg.threshold() {
+		return ""
+	}
+	p.story = match
+	return fmt.Sprintf("same story as %q (%s), similarity %.2f", match.Title, match.Link, sim)
+}
+
+// rememberPost adds a sent item to the embeddings later items are compared against
+func (b *Bot) rememberPost(p *pendingItem, chatID string, messageID int64, text string) {
+	if b.embeddings == nil || p.embedding == nil {
+		return
+	}
+	b.embeddings.add(embeddedItem{
+		ID:        p.id,
+		Title:     p.item.Title,
+		Link:      p.item.Link,
+		Feed:      p.feed.URL,
+		ChatID:    chatID,
+		MessageID: messageID,
+		SentAt:    time.Now().UTC(),
+		Vector:    p.embedding,
+		Home:      b.cfg.chatFor(p.feed),
+		Text:      text,
+	})
+}
+
+// combineStory links a duplicate from the post of its story
+func (b *Bot) combineStory(ctx context.Context, p *pendingItem) error {
+	if !b.cfg.Stories.Combine || p.story == nil || p.story.MessageID == 0 || p.story.Text == "" {
+		return nil
+	}
+	story := b.embeddings.addLink(p.story.ID, p.story.ChatID, p.item.Link)
+	if story == nil {
+		return nil
+	}
+	text := story.Text + alsoCovered(story.Also)
+	if telegramTextLen(text) > TELEGRAM_MESSAGE_LIMIT {
+		return nil // the story has links enough
+	}
+	err := telegramCall(ctx, b.cfg.Telegram.Token, "editMessageText", map[string]any{
+		"chat_id":                  story.ChatID,
+		"message_id":               story.MessageID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}, nil)
+	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return fmt.Errorf("combining with %q failed: %w", story.Title, err)
+	}
+	fmt.Printf("   🧩 Added to the post of %q\n", story.Title)
+	return nil
+}
+
+// alsoCovered is the line listing a story's other sources by site
+func alsoCovered(links []string) string {
+	var sites []string
+	for _, link := range links {
+		site := link
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			site = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+		sites = append(sites, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link), html.EscapeString(site)))
+	}
+	return "\n\n📰 Also covered by: " + strings.Join(sites, ", ")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// With ai.structured the model answers with the fields of a summary as JSON
+// instead of formatted text, and the bot lays the post out from them: there
+// is no model markup to convert, and the rating is a number filters can
+// trust. Release feeds keep their own prompt; prompt files still apply, with
+// the fields' schema added by genkit, but category section changes don't.
+
+// SummaryOutput is a summary as structured output
+type SummaryOutput struct {
+	Summary      string   `json:"summary"`
+	KeyPoints    []string `json:"key_points"`
+	Thoughts     string   `json:"thoughts"`
+	Rating       int      `json:"rating"` // 1-10; 0 when the tone has no rating
+	RatingReason string   `json:"rating_reason"`
+	Tags         []string `json:"tags"`
+	WhyCare      string   `json:"why_care,omitempty"` // asked for with a persona, see persona.go
+}
+
+// structuredTone is how a tone asks for and lays out the fields
+type structuredTone struct {
+	persona string
+	fields  string // instructions for thoughts and rating
+	labels  [3]string
+}
+
+var STRUCTURED_TONES = map[string]structuredTone{
+	TONE_EDITOR: {
+		persona: "You are an opinionated tech editor. Summarize this article.",
+		fields: `- thoughts: your analysis, 2-3 sentences
+- rating: 1-10, how worthwhile the article is for a technical reader
+- rating_reason: a brief explanation of the rating`,
+		labels: [3]string{"Summary", "Key Points", "My Thoughts"},
+	},
+	TONE_NEUTRAL: {
+		persona: "You are a newswire editor. Summarize this article neutrally and factually, without judgement, praise or speculation beyond what it states.",
+		fields:  "- thoughts, rating, rating_reason: leave empty",
+		labels:  [3]string{"Summary", "Key Points", ""},
+	},
+	TONE_ELI5: {
+		persona: "Explain this article so that a curious person with no technical background can follow it: short sentences, everyday words, and any jargon you can't avoid explained.",
+		fields: `- thoughts: why it matters, 1-2 sentences
+- rating, rating_reason: leave empty`,
+		labels: [3]string{"In Simple Terms", "What Happened", "Why It Matters"},
+	},
+}
+
+const STRUCTURED_PROMPT = `%s
+
+Fill in:
+- summary: 2-3 sentences
+- key_points: the 3 most important points, one sentence each
+%s
+- tags: up to 5 lowercase topic tags, e.g. go, security, machine-learning
+
+Every field is plain text: no markdown, no HTML.
+
+Title: %s
+
+Content:
+%s`
+
+func structuredToneFor(tone string) structuredTone {
+	if t, ok := STRUCTURED_TONES[tone]; ok {
+		return t
+	}
+	return STRUCTURED_TONES[DEFAULT_TONE]
+}
+
+func structuredPrompt(tone, title, content string) string {
+	t := structuredToneFor(tone)
+	return fmt.Sprintf(STRUCTURED_PROMPT, t.persona, t.fields, title, content)
+}
+
+// text lays the fields out the way the text prompts ask the model to, so
+// translation, the archive and the message layout treat both alike
+func (o *SummaryOutput) text(tone string) string {
+	t := structuredToneFor(tone)
+	var parts []string
+	if s := plainField(o.Summary); s != "" {
+		parts = append(parts, fmt.Sprintf("**%s:** %s", t.labels[0], s))
+	}
+	var points []string
+	for _, point := range o.KeyPoints {
+		if s := plainField(point); s != "" {
+			points = append(points, "• "+s)
+		}
+	}
+	if len(points) > 0 {
+		parts = append(parts, fmt.Sprintf("**%s:**\n%s", t.labels[1], strings.Join(points, "\n")))
+	}
+	if s := plainField(o.Thoughts); s != "" && t.labels[2] != "" {
+		parts = append(parts, fmt.Sprintf("**%s:** %s", t.labels[2], s))
+	}
+	if s := plainField(o.WhyCare); s != "" {
+		parts = append(parts, "**Why You Should Care:** "+s)
+	}
+	if rating := o.rating(); rating > 0 {
+		line := fmt.Sprintf("**Rating:** %d/10", rating)
+		if s := plainField(o.RatingReason); s != "" {
+			line += " - " + s
+		}
+		parts = append(parts, line)
+	}
+	if tags := o.hashtags(); len(tags) > 0 {
+		parts = append(parts, strings.Join(tags, " "))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// rating is the rating when it is in range, 0 otherwise
+func (o *SummaryOutput) rating() int {
+	if o.Rating < 1 || o.Rating > 10 {
+		return 0
+	}
+	return o.Rating
+}
+
+// tags are the tags as hashtags, without duplicates
+func (o *SummaryOutput) tags() []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, tag := range o.Tags {
+		tag = hashtag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// hashtag spells a tag as Telegram hashtags can: lowercase letters, digits
+// and underscores
+func hashtag(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '-' || r == '_' || r == ' ':
+			return '_'
+		default:
+			return -1
+		}
+	}, strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	return strings.Trim(tag, "_")
+}
+
+func (o *SummaryOutput) hashtags() []string {
+	var tags []string
+	for _, tag := range o.tags() {
+		tags = append(tags, "#"+tag)
+	}
+	return tags
+}
+
+// plainField drops the asterisks that would turn into markup, and the line
+// breaks that would split a section
+func plainField(s string) string {
+	s = strings.ReplaceAll(s, "*", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// The prompts ask the model to answer AI_FAILED when it can't summarize, e.g.
+// for a paywall or cookie page; that, an empty answer and one too short to be
+// a summary are failures, and the post falls back to the feed's description
+const AI_FAILED = "AI FAILED"
+
+// Fewer words than this can't be a summary
+const MIN_SUMMARY_WORDS = 5
+
+// checkSummary tells a summary from a model's failure to write one
+func checkSummary(text string) error {
+	plain := plainField(text)
+	switch {
+	case strings.Contains(plain, AI_FAILED):
+		return fmt.Errorf("model answered %s", AI_FAILED)
+	case plain == "":
+		return fmt.Errorf("model answered nothing")
+	case len(strings.Fields(plain)) < MIN_SUMMARY_WORDS:
+		return fmt.Errorf("model answered %q, too short for a summary", truncate(plain, 80, "…"))
+	}
+	return nil
+}
+
+// feedSummary is the item's own description as plain text, to post when the
+// model has no summary
+func feedSummary(item Item) string {
+	text := plainField(htmlText(cmp.Or(item.Description, item.Content)))
+	if text == "" || text == plainField(item.Title) {
+		return ""
+	}
+	return "**From the feed:** " + truncate(text, 1000, "…")
+}
+
+// ratingLine finds "Rating: 7/10" in a text summary, with or without the bold
+var ratingLine = regexp.MustCompile(`(?i)rating:?\**:?\s*(\d{1,2})\s*/\s*10`)
+
+// summaryRating reads the rating out of a posted summary, 0 when it has none
+func summaryRating(summary string) int {
+	m := ratingLine.FindStringSubmatch(summary)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n < 1 || n > 10 {
+		return 0
+	}
+	return n
+}
+
+// checkRating records the item's rating and reports why it should be skipped
+// for rating below the feed's min_rating, or "" to go on. Summaries without a
+// rating, from tones that leave it out, always go on.
+func (b *Bot) checkRating(p *pendingItem, out *SummaryOutput, text string) string {
+	rating := summaryRating(text)
+	if out != nil {
+		rating = out.rating()
+	}
+	if rating == 0 {
+		return ""
+	}
+	p.decision.score("rating", float64(rating))
+	if threshold := b.cfg.minRatingFor(p.feed); rating < threshold {
+		return fmt.Sprintf("rated %d/10, below %d", rating, threshold)
+	}
+	return ""
+}
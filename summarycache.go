@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Generated summaries are kept in the state store, keyed by a hash of the
+// prompt (which holds the template, title and content), so an item whose
+// send failed, or a run that was killed and started again, doesn't pay for
+// the same model call twice. Entries expire after SUMMARY_CACHE_TTL.
+
+const SUMMARY_CACHE_TTL = 7 * 24 * time.Hour
+
+// CachedSummary is a summary as it came from the model, before translation
+type CachedSummary struct {
+	Text    string    `json:"text"`
+	Tags    []string  `json:"tags,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+func (c CachedSummary) fresh() bool {
+	return time.Since(c.Created) < SUMMARY_CACHE_TTL
+}
+
+// summaryCache is implemented by stores that keep generated summaries: the
+// JSON file, bolt and redis. The summarize stage reads it while delivery
+// writes to the store, so implementations must allow that.
+type summaryCache interface {
+	CachedSummary(key string) (*CachedSummary, error)
+	CacheSummary(key string, summary CachedSummary) error
+}
+
+// summaryKey identifies a request for a summary by its model and prompt
+func summaryKey(model, prompt string) string {
+	return hash(model + "\x00" + prompt)
+}
+
+// cacheOf is the store's summary cache, nil when it keeps none
+func cacheOf(store StateStore) summaryCache {
+	cache, _ := store.(summaryCache)
+	return cache
+}
+
+func (f *stateFile) cachedSummary(key string) *CachedSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.Summaries[key]; ok && c.fresh() {
+		return &c
+	}
+	return nil
+}
+
+// cacheSummary stores a summary and drops the expired ones
+func (f *stateFile) cacheSummary(key string, summary CachedSummary) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, c := range f.Summaries {
+		if !c.fresh() {
+			delete(f.Summaries, k)
+		}
+	}
+	f.Summaries[key] = summary
+}
+
+// Cached summaries are saved with the next batch of marks or on Close
+func (s *jsonState) CachedSummary(key string) (*CachedSummary, error) {
+	return s.file.cachedSummary(key), nil
+}
+
+func (s *jsonState) CacheSummary(key string, summary CachedSummary) error {
+	s.file.cacheSummary(key, summary)
+	return nil
+}
+
+var boltSummariesBucket = []byte("summaries")
+
+func (s *boltState) CachedSummary(key string) (*CachedSummary, error) {
+	var cached *CachedSummary
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltSummariesBucket).Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		var c CachedSummary
+		if err := json.Unmarshal(v, &c); err != nil {
+			return fmt.Errorf("cached summary %s: %w", key, err)
+		}
+		if c.fresh() {
+			cached = &c
+		}
+		return nil
+	})
+	return cached, err
+}
+
+// CacheSummary stores a summary and drops the expired ones
+func (s *boltState) CacheSummary(key string, summary CachedSummary) error {
+	data, _ := json.Marshal(summary)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSummariesBucket)
+		var expired [][]byte
+		bucket.ForEach(func(k, v []byte) error {
+			var c CachedSummary
+			if json.Unmarshal(v, &c) != nil || !c.fresh() {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return bucket.Put([]byte(key), data)
+	})
+}
+
+// summariesKey is outside the item prefix, so Each and Prune never see it
+func (s *redisState) summariesKey(key string) string {
+	return "rss:summaries:" + s.prefix + key
+}
+
+func (s *redisState) CachedSummary(key string) (*CachedSummary, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	data, err := s.client.Get(ctx, s.summariesKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c CachedSummary
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cached summary %s: %w", key, err)
+	}
+	return &c, nil
+}
+
+// CacheSummary leaves expiry to Redis
+func (s *redisState) CacheSummary(key string, summary CachedSummary) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	data, _ := json.Marshal(summary)
+	return s.client.Set(ctx, s.summariesKey(key), data, SUMMARY_CACHE_TTL).Err()
+}
+
+// lookupSummary asks the item's cache for a summary, nil on a miss or when
+// there is no cache
+func (p *pendingItem) lookupSummary(key string) *CachedSummary {
+	if p.cache == nil {
+		return nil
+	}
+	cached, err := p.cache.CachedSummary(key)
+	if err != nil {
+		fmt.Printf("⚠️  Summary cache lookup failed: %v\n", err)
+		return nil
+	}
+	return cached
+}
+
+// storeSummary keeps a generated summary in the item's cache, if any
+func (p *pendingItem) storeSummary(key, text string, tags []string) {
+	if p.cache == nil {
+		return
+	}
+	if err := p.cache.CacheSummary(key, CachedSummary{Text: text, Tags: tags, Created: time.Now().UTC()}); err != nil {
+		fmt.Printf("⚠️  Caching the summary failed: %v\n", err)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// telegramCall invokes a Bot API method with JSON params and decodes
+// "result" into result, retrying when Telegram throttles it
+func telegramCall(ctx context.Context, token, method string, params any, result any) error {
+	err := withTelegramRetry(ctx, method, func() error {
+		return telegramCallOnce(ctx, token, method, params, result)
+	})
+	if err == nil {
+		auditTelegram(ctx, method, params, result)
+	}
+	return err
+}
+
+// auditTelegram records the calls that post, edit or pin a message; the
+// message id comes from the sent message, or the params for an edit or pin
+func auditTelegram(ctx context.Context, method string, params any, result any) {
+	if !strings.HasPrefix(method, "send") && !strings.HasPrefix(method, "edit") && method != "pinChatMessage" {
+		return
+	}
+	var chat string
+	var messageID int64
+	switch p := params.(type) {
+	case map[string]any:
+		if id, ok := p["chat_id"]; ok {
+			chat = fmt.Sprint(id)
+		}
+		if id, ok := p["message_id"].(int64); ok {
+			messageID = id
+		}
+	case map[string]string:
+		chat = p["chat_id"]
+	}
+	if sent, ok := result.(*TelegramMessage); ok && sent.MessageID != 0 {
+		messageID = sent.MessageID
+	}
+	audit.Message(ctx, method, chat, messageID)
+}
+
+func telegramCallOnce(ctx context.Context, token, method string, params any, result any) error {
+	if strings.HasPrefix(method, "send") {
+		if err := chaosError(CHAOS_TELEGRAM); err != nil {
+			return err
+		}
+	}
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
+
+	b, _ := json.Marshal(params)
+	req, err := http.NewRequestWithContext(withPurpose(ctx, "telegram"), http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	timeout := timeouts.Telegram
+	if method == "getUpdates" {
+		timeout = TELEGRAM_POLL_TIMEOUT
+	}
+	resp, err := httpDo(req, timeout)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", string(rb))
+	}
+	if result == nil {
+		return nil
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+// telegramUpload invokes a Bot API method that takes a file, as
+// multipart/form-data, retrying when Telegram throttles it
+func telegramUpload(ctx context.Context, token, method string, fields map[string]string, fileField, filename string, data []byte, result any) error {
+	err := withTelegramRetry(ctx, method, func() error {
+		return telegramUploadOnce(ctx, token, method, fields, fileField, filename, data, result)
+	})
+	if err == nil {
+		auditTelegram(ctx, method, fields, result)
+	}
+	return err
+}
+
+func telegramUploadOnce(ctx context.Context, token, method string, fields map[string]string, fileField, filename string, data []byte, result any) error {
+	if err := chaosError(CHAOS_TELEGRAM); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	fw, err := mw.CreateFormFile(fileField, filename)
+	if err != nil {
+		return err
+	}
+	fw.Write(data)
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(withPurpose(ctx, "telegram"), http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := httpDo(req, timeouts.Telegram)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", string(rb))
+	}
+	if result == nil {
+		return nil
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+type TelegramChat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+}
+
+type TelegramUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+type TelegramMessage struct {
+	MessageID       int64            `json:"message_id"`
+	From            *TelegramUser    `json:"from,omitempty"`
+	Chat            TelegramChat     `json:"chat"`
+	Text            string           `json:"text,omitempty"`
+	ReplyTo         *TelegramMessage `json:"reply_to_message,omitempty"`
+	ForwardOrigin   *TelegramOrigin  `json:"forward_origin,omitempty"`
+	AutoForwarded   bool             `json:"is_automatic_forward,omitempty"`
+	MessageThreadID int64            `json:"message_thread_id,omitempty"`
+}
+
+// TelegramOrigin tells where a forwarded message came from; channel posts
+// copied into their discussion group have type "channel"
+type TelegramOrigin struct {
+	Type      string        `json:"type"`
+	Chat      *TelegramChat `json:"chat,omitempty"`
+	MessageID int64         `json:"message_id,omitempty"`
+}
+
+type TelegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *TelegramMessage `json:"message,omitempty"`
+}
+
+// getUpdates long-polls for new updates after offset
+func getUpdates(ctx context.Context, token string, offset int64, allowed []string) ([]TelegramUpdate, error) {
+	var updates []TelegramUpdate
+	err := telegramCall(ctx, token, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         50,
+		"allowed_updates": allowed,
+	}, &updates)
+	return updates, err
+}
+
+// replyOnTelegram answers a message in the same chat and thread
+func replyOnTelegram(ctx context.Context, token string, to *TelegramMessage, text string) (int64, error) {
+	params := map[string]any{
+		"chat_id":                  to.Chat.ID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+		"reply_parameters":         map[string]any{"message_id": to.MessageID, "allow_sending_without_reply": true},
+	}
+	if to.MessageThreadID != 0 {
+		params["message_thread_id"] = to.MessageThreadID
+	}
+
+	var sent TelegramMessage
+	if err := telegramCall(ctx, token, "sendMessage", params, &sent); err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
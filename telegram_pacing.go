@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telegram answers sends that come too fast with 429 and a retry_after in
+// the error's parameters. Such calls wait that long and go again instead of
+// failing the item, and the pause between posts grows by the same wait,
+// shrinking back as sends go through again.
+
+const TELEGRAM_ATTEMPTS = 3
+
+// Longer waits fail the call; the item is tried again next run
+const MAX_TELEGRAM_RETRY_AFTER = 2 * time.Minute
+
+// Cap on what throttling adds to the pause between posts
+const MAX_EXTRA_POST_DELAY = time.Minute
+
+type telegramPacing struct {
+	mu    sync.Mutex
+	extra time.Duration
+}
+
+var pacing = &telegramPacing{}
+
+// throttled stretches the pause after Telegram asked us to wait
+func (t *telegramPacing) throttled(wait time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.extra = min(max(t.extra*2, wait), MAX_EXTRA_POST_DELAY)
+}
+
+// sent halves the extra pause after a send went through
+func (t *telegramPacing) sent() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.extra /= 2; t.extra < 100*time.Millisecond {
+		t.extra = 0
+	}
+}
+
+// pause waits between posts: the configured delay plus whatever throttling added
+func (t *telegramPacing) pause(delay time.Duration) {
+	t.mu.Lock()
+	extra := t.extra
+	t.mu.Unlock()
+	time.Sleep(delay + extra)
+}
+
+// telegramRetryAfter reads how long a 429 from Telegram asks to wait, e.g.
+// {"ok":false,"error_code":429,"parameters":{"retry_after":5}}
+func telegramRetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	var payload struct {
+		ErrorCode  int `json:"error_code"`
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if json.Unmarshal([]byte(err.Error()), &payload) != nil || payload.ErrorCode != 429 {
+		return 0, false
+	}
+	return time.Duration(max(payload.Parameters.RetryAfter, 1)) * time.Second, true
+}
+
+// withTelegramRetry makes a Bot API call, waiting out and retrying 429s
+func withTelegramRetry(ctx context.Context, method string, call func() error) error {
+	for n := 1; ; n++ {
+		err := call()
+		wait, throttled := telegramRetryAfter(err)
+		if !throttled {
+			if err == nil && strings.HasPrefix(method, "send") {
+				pacing.sent()
+			}
+			return err
+		}
+		pacing.throttled(wait)
+		if n >= TELEGRAM_ATTEMPTS || wait > MAX_TELEGRAM_RETRY_AFTER {
+			return err
+		}
+		fmt.Printf("⏳ Telegram throttled %s, retrying in %s\n", method, wait)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MessageData is what a message template (message_template, or a feed's
+// template) is executed with. The output is Telegram HTML.
+type MessageData struct {
+	Title     string // as published, or translated; not escaped
+	Link      string
+	Summary   string // the AI summary, already Telegram HTML
+	Feed      string
+	Category  string
+	Published time.Time // zero when the feed doesn't date its items
+	Event     string    // "Add to calendar" line for detected events, Telegram HTML
+
+	OriginalTitle string // the feed's title when rewrite_titles replaced it, empty otherwise; not escaped
+}
+
+// TEMPLATE_FUNCS are available in message templates, e.g.
+//
+//	<b><a href="{{.Link | escapeHTML}}">{{.Title | truncate 80 | escapeHTML}}</a></b>
+//	{{if not .Published.IsZero}}<i>{{ago .Published}}</i>{{end}}
+var TEMPLATE_FUNCS = template.FuncMap{
+	"truncate":       smartTruncate,
+	"plural":         plural,
+	"ago":            ago,
+	"humanize":       humanize,
+	"escapeHTML":     html.EscapeString,
+	"escapeMarkdown": escapeMarkdownV2,
+}
+
+// parseMessageTemplate compiles a message template with the function library
+func parseMessageTemplate(text string) (*template.Template, error) {
+	return template.New("message").Funcs(TEMPLATE_FUNCS).Option("missingkey=error").Parse(text)
+}
+
+func renderMessage(tmpl *template.Template, data MessageData) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// smartTruncate shortens s to n characters, preferring to end after a whole
+// sentence, then at a word, and marking the cut with an ellipsis
+func smartTruncate(n int, s string) string {
+	if graphemeCount(s) <= n {
+		return s
+	}
+	cut := truncate(s, n, "")
+	if i := strings.LastIndexAny(cut, ".!?"); i >= 0 && graphemeCount(cut[:i+1]) >= n/2 {
+		return cut[:i+1]
+	}
+	cut = truncate(s, n, "…")
+	cut = strings.TrimSuffix(cut, "…")
+	if i := strings.LastIndexByte(cut, ' '); i > 0 && graphemeCount(cut[:i]) > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
+
+// plural formats a count with the matching noun: plural 1 "post" "posts" is "1 post"
+func plural(n int, one, many string) string {
+	if n == 1 || n == -1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// ago describes t relative to now: "just now", "5 minutes ago", "in 2 days";
+// past a month it is the date
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var s string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		s = plural(int(d/time.Minute), "minute", "minutes")
+	case d < 24*time.Hour:
+		s = plural(int(d/time.Hour), "hour", "hours")
+	case d < 30*24*time.Hour:
+		s = plural(int(d/(24*time.Hour)), "day", "days")
+	default:
+		return t.Format("2 Jan 2006")
+	}
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
+
+// humanize shortens large numbers: 950, 1.2K, 3.4M, 1.1B
+func humanize(v any) string {
+	var n float64
+	switch x := v.(type) {
+	case int:
+		n = float64(x)
+	case int64:
+		n = float64(x)
+	case int32:
+		n = float64(x)
+	case float64:
+		n = x
+	default:
+		return fmt.Sprint(v)
+	}
+
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e9, "B"}, {1e6, "M"}, {1e3, "K"}} {
+		if n >= unit.size {
+			s := fmt.Sprintf("%.1f", n/unit.size)
+			return sign + strings.TrimSuffix(s, ".0") + unit.suffix
+		}
+	}
+	return sign + fmt.Sprintf("%g", n)
+}
+
+// escapeMarkdownV2 escapes the characters Telegram's MarkdownV2 reserves
+func escapeMarkdownV2(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
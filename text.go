@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Telegram limits message text to 4096 UTF-16 code units after entity parsing
+const TELEGRAM_MESSAGE_LIMIT = 4096
+
+// and photo captions to 1024
+const TELEGRAM_CAPTION_LIMIT = 1024
+
+// nextGrapheme returns the byte length of the user-perceived character at the
+// start of s: a base rune plus combining marks, variation selectors, emoji
+// modifiers and tags, ZWJ sequences, regional-indicator flag pairs and CRLF.
+// It is an approximation of UAX #29 that covers what shows up in articles and
+// summaries without pulling in the full segmentation tables.
+func nextGrapheme(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return 0
+	}
+	i := size
+
+	if r == '\r' && i < len(s) && s[i] == '\n' {
+		return i + 1
+	}
+	if isRegionalIndicator(r) {
+		if next, n := utf8.DecodeRuneInString(s[i:]); isRegionalIndicator(next) {
+			i += n
+		}
+	}
+
+	for i < len(s) {
+		next, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case next == '\u200d': // zero width joiner glues the following rune on
+			i += n
+			if i < len(s) {
+				_, m := utf8.DecodeRuneInString(s[i:])
+				i += m
+			}
+		case isGraphemeExtend(next):
+			i += n
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0xFE00 && r <= 0xFE0F) || // variation selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // tag sequences (subdivision flags)
+}
+
+// graphemeCount is the number of user-perceived characters in s
+func graphemeCount(s string) int {
+	n := 0
+	for len(s) > 0 {
+		s = s[nextGrapheme(s):]
+		n++
+	}
+	return n
+}
+
+// truncate shortens s to at most n user-perceived characters, including the
+// suffix, never splitting a rune or an emoji sequence
+func truncate(s string, n int, suffix string) string {
+	if graphemeCount(s) <= n {
+		return s
+	}
+	keep := n - graphemeCount(suffix)
+	end := 0
+	for i := 0; i < keep && end < len(s); i++ {
+		end += nextGrapheme(s[end:])
+	}
+	return s[:end] + suffix
+}
+
+// utf16Len counts UTF-16 code units, the unit Telegram measures lengths and offsets in
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// telegramTextLen is the length Telegram checks against TELEGRAM_MESSAGE_LIMIT
+// for an HTML parse_mode message: tags removed, entities decoded, in UTF-16 units
+func telegramTextLen(htmlText string) int {
+	if text, _, err := telegramEntities(htmlText); err == nil {
+		return utf16Len(text)
+	}
+	return utf16Len(html.UnescapeString(htmlTag.ReplaceAllString(htmlText, "")))
+}
+
+// fitMessage shortens text until build(text) fits in one Telegram message
+func fitMessage(text string, build func(string) string) string {
+	msg := build(text)
+	for over := telegramTextLen(msg) - TELEGRAM_MESSAGE_LIMIT; over > 0; over = telegramTextLen(msg) - TELEGRAM_MESSAGE_LIMIT {
+		keep := graphemeCount(text) - over
+		if keep <= 1 {
+			return build("")
+		}
+		text = truncate(text, keep, "…")
+		msg = build(text)
+	}
+	return msg
+}
+
+// readMoreLink ends a summary that fitMessage had to cut, pointing to the article
+func readMoreLink(link string) string {
+	return fmt.Sprintf(` <a href="%s">Read more</a>`, html.EscapeString(link))
+}
+
+// MessageEntity mirrors Telegram's MessageEntity; Offset and Length are in UTF-16 code units
+type MessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	URL    string `json:"url,omitempty"`
+}
+
+// telegramTags maps the HTML tags Telegram accepts to entity types
+var telegramTags = map[string]string{
+	"b": "bold", "strong": "bold",
+	"i": "italic", "em": "italic",
+	"u": "underline", "ins": "underline",
+	"s": "strikethrough", "strike": "strikethrough", "del": "strikethrough",
+	"a":          "text_link",
+	"code":       "code",
+	"pre":        "pre",
+	"blockquote": "blockquote",
+	"tg-spoiler": "spoiler",
+	"span":       "spoiler", // only <span class="tg-spoiler">
+}
+
+var tagAttr = regexp.MustCompile(`([a-z-]+)(?:\s*=\s*"([^"]*)")?`)
+
+// telegramEntities parses an HTML parse_mode message the way Telegram does,
+// returning the visible text and its entities with UTF-16 offsets. Unknown or
+// unbalanced tags are reported as errors, which Telegram would reject with
+// "can't parse entities".
+func telegramEntities(htmlText string) (string, []MessageEntity, error) {
+	type open struct {
+		tag    string
+		entity MessageEntity
+	}
+
+	var text []byte
+	var entities []MessageEntity
+	var stack []open
+	offset := 0 // UTF-16 units written so far
+
+	rest := htmlText
+	for len(rest) > 0 {
+		lt := strings.IndexByte(rest, '<')
+		if lt < 0 {
+			lt = len(rest)
+		}
+		if lt > 0 {
+			chunk := html.UnescapeString(rest[:lt])
+			text = append(text, chunk...)
+			offset += utf16Len(chunk)
+			rest = rest[lt:]
+			continue
+		}
+
+		gt := strings.IndexByte(rest, '>')
+		if gt < 0 {
+			return "", nil, fmt.Errorf("unclosed tag at %q", truncate(rest, 20, "…"))
+		}
+		raw := strings.TrimSpace(rest[1:gt])
+		rest = rest[gt+1:]
+
+		if name, closing := strings.CutPrefix(raw, "/"); closing {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if len(stack) == 0 || stack[len(stack)-1].tag != name {
+				return "", nil, fmt.Errorf("unexpected closing tag </%s>", name)
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			top.entity.Length = offset - top.entity.Offset
+			if top.entity.Length > 0 {
+				entities = append(entities, top.entity)
+			}
+			continue
+		}
+
+		name, attrs, _ := strings.Cut(raw, " ")
+		name = strings.ToLower(name)
+		kind, ok := telegramTags[name]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported tag <%s>", name)
+		}
+		entity := MessageEntity{Type: kind, Offset: offset}
+		for _, m := range tagAttr.FindAllStringSubmatch(attrs, -1) {
+			switch {
+			case name == "a" && m[1] == "href":
+				entity.URL = html.UnescapeString(m[2])
+			case name == "blockquote" && m[1] == "expandable":
+				entity.Type = "expandable_blockquote"
+			case name == "span" && m[1] == "class" && m[2] != "tg-spoiler":
+				return "", nil, fmt.Errorf("unsupported <span class=%q>", m[2])
+			}
+		}
+		if name == "a" && entity.URL == "" {
+			return "", nil, fmt.Errorf("<a> without href")
+		}
+		stack = append(stack, open{tag: name, entity: entity})
+	}
+
+	if len(stack) > 0 {
+		return "", nil, fmt.Errorf("unclosed tag <%s>", stack[len(stack)-1].tag)
+	}
+	// Telegram orders entities by offset, outer before inner
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+	return string(text), entities, nil
+}
+
+// sliceUTF16 returns the part of s covered by an entity's UTF-16 offset and length
+func sliceUTF16(s string, offset, length int) string {
+	units := utf16.Encode([]rune(s))
+	if offset < 0 || offset > len(units) {
+		return ""
+	}
+	end := min(offset+length, len(units))
+	return string(utf16.Decode(units[offset:end]))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutsConfig bounds each stage of handling an item. A stage that runs
+// out of time fails like any other error: the feed counts as failed, or the
+// item is retried next run.
+type TimeoutsConfig struct {
+	Feed    time.Duration `yaml:"feed"`    // feed download, body included; default 15s
+	Article time.Duration `yaml:"article"` // article page download; default 30s
+	AI      time.Duration `yaml:"ai"`      // one model call (summary, translation, event or job check), retries and fallbacks included; default 60s
+	// One request to a model within such a call; a request that hangs past
+	// it is retried while timeouts.ai lasts. Default and at most timeouts.ai.
+	AIAttempt time.Duration `yaml:"ai_attempt"`
+	Telegram  time.Duration `yaml:"telegram"` // one Bot API call or upload; default 30s
+}
+
+// Long polls are held open by Telegram for up to 50s, so they get longer than timeouts.telegram
+const TELEGRAM_POLL_TIMEOUT = 90 * time.Second
+
+// timeouts are the stage timeouts in effect, see configureTimeouts
+var timeouts = TimeoutsConfig{}.withDefaults()
+
+func configureTimeouts(cfg TimeoutsConfig) {
+	timeouts = cfg.withDefaults()
+}
+
+func (t TimeoutsConfig) withDefaults() TimeoutsConfig {
+	if t.Feed <= 0 {
+		t.Feed = FETCH_TIMEOUT
+	}
+	if t.Article <= 0 {
+		t.Article = 30 * time.Second
+	}
+	if t.AI <= 0 {
+		t.AI = 60 * time.Second
+	}
+	if t.AIAttempt <= 0 || t.AIAttempt > t.AI {
+		t.AIAttempt = t.AI
+	}
+	if t.Telegram <= 0 {
+		t.Telegram = 30 * time.Second
+	}
+	return t
+}
+
+// aiContext bounds one model call by timeouts.ai
+func aiContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, timeouts.AI)
+}
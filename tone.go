@@ -0,0 +1,94 @@
+package main
+
+import "fmt"
+
+// Tone presets change both the persona and which sections the summary has.
+// Each prompt takes the article title and content.
+const (
+	TONE_EDITOR  = "editor"  // opinionated editor: analysis and a rating (the original format)
+	TONE_NEUTRAL = "neutral" // newswire: facts only, no opinion sections
+	TONE_ELI5    = "eli5"    // plain-language explanation for non-specialists
+)
+
+const DEFAULT_TONE = TONE_EDITOR
+
+const NEUTRAL_PROMPT = `You are a newswire editor. Summarize this article neutrally and factually, without opinions, in plain text with simple formatting.
+
+Format rules:
+- Use **bold** for section headers
+- Use bullet points (•) for lists
+- Keep it clean and readable
+- NO HTML tags
+- No judgement, praise or speculation beyond what the article states
+
+Structure:
+**Summary:** 2-3 sentences
+
+**Key Points:**
+- Point 1
+- Point 2
+- Point 3
+
+If you can't summarize, output: AI FAILED
+
+Title: %s
+
+Content:
+%s`
+
+const ELI5_PROMPT = `Explain this article so that a curious person with no technical background can follow it, in plain text with simple formatting.
+
+Format rules:
+- Use **bold** for section headers
+- Use bullet points (•) for lists
+- Short sentences, everyday words, explain any jargon you can't avoid
+- NO HTML tags
+
+Structure:
+**In Simple Terms:** 2-3 sentences
+
+**What Happened:**
+- Point 1
+- Point 2
+- Point 3
+
+**Why It Matters:** 1-2 sentences
+
+If you can't summarize, output: AI FAILED
+
+Title: %s
+
+Content:
+%s`
+
+var TONE_PROMPTS = map[string]string{
+	TONE_EDITOR:  AI_PROMPT,
+	TONE_NEUTRAL: NEUTRAL_PROMPT,
+	TONE_ELI5:    ELI5_PROMPT,
+}
+
+func checkTone(tone string) error {
+	if _, ok := TONE_PROMPTS[tone]; !ok && tone != "" {
+		return fmt.Errorf("unknown tone %q (expected editor, neutral or eli5)", tone)
+	}
+	return nil
+}
+
+// toneFor picks the tone of the channel a feed posts to, falling back to the global tone
+func (c *Config) toneFor(feed FeedConfig) string {
+	if ch, ok := c.Channels[feed.Channel]; ok && feed.Channel != "" && ch.Tone != "" {
+		return ch.Tone
+	}
+	if c.Tone != "" {
+		return c.Tone
+	}
+	return DEFAULT_TONE
+}
+
+// promptFor is the summary prompt for a tone, unknown tones getting the default
+func promptFor(tone string) string {
+	if prompt, ok := TONE_PROMPTS[tone]; ok {
+		return prompt
+	}
+	return TONE_PROMPTS[DEFAULT_TONE]
+}
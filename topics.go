@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TopicsConfig has the model file each item under one topic of a taxonomy.
+// The topic becomes a hashtag, and can send the post to the topic's own chat
+// or forum topic; tone and language still follow the feed's channel.
+type TopicsConfig struct {
+	List  []TopicConfig `yaml:"list"`  // the taxonomy; empty turns classification off
+	Model string        `yaml:"model"` // default ai.model
+}
+
+type TopicConfig struct {
+	Name        string `yaml:"name"`        // e.g. security; also the hashtag
+	Description string `yaml:"description"` // what belongs under it, for the model
+	Channel     string `yaml:"channel"`     // key into channels; default the feed's chat
+	ThreadID    int64  `yaml:"thread_id"`   // forum topic in that chat, 0 for none
+}
+
+const TOPIC_PROMPT = `File this article under exactly one of these topics, or "other" if none fits:
+
+%s
+
+Answer with the topic's name.
+
+Title: %s
+
+Content:
+%s`
+
+type TopicOutput struct {
+	Topic string `json:"topic"`
+}
+
+// find is the topic with that name, nil if there is none
+func (c TopicsConfig) find(name string) *TopicConfig {
+	for i := range c.List {
+		if strings.EqualFold(c.List[i].Name, name) {
+			return &c.List[i]
+		}
+	}
+	return nil
+}
+
+// classifyTopic asks the model for the item's topic, nil when none fits or
+// the model fails
+func (b *Bot) classifyTopic(ctx context.Context, p *pendingItem) *TopicConfig {
+	if len(b.cfg.Topics.List) == 0 {
+		return nil
+	}
+	res, err := b.flows.classify.Run(ctx, ClassifyInput{Title: p.item.Title, Content: p.content})
+	p.addTokens(res.InputTokens, res.OutputTokens)
+	if err != nil {
+		fmt.Printf("⚠️  Topic classification failed (%s): %v\n", p.item.Title, err)
+		return nil
+	}
+	topic := b.cfg.Topics.find(res.Topic)
+	if topic != nil {
+		p.decision.Topic = topic.Name
+	}
+	return topic
+}
+
+// tagTopic adds the topic's hashtag to the item's summary and tags
+func (p *pendingItem) tagTopic() {
+	if p.topic == nil {
+		return
+	}
+	tag := hashtag(p.topic.Name)
+	if tag == "" || slices.Contains(p.tags, tag) {
+		return
+	}
+	p.tags = append([]string{tag}, p.tags...)
+	if p.summary != "" {
+		p.summary += "\n\n#" + tag
+	}
+}
+
+// route is the chat and forum topic an item is posted to: its topic's, when
+// that has one, otherwise its feed's
+func (b *Bot) route(p *pendingItem) (string, int64) {
+	chatID := b.cfg.chatFor(p.feed)
+	if p.topic == nil {
+		return chatID, 0
+	}
+	if p.topic.Channel != "" {
+		chatID = b.cfg.chatFor(FeedConfig{Channel: p.topic.Channel})
+	}
+	return chatID, p.topic.ThreadID
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+type TranslationConfig struct {
+	TargetLanguage string `yaml:"target_language"` // e.g. "ru", "de", "pt-BR"
+	DeepL          struct {
+		APIKey string `yaml:"api_key"`
+	} `yaml:"deepl"`
+	Google struct {
+		APIKey string `yaml:"api_key"` // Cloud Translation (v2) API key
+	} `yaml:"google"`
+	// Provider per task: "deepl", "google" or "ai" (the summarization model).
+	// Tasks: title, summary. Unset tasks are not translated.
+	Tasks map[string]string `yaml:"tasks"`
+}
+
+const (
+	TRANSLATE_TITLE   = "title"
+	TRANSLATE_SUMMARY = "summary"
+)
+
+// Translator turns text into the target language
+type Translator interface {
+	Translate(ctx context.Context, text, targetLang string) (string, error)
+}
+
+// newTranslators builds one translator per configured task
+func newTranslators(cfg TranslationConfig, g *genkit.Genkit, aiCfg AIConfig) (map[string]Translator, error) {
+	translators := map[string]Translator{}
+	for task, provider := range cfg.Tasks {
+		if task != TRANSLATE_TITLE && task != TRANSLATE_SUMMARY {
+			return nil, fmt.Errorf("translation: unknown task %q (expected title or summary)", task)
+		}
+		if cfg.TargetLanguage == "" {
+			return nil, fmt.Errorf("translation: target_language is required")
+		}
+
+		switch provider {
+		case "deepl":
+			if cfg.DeepL.APIKey == "" {
+				return nil, fmt.Errorf("translation: deepl.api_key is required")
+			}
+			translators[task] = &deeplTranslator{apiKey: cfg.DeepL.APIKey}
+		case "google":
+			if cfg.Google.APIKey == "" {
+				return nil, fmt.Errorf("translation: google.api_key is required")
+			}
+			translators[task] = &googleTranslator{apiKey: cfg.Google.APIKey}
+		case "ai":
+			translators[task] = &aiTranslator{g: g, cfg: aiCfg}
+		default:
+			return nil, fmt.Errorf("translation: unknown provider %q for %s", provider, task)
+		}
+	}
+	return translators, nil
+}
+
+func postTranslateForm(ctx context.Context, endpoint string, form url.Values, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(withPurpose(ctx, "translate"), http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := httpDo(req, 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("translate failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("translate failed: %d %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+type deeplTranslator struct {
+	apiKey string
+}
+
+func (t *deeplTranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	// Free-tier keys end in ":fx" and use a separate host
+	endpoint := "https://api.deepl.com/v2/translate"
+	if strings.HasSuffix(t.apiKey, ":fx") {
+		endpoint = "https://api-free.deepl.com/v2/translate"
+	}
+
+	form := url.Values{"text": {text}, "target_lang": {strings.ToUpper(targetLang)}}
+	body, err := postTranslateForm(ctx, endpoint, form, http.Header{"Authorization": {"DeepL-Auth-Key " + t.apiKey}})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Translations) == 0 {
+		return "", fmt.Errorf("translate failed: unexpected response %s", string(body))
+	}
+	return resp.Translations[0].Text, nil
+}
+
+type googleTranslator struct {
+	apiKey string
+}
+
+func (t *googleTranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	form := url.Values{"q": {text}, "target": {targetLang}, "format": {"text"}, "key": {t.apiKey}}
+	body, err := postTranslateForm(ctx, "https://translation.googleapis.com/language/translate/v2", form, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Data.Translations) == 0 {
+		return "", fmt.Errorf("translate failed: unexpected response %s", string(body))
+	}
+	return resp.Data.Translations[0].TranslatedText, nil
+}
+
+// aiTranslator uses the summarization model, for when quality matters more than cost
+type aiTranslator struct {
+	g   *genkit.Genkit
+	cfg AIConfig
+}
+
+const TRANSLATE_PROMPT = `Translate the following text into %s. Keep any **bold** markers, bullet points and line breaks. Output only the translation.
+
+%s`
+
+func (t *aiTranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	ctx, cancel := aiContext(ctx)
+	defer cancel()
+	resp, err := genkit.Generate(ctx, t.g,
+		ai.WithPrompt(fmt.Sprintf(TRANSLATE_PROMPT, targetLang, text)),
+		ai.WithModelName(t.cfg.Model),
+		withFallback(t.g, t.cfg),
+	)
+	if err != nil {
+		return "", fmt.Errorf("translate failed: %w", err)
+	}
+	return strings.TrimSpace(resp.Text()), nil
+}
+
+// translate runs the translator configured for task, keeping the original text on failure
+func (b *Bot) translate(ctx context.Context, task, text string) string {
+	if _, ok := b.translators[task]; !ok || strings.TrimSpace(text) == "" {
+		return text
+	}
+	translated, err := b.flows.translate.Run(ctx, TranslateInput{Task: task, Text: text})
+	if err != nil {
+		fmt.Printf("⚠️  Translating %s failed, keeping original: %v\n", task, err)
+		return text
+	}
+	return translated
+}
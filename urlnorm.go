@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query parameters that only say where a link was shared from; any utm_* is dropped too
+var TRACKING_PARAMS = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true, "igshid": true,
+	"mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true, "mkt_tok": true,
+	"ref": true, "ref_src": true, "ref_url": true, "source": true,
+}
+
+// normalizeURL reduces a link to the form used for deduplication: https,
+// lowercase host without a default port, no trailing slash, fragment or
+// tracking parameters, remaining parameters sorted
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Host = strings.TrimSuffix(strings.TrimSuffix(u.Host, ":443"), ":80")
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.Fragment, u.RawFragment = "", ""
+
+	query := u.Query()
+	for key := range query {
+		if TRACKING_PARAMS[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// itemID is the state key of an item: the hash of its normalized link
+func itemID(link string) string {
+	return hash(normalizeURL(link))
+}
+
+// seenLink reports whether an item was recorded. Items recorded before links
+// were normalized are keyed by the hash of the raw link, so that id counts too.
+func seenLink(store StateStore, link string) (bool, error) {
+	seen, err := store.Seen(itemID(link))
+	if err != nil || seen {
+		return seen, err
+	}
+	if legacy := hash(link); legacy != itemID(link) {
+		return store.Seen(legacy)
+	}
+	return false, nil
+}
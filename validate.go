@@ -0,0 +1,638 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configProblem is one actionable validation finding
+type configProblem struct {
+	Path    string
+	Message string
+}
+
+func (p configProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// validateConfigFile checks the config at path (with profile applied, if set)
+// and returns every problem found
+func validateConfigFile(path, profile string) ([]configProblem, error) {
+	if err := checkConfigScheme(path); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	if isRemoteConfig(path) {
+		data, err = fetchRemoteConfig(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []configProblem{{Message: fmt.Sprintf("not valid YAML: %v", err)}}, nil
+	}
+	if len(root.Content) == 0 {
+		return []configProblem{{Message: "config file is empty"}}, nil
+	}
+	expandNode(&root)
+
+	var problems []configProblem
+	problems = append(problems, unknownKeys(root.Content[0], reflect.TypeOf(Config{}), "")...)
+
+	if err := applyProfile(&root, profile); err != nil {
+		return append(problems, configProblem{Path: "profiles", Message: err.Error()}), nil
+	}
+
+	// Decode leniently so the remaining checks still run when there are unknown keys
+	cfg := &Config{}
+	if err := root.Decode(cfg); err != nil {
+		problems = append(problems, configProblem{Message: fmt.Sprintf("wrong value type: %v", err)})
+		return problems, nil
+	}
+	cfg.applyDefaults()
+
+	problems = append(problems, cfg.validate()...)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return problems, nil
+}
+
+// unknownKeys walks a YAML mapping alongside the struct it decodes into
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []configProblem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var problems []configProblem
+	switch {
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, child := range node.Content {
+			problems = append(problems, unknownKeys(child, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, unknownKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := map[string]reflect.Type{}
+		var known []string
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			fields[name] = t.Field(i).Type
+			known = append(known, name)
+		}
+		sort.Strings(known)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			ft, ok := fields[key]
+			if !ok {
+				problems = append(problems, configProblem{
+					Path:    joinPath(path, key),
+					Message: fmt.Sprintf("unknown key (line %d); expected one of: %s", node.Content[i].Line, strings.Join(known, ", ")),
+				})
+				continue
+			}
+			problems = append(problems, unknownKeys(node.Content[i+1], ft, joinPath(path, key))...)
+		}
+	}
+	return problems
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// validate checks values that decode fine but can't work at runtime
+func (c *Config) validate() []configProblem {
+	var problems []configProblem
+	add := func(path, format string, args ...any) {
+		problems = append(problems, configProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Values coming from a secret manager are only fetched at startup
+	missing := func(value, env string) bool {
+		return value == "" && c.Secrets[env] == ""
+	}
+	if missing(c.Telegram.Token, "TG_BOT_TOKEN") {
+		add("telegram.token", "empty and TG_BOT_TOKEN is not set")
+	}
+	if missing(c.Telegram.ChannelID, "TG_CHANNEL_ID") {
+		add("telegram.channel_id", "empty and TG_CHANNEL_ID is not set")
+	}
+	if missing(c.AI.Model, "GEMINI_MODEL") {
+		add("ai.model", "empty and GEMINI_MODEL is not set")
+	}
+	// Each provider in use needs its credentials
+	checkModel := func(path, model string) {
+		switch modelProvider(model) {
+		case PROVIDER_GOOGLEAI, "":
+			if missing(c.AI.APIKey, "GEMINI_API_TOKEN") {
+				add("ai.api_key", "empty and GEMINI_API_TOKEN is not set")
+			}
+		case PROVIDER_VERTEXAI:
+			if missing(c.AI.Vertex.Project, "GOOGLE_CLOUD_PROJECT") {
+				add("ai.vertex.project", "empty and GOOGLE_CLOUD_PROJECT is not set")
+			}
+			if missing(c.AI.Vertex.Location, "GOOGLE_CLOUD_LOCATION") {
+				add("ai.vertex.location", "empty and GOOGLE_CLOUD_LOCATION is not set")
+			}
+		case PROVIDER_OPENAI:
+			if c.AI.OpenAI.BaseURL == "" && missing(c.AI.OpenAI.APIKey, "OPENAI_API_KEY") {
+				add("ai.openai.api_key", "empty and OPENAI_API_KEY is not set")
+			}
+		case PROVIDER_AZURE:
+			if c.AI.Azure.Endpoint == "" {
+				add("ai.azure.endpoint", "empty")
+			} else if err := checkHTTPURL(c.AI.Azure.Endpoint); err != nil {
+				add("ai.azure.endpoint", "%v", err)
+			}
+			if missing(c.AI.Azure.APIKey, "AZURE_OPENAI_API_KEY") {
+				add("ai.azure.api_key", "empty and AZURE_OPENAI_API_KEY is not set")
+			}
+		case PROVIDER_ANTHROPIC:
+			if missing(c.AI.Anthropic.APIKey, "ANTHROPIC_API_KEY") {
+				add("ai.anthropic.api_key", "empty and ANTHROPIC_API_KEY is not set")
+			}
+		case PROVIDER_OLLAMA:
+			// a local server, no credentials
+		default:
+			add(path, "unknown provider in %q (want googleai/..., vertexai/..., openai/..., azure/..., anthropic/... or ollama/...)", model)
+		}
+	}
+	checkModel("ai.model", c.AI.Model)
+	for i, model := range c.AI.Fallback {
+		path := fmt.Sprintf("ai.fallback[%d]", i)
+		if model == "" || model == c.AI.Model {
+			add(path, "want a model other than ai.model, got %q", model)
+			continue
+		}
+		checkModel(path, model)
+	}
+	for model, p := range c.Costs.Prices {
+		if p.Input < 0 || p.Output < 0 {
+			add("costs.prices."+model, "prices must not be negative")
+		}
+	}
+	if c.Costs.Top < 0 {
+		add("costs.top", "must not be negative")
+	}
+	if s := c.Stories; s.Embedder != "" {
+		if modelProvider(s.Embedder) == PROVIDER_ANTHROPIC {
+			add("stories.embedder", "anthropic has no embedding models, got %q", s.Embedder)
+		} else {
+			checkModel("stories.embedder", s.Embedder)
+		}
+		if s.Threshold < 0 || s.Threshold > 1 {
+			add("stories.threshold", "want a similarity between 0 and 1, got %g", s.Threshold)
+		}
+		if s.Hours < 0 {
+			add("stories.hours", "must not be negative")
+		}
+	}
+	if len(c.Topics.List) > 0 && c.Topics.Model != "" {
+		checkModel("topics.model", c.Topics.Model)
+	}
+	topics := map[string]int{}
+	for i, t := range c.Topics.List {
+		path := fmt.Sprintf("topics.list[%d]", i)
+		if hashtag(t.Name) == "" {
+			add(path+".name", "want a name of letters, digits or dashes, got %q", t.Name)
+		} else if first, ok := topics[hashtag(t.Name)]; ok {
+			add(path+".name", "same hashtag as topics.list[%d] (%s)", first, c.Topics.List[first].Name)
+		} else {
+			topics[hashtag(t.Name)] = i
+		}
+		if _, ok := c.Channels[t.Channel]; t.Channel != "" && !ok {
+			add(path+".channel", "references unknown channel %q; define it under channels:", t.Channel)
+		}
+		if t.ThreadID < 0 {
+			add(path+".thread_id", "must not be negative")
+		}
+	}
+	if r := c.Relevance; r.Profile != "" || r.Threshold != 0 || r.Model != "" {
+		if r.Threshold < 0 || r.Threshold > 10 {
+			add("relevance.threshold", "want 0-10, got %d", r.Threshold)
+		}
+		if r.Profile == "" {
+			add("relevance.profile", "empty, so relevance scoring is off")
+		}
+		if r.Model != "" {
+			checkModel("relevance.model", r.Model)
+		}
+	}
+	if c.AI.Attempts < 0 || c.AI.RetryDelay < 0 {
+		add("ai", "attempts and retry_delay must not be negative")
+	}
+	for _, p := range []struct {
+		name      string
+		baseURL   string
+		maxTokens int
+	}{
+		{PROVIDER_OPENAI, c.AI.OpenAI.BaseURL, c.AI.OpenAI.MaxTokens},
+		{PROVIDER_ANTHROPIC, c.AI.Anthropic.BaseURL, c.AI.Anthropic.MaxTokens},
+		{PROVIDER_OLLAMA, c.AI.Ollama.BaseURL, c.AI.Ollama.MaxTokens},
+	} {
+		if p.baseURL != "" && !strings.HasPrefix(p.baseURL, "http://") && !strings.HasPrefix(p.baseURL, "https://") {
+			add("ai."+p.name+".base_url", "want an http(s) URL, got %q", p.baseURL)
+		}
+		if p.maxTokens < 0 {
+			add("ai."+p.name+".max_tokens", "must not be negative")
+		}
+	}
+	if o := c.AI.Ollama; o.ContextWindow < 0 {
+		add("ai.ollama.context_window", "must not be negative")
+	} else if window := cmp.Or(o.ContextWindow, OLLAMA_CONTEXT_WINDOW); cmp.Or(o.MaxTokens, OLLAMA_MAX_TOKENS) >= window {
+		add("ai.ollama.max_tokens", "leaves no room for the prompt in a %d-token context window", window)
+	}
+	for env, ref := range c.Secrets {
+		scheme, rest, ok := strings.Cut(ref, "://")
+		if !ok || rest == "" || (scheme != "vault" && scheme != "awssm" && scheme != "gcpsm") {
+			add("secrets."+env, "bad reference %q (want vault://path#field, awssm://id[#field] or gcpsm://projects/p/secrets/s[#field])", ref)
+		} else if scheme == "vault" && !strings.Contains(rest, "#") {
+			add("secrets."+env, "vault references need a #field")
+		}
+	}
+
+	if err := checkTone(c.Tone); err != nil {
+		add("tone", "%v", err)
+	}
+	if err := checkLength(c.Length); err != nil {
+		add("length", "%v", err)
+	}
+	for name, ch := range c.Channels {
+		if ch.ChatID == "" {
+			add("channels."+name, "chat id is empty")
+		}
+		if err := checkTone(ch.Tone); err != nil {
+			add("channels."+name+".tone", "%v", err)
+		}
+		if err := checkLength(ch.Length); err != nil {
+			add("channels."+name+".length", "%v", err)
+		}
+	}
+
+	seen := map[string]int{}
+	for i, feed := range c.Feeds {
+		path := fmt.Sprintf("feeds[%d]", i)
+		if err := checkHTTPURL(feed.URL); err != nil {
+			add(path+".url", "%v", err)
+			continue
+		}
+		key := feedKey(feed.URL)
+		if first, dup := seen[key]; dup {
+			add(path+".url", "duplicate of feeds[%d] (%s)", first, c.Feeds[first].URL)
+		} else {
+			seen[key] = i
+		}
+		if feed.Channel != "" {
+			if _, ok := c.Channels[feed.Channel]; !ok {
+				add(path+".channel", "references unknown channel %q; define it under channels:", feed.Channel)
+			}
+		}
+		if feed.Kind != FEED_ARTICLE && feed.Kind != FEED_RELEASE && feed.Kind != FEED_DOCS {
+			add(path+".kind", "unknown kind %q (expected release or docs, or leave it out)", feed.Kind)
+		}
+		if feed.Jobs != JOBS_KEEP && feed.Jobs != JOBS_DROP && feed.Jobs != JOBS_EXTRACT {
+			add(path+".jobs", "unknown value %q (expected drop or extract)", feed.Jobs)
+		}
+		if feed.MaxPosts < 0 {
+			add(path+".max_posts", "must not be negative")
+		}
+		if err := checkLength(feed.Length); err != nil {
+			add(path+".length", "%v", err)
+		}
+		if feed.Batch < 0 || feed.Batch > MAX_BATCH {
+			add(path+".batch", "want 0-%d, got %d", MAX_BATCH, feed.Batch)
+		}
+		if feed.MinRating < 0 || feed.MinRating > 10 {
+			add(path+".min_rating", "want 0-10, got %d", feed.MinRating)
+		}
+		if feed.PostDelay < 0 {
+			add(path+".post_delay", "must not be negative")
+		}
+		if feed.Timeout < 0 {
+			add(path+".timeout", "must not be negative")
+		}
+		if feed.TLS.CAFile != "" {
+			if _, err := loadCAFile(feed.TLS.CAFile); err != nil {
+				add(path+".tls.ca_file", "%v", err)
+			}
+		}
+		if feed.Proxy != "" {
+			if _, err := checkProxyURL(feed.Proxy); err != nil {
+				add(path+".proxy", "%v", err)
+			}
+		}
+		checkRedirectPolicy(add, path, feed.MaxRedirects, feed.OffDomain)
+		checkMessageTemplate(add, path+".template", feed.Template)
+		if feed.PromptFile != "" {
+			if _, err := loadPromptFile(feed.PromptFile); err != nil {
+				add(path+".prompt_file", "%v", err)
+			}
+		}
+	}
+	checkMessageTemplate(add, "message_template", c.MessageTemplate)
+	checkPrompts(add, c.Prompts)
+
+	if c.Fetch.Proxy != "" {
+		if _, err := checkProxyURL(c.Fetch.Proxy); err != nil {
+			add("fetch.proxy", "%v", err)
+		}
+	}
+	checkRedirectPolicy(add, "fetch", c.Fetch.MaxRedirects, c.Fetch.OffDomain)
+	if c.Fetch.Attempts < 0 {
+		add("fetch.attempts", "must not be negative, got %d", c.Fetch.Attempts)
+	}
+	if c.Fetch.HostRate < 0 || c.Fetch.HostBurst < 0 {
+		add("fetch", "host_rate and host_burst must not be negative")
+	}
+	for domain, rate := range c.Fetch.HostRates {
+		if rate <= 0 {
+			add("fetch.host_rates."+domain, "must be a positive number of requests per second, got %g", rate)
+		}
+	}
+	if t := c.Timeouts; t.Feed < 0 || t.Article < 0 || t.AI < 0 || t.Telegram < 0 {
+		add("timeouts", "feed, article, ai and telegram must not be negative")
+	}
+	if c.ArticleCache.TTL < 0 {
+		add("article_cache.ttl", "must not be negative, got %s", c.ArticleCache.TTL)
+	}
+	if c.Fetch.TLS.CAFile != "" {
+		if _, err := loadCAFile(c.Fetch.TLS.CAFile); err != nil {
+			add("fetch.tls.ca_file", "%v", err)
+		}
+	}
+	for i, entry := range c.Fetch.AllowNetworks {
+		if _, err := parseNetwork(entry); err != nil {
+			add(fmt.Sprintf("fetch.allow_networks[%d]", i), "want a CIDR or an IP address, got %q", entry)
+		}
+	}
+	if c.Fetch.Timeout < 0 {
+		add("fetch.timeout", "must not be negative, got %s", c.Fetch.Timeout)
+	}
+	if c.Fetch.RetryDelay < 0 {
+		add("fetch.retry_delay", "must not be negative, got %s", c.Fetch.RetryDelay)
+	}
+	for name, list := range map[string]FilterList{"filters.block": c.Filters.Block, "filters.allow": c.Filters.Allow} {
+		for i, u := range list.URLs {
+			if !isRemoteConfig(u) {
+				add(fmt.Sprintf("%s.urls[%d]", name, i), "want an https:// or s3:// URL, got %q", u)
+			}
+		}
+	}
+	if c.Filters.Refresh < 0 {
+		add("filters.refresh", "must not be negative, got %s", c.Filters.Refresh)
+	}
+	switch c.Network.IP {
+	case IP_ANY, IP_PREFER_IPV4, IP_PREFER_IPV6, IP_ONLY_IPV4, IP_ONLY_IPV6:
+	default:
+		add("network.ip", "unknown value %q (expected prefer-ipv4, prefer-ipv6, ipv4 or ipv6)", c.Network.IP)
+	}
+	if c.Network.Proxy != "" {
+		if _, err := checkProxyURL(c.Network.Proxy); err != nil {
+			add("network.proxy", "%v", err)
+		}
+	}
+	for i, server := range c.Network.DNS {
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			add(fmt.Sprintf("network.dns[%d]", i), "want an IP address (optionally with :port), got %q", server)
+		}
+	}
+	if c.Network.MaxIdlePerHost < 0 {
+		add("network.max_idle_per_host", "must not be negative, got %d", c.Network.MaxIdlePerHost)
+	}
+	if c.Network.IdleTimeout < 0 || c.Network.ConnectTimeout < 0 || c.Network.TLSTimeout < 0 {
+		add("network", "idle_timeout, connect_timeout and tls_timeout must not be negative")
+	}
+	if c.Network.DNSCacheTTL < 0 {
+		add("network.dns_cache_ttl", "must not be negative, got %s", c.Network.DNSCacheTTL)
+	}
+	if c.FeedBackoff.After < -1 {
+		add("feed_backoff.after", "must be -1 (never) or a number of runs, got %d", c.FeedBackoff.After)
+	}
+	if c.FeedBackoff.Base < 0 || c.FeedBackoff.Max < 0 {
+		add("feed_backoff", "durations must not be negative")
+	}
+	switch c.Moderation.Action {
+	case "", MODERATION_BLOCK, MODERATION_FLAG:
+	default:
+		add("moderation.action", "unknown value %q (expected block or flag)", c.Moderation.Action)
+	}
+	switch c.Moderation.Provider {
+	case "":
+	case PROVIDER_OPENAI:
+		if c.AI.OpenAI.APIKey == "" && c.AI.OpenAI.BaseURL == "" {
+			add("moderation.provider", "openai needs ai.openai.api_key or OPENAI_API_KEY")
+		}
+	default:
+		add("moderation.provider", "unknown provider %q (expected openai)", c.Moderation.Provider)
+	}
+	if c.MinRating < 0 || c.MinRating > 10 {
+		add("min_rating", "want 0-10, got %d", c.MinRating)
+	}
+	if c.Bandwidth.MaxDownloadKB < 0 {
+		add("bandwidth.max_download_kb", "must not be negative, got %d", c.Bandwidth.MaxDownloadKB)
+	}
+	if c.Retention.StateDays < 0 {
+		add("retention.state_days", "must not be negative, got %d", c.Retention.StateDays)
+	}
+	if c.State.SaveEvery < 0 {
+		add("state.save_every", "must not be negative, got %d", c.State.SaveEvery)
+	}
+	switch c.State.Lock {
+	case "", LOCK_SKIP, LOCK_WAIT, LOCK_NONE:
+	default:
+		add("state.lock", "unknown value %q (expected skip, wait or none)", c.State.Lock)
+	}
+	switch c.State.Backend {
+	case "", STATE_JSON, STATE_BOLT:
+	case STATE_REDIS:
+		if c.State.URL == "" {
+			add("state.url", "required for the redis backend")
+		}
+	case STATE_S3, STATE_GCS:
+		scheme := map[string]string{STATE_S3: "s3://", STATE_GCS: "gs://"}[c.State.Backend]
+		if !strings.HasPrefix(c.State.URL, scheme) {
+			add("state.url", "want %sbucket/key for the %s backend, got %q", scheme, c.State.Backend, c.State.URL)
+		}
+	default:
+		add("state.backend", "unknown backend %q (expected json, bolt, redis, s3 or gcs)", c.State.Backend)
+	}
+	if c.Schedule != "" {
+		if _, err := parseCron(c.Schedule); err != nil {
+			add("schedule", "%v", err)
+		}
+	}
+	if p := c.Project; p.Name == "" && (p.Blog != "" || p.GitHub != "" || len(p.Docs) > 0) {
+		add("project.name", "required to enable project mode")
+	}
+	if p := c.Project; p.GitHub != "" && strings.Count(strings.Trim(p.GitHub, "/"), "/") != 1 {
+		add("project.github", "want owner/repo, got %q", p.GitHub)
+	}
+	if c.Quiz.Schedule != "" {
+		if _, err := parseCron(c.Quiz.Schedule); err != nil {
+			add("quiz.schedule", "%v", err)
+		}
+	}
+	if n := c.Newsletter; n.Schedule != "" {
+		if _, err := parseCron(n.Schedule); err != nil {
+			add("newsletter.schedule", "%v", err)
+		}
+		if n.File == "" && n.Email.SMTP == "" && n.Telegraph.AccessToken == "" {
+			add("newsletter", "schedule is set but there is nowhere to deliver: set file, email.smtp or telegraph.access_token")
+		}
+	}
+	if e := c.Newsletter.Email; e.SMTP != "" {
+		if _, _, err := net.SplitHostPort(e.SMTP); err != nil {
+			add("newsletter.email.smtp", "want host:port, got %q", e.SMTP)
+		}
+		if e.From == "" || len(e.To) == 0 {
+			add("newsletter.email", "from and to are required to send email")
+		}
+	}
+	if ch := c.Newsletter.Telegraph.Channel; ch != "" {
+		if _, ok := c.Channels[ch]; !ok {
+			add("newsletter.telegraph.channel", "references unknown channel %q; define it under channels:", ch)
+		}
+	}
+	if c.Jobs.Channel != "" {
+		if _, ok := c.Channels[c.Jobs.Channel]; !ok {
+			add("jobs.channel", "references unknown channel %q; define it under channels:", c.Jobs.Channel)
+		}
+	}
+	if c.Quiz.Channel != "" {
+		if _, ok := c.Channels[c.Quiz.Channel]; !ok {
+			add("quiz.channel", "references unknown channel %q; define it under channels:", c.Quiz.Channel)
+		}
+	}
+	if c.Metrics.PushgatewayURL != "" {
+		if err := checkHTTPURL(c.Metrics.PushgatewayURL); err != nil {
+			add("metrics.pushgateway_url", "%v", err)
+		}
+	}
+	for path, addr := range map[string]string{"grpc.listen": c.GRPC.Listen, "api.listen": c.API.Listen} {
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			add(path, "bad listen address %q (want host:port or :port)", addr)
+		}
+	}
+	if c.GRPC.Listen != "" && c.GRPC.Listen == c.API.Listen {
+		add("api.listen", "same address as grpc.listen")
+	}
+	if _, err := newTranslators(c.Translation, nil, AIConfig{}); err != nil {
+		add("translation", "%v", strings.TrimPrefix(err.Error(), "translation: "))
+	}
+
+	return problems
+}
+
+func checkHTTPURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("URL is empty")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("malformed URL %q: %v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("malformed URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("malformed URL %q: missing host", raw)
+	}
+	return nil
+}
+
+// feedKey is the form used to spot duplicate feeds: scheme-less, lowercase host, no trailing slash
+func feedKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return strings.ToLower(u.Host) + strings.TrimRight(u.Path, "/") + "?" + u.RawQuery
+}
+
+// runConfigValidate implements `rss config validate`
+func runConfigValidate(path, profile string) int {
+	problems, err := validateConfigFile(path, profile)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return 1
+	}
+
+	label := path
+	if profile != "" {
+		label += " (profile " + profile + ")"
+	}
+	if len(problems) == 0 {
+		fmt.Printf("✅ %s is valid\n", label)
+		return 0
+	}
+
+	fmt.Printf("❌ %s has %d problem(s):\n", label, len(problems))
+	for _, p := range problems {
+		fmt.Printf("   • %s\n", p)
+	}
+	return 1
+}
+
+// checkMessageTemplate parses a template and runs it on a sample post, which
+// catches misspelled fields and bad function arguments
+func checkMessageTemplate(add func(path, format string, args ...any), path, text string) {
+	if text == "" {
+		return
+	}
+	tmpl, err := parseMessageTemplate(text)
+	if err == nil {
+		_, err = renderMessage(tmpl, MessageData{
+			Title:     "Sample title",
+			Link:      "https://example.com/post",
+			Summary:   "Sample summary.",
+			Published: time.Now().Add(-time.Hour),
+		})
+	}
+	if err != nil {
+		add(path, "%v", err)
+	}
+}
+
+func checkRedirectPolicy(add func(path, format string, args ...any), path string, maxRedirects int, offDomain string) {
+	if maxRedirects < -1 {
+		add(path+".max_redirects", "want -1 (none) or more, got %d", maxRedirects)
+	}
+	switch offDomain {
+	case "", REDIRECT_ALLOW, REDIRECT_DENY, REDIRECT_CANONICAL:
+	default:
+		add(path+".off_domain", "unknown policy %q (expected allow, deny or canonical)", offDomain)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/auth/credentials"
+)
+
+// Gemini can also be reached through Vertex AI, for Google Cloud projects
+// that may not use API keys: models are named vertexai/gemini-2.5-flash and
+// so on, and calls are billed to the project. Auth is application default
+// credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud auth
+// application-default login, or the metadata server on GCP), or the service
+// account key file given in ai.vertex.credentials.
+const PROVIDER_VERTEXAI = "vertexai"
+
+type VertexConfig struct {
+	Project     string `yaml:"project"`     // Google Cloud project; default GOOGLE_CLOUD_PROJECT
+	Location    string `yaml:"location"`    // region, e.g. us-central1 or global; default GOOGLE_CLOUD_LOCATION
+	Credentials string `yaml:"credentials"` // service account key file; empty uses application default credentials
+}
+
+func (c VertexConfig) enabled() bool {
+	return c.Project != "" && c.Location != ""
+}
+
+// checkCredentials finds the credentials genkit's plugin will use, which
+// panics instead of failing when there are none
+func (c VertexConfig) checkCredentials() error {
+	if c.Credentials != "" {
+		// the plugin only looks at the environment
+		if err := os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", c.Credentials); err != nil {
+			return err
+		}
+	}
+	_, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		return fmt.Errorf("vertexai: no Google Cloud credentials (set ai.vertex.credentials or run gcloud auth application-default login): %w", err)
+	}
+	return nil
+}
+
+// googleAIModel is the Google AI name of a Vertex AI model, which costs the same
+func googleAIModel(model string) string {
+	return PROVIDER_GOOGLEAI + "/" + strings.TrimPrefix(model, PROVIDER_VERTEXAI+"/")
+}